@@ -0,0 +1,168 @@
+package brokertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// listEnvironments lists the environments.
+func (s *Server) listEnvironments(w http.ResponseWriter) {
+	environments := append([]types.Environment{}, s.environments...)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_embedded": map[string]interface{}{"environments": environments},
+	})
+}
+
+// createEnvironment creates an environment, assigning it a UUID.
+func (s *Server) createEnvironment(w http.ResponseWriter, r *http.Request) {
+	var environment types.Environment
+	if err := json.NewDecoder(r.Body).Decode(&environment); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if environment.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if _, ok := s.findEnvironment(environment.Name); ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("environment '%s' already exists", environment.Name))
+		return
+	}
+
+	s.sequence++
+	environment.UUID = fmt.Sprintf("environment-%d", s.sequence)
+	s.environments = append(s.environments, environment)
+
+	writeJSON(w, http.StatusCreated, environment)
+}
+
+// findEnvironment finds an environment by name.
+func (s *Server) findEnvironment(name string) (types.Environment, bool) {
+	for _, e := range s.environments {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return types.Environment{}, false
+}
+
+// recordDeployment records the deployment or release of a pacticipant
+// version to an environment. A deployment replaces the version previously
+// deployed to the environment, for the same application instance.
+func (s *Server) recordDeployment(w http.ResponseWriter, r *http.Request, name string, number string, uuid string, released bool) {
+	v := s.findVersion(name, number)
+	if v == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("version %s of %s does not exist", number, name))
+		return
+	}
+
+	var body struct {
+		ApplicationInstance string `json:"applicationInstance"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	if !released {
+		for _, d := range s.deployments {
+			if !d.released && d.environment == uuid && d.version.pacticipant == name && d.applicationInstance == body.ApplicationInstance {
+				d.current = false
+			}
+		}
+	}
+
+	s.sequence++
+	d := &deployment{
+		uuid:                fmt.Sprintf("deployment-%d", s.sequence),
+		released:            released,
+		version:             v,
+		environment:         uuid,
+		applicationInstance: body.ApplicationInstance,
+		current:             true,
+	}
+	s.deployments = append(s.deployments, d)
+
+	writeJSON(w, http.StatusCreated, s.deploymentResource(d))
+}
+
+// currentlyDeployed lists the versions currently deployed to an environment,
+// optionally filtered by pacticipant and application instance.
+func (s *Server) currentlyDeployed(w http.ResponseWriter, r *http.Request, uuid string) {
+	query := r.URL.Query()
+
+	var deployed []map[string]interface{}
+	for _, d := range s.deployments {
+		if d.released || !d.current || d.environment != uuid {
+			continue
+		}
+		if pacticipant := query.Get("pacticipant"); pacticipant != "" && d.version.pacticipant != pacticipant {
+			continue
+		}
+		if instance, ok := query["applicationInstance"]; ok && d.applicationInstance != instance[0] {
+			continue
+		}
+		deployed = append(deployed, s.deploymentResource(d))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_embedded": map[string]interface{}{"deployedVersions": deployed},
+	})
+}
+
+// updateDeployment marks a deployed version as no longer deployed.
+func (s *Server) updateDeployment(w http.ResponseWriter, r *http.Request, uuid string) {
+	var body struct {
+		CurrentlyDeployed *bool `json:"currentlyDeployed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, d := range s.deployments {
+		if d.uuid == uuid && !d.released {
+			if body.CurrentlyDeployed != nil && !*body.CurrentlyDeployed {
+				d.current = false
+			}
+			writeJSON(w, http.StatusOK, s.deploymentResource(d))
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("deployed version %s does not exist", uuid))
+}
+
+// deploymentResource describes a deployed or released version.
+func (s *Server) deploymentResource(d *deployment) map[string]interface{} {
+	resource := map[string]interface{}{
+		"uuid":    d.uuid,
+		"version": map[string]string{"number": d.version.number},
+	}
+	if d.released {
+		resource["currentlySupported"] = d.current
+		resource["_links"] = map[string]interface{}{"self": link(fmt.Sprintf("%s/released-versions/%s", s.URL, d.uuid))}
+	} else {
+		resource["currentlyDeployed"] = d.current
+		resource["applicationInstance"] = d.applicationInstance
+		resource["_links"] = map[string]interface{}{"self": link(fmt.Sprintf("%s/deployed-versions/%s", s.URL, d.uuid))}
+	}
+	return resource
+}
+
+// inEnvironment reports whether the version is currently deployed or
+// released to the named environment.
+func (s *Server) inEnvironment(v *version, name string) bool {
+	environment, ok := s.findEnvironment(name)
+	if !ok {
+		return false
+	}
+	for _, d := range s.deployments {
+		if d.current && d.version == v && d.environment == environment.UUID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package brokertest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// matrix answers a can-i-deploy query for a single pacticipant version,
+// against the latest versions (optionally with a tag) or the versions in an
+// environment of the pacticipants it integrates with.
+func (s *Server) matrix(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("q[][pacticipant]")
+
+	var selected *version
+	switch {
+	case query.Get("q[][version]") != "":
+		selected = s.findVersion(name, query.Get("q[][version]"))
+	case query.Get("q[][tag]") != "":
+		tag := query.Get("q[][tag]")
+		selected = s.latestVersion(name, func(v *version) bool { return v.hasTag(tag) })
+	default:
+		selected = s.latestVersion(name, nil)
+	}
+
+	if selected == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("no matching version of %s found", name))
+		return
+	}
+
+	environment, tag := query.Get("environment"), query.Get("tag")
+	if environment != "" {
+		if _, ok := s.findEnvironment(environment); !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("environment '%s' does not exist", environment))
+			return
+		}
+	}
+
+	// The versions of another pacticipant that the selected version will be
+	// deployed alongside.
+	targets := func(pacticipant string) []*version {
+		if environment != "" {
+			var versions []*version
+			for _, v := range s.pacticipants[pacticipant].versions {
+				if s.inEnvironment(v, environment) {
+					versions = append(versions, v)
+				}
+			}
+			return versions
+		}
+
+		v := s.latestVersion(pacticipant, func(v *version) bool {
+			return tag == "" || v.hasTag(tag)
+		})
+		if v == nil {
+			return nil
+		}
+		return []*version{v}
+	}
+
+	var response types.MatrixResponse
+	response.Matrix = []types.MatrixRow{}
+
+	// The selected version as a consumer
+	for _, p := range s.pacts {
+		if p.consumer != name || p.version != selected {
+			continue
+		}
+		for _, providerVersion := range targets(p.provider) {
+			response.Matrix = append(response.Matrix, s.matrixRow(p, providerVersion))
+		}
+	}
+
+	// The selected version as a provider
+	for _, consumer := range s.sortedPacticipants() {
+		if consumer == name {
+			continue
+		}
+		for _, consumerVersion := range targets(consumer) {
+			if p := s.findPact(consumer, name, consumerVersion.number); p != nil {
+				response.Matrix = append(response.Matrix, s.matrixRow(p, selected))
+			}
+		}
+	}
+
+	for _, row := range response.Matrix {
+		switch {
+		case row.VerificationResult == nil:
+			response.Summary.Unknown++
+		case row.VerificationResult.Success:
+			response.Summary.Success++
+		default:
+			response.Summary.Failed++
+		}
+	}
+
+	response.Summary.Deployable = response.Summary.Failed == 0 && response.Summary.Unknown == 0
+	switch {
+	case len(response.Matrix) == 0:
+		response.Summary.Reason = "There are no missing dependencies"
+	case response.Summary.Failed > 0:
+		response.Summary.Reason = "One or more of the verifications failed"
+	case response.Summary.Unknown > 0:
+		response.Summary.Reason = "Missing one or more required verification results"
+	default:
+		response.Summary.Reason = "All required verification results are published and successful"
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// matrixRow describes the latest verification of a pact by a provider version.
+func (s *Server) matrixRow(p *pact, providerVersion *version) types.MatrixRow {
+	var row types.MatrixRow
+	row.Consumer.Name = p.consumer
+	row.Consumer.Version.Number = p.version.number
+	row.Provider.Name = p.provider
+	row.Provider.Version.Number = providerVersion.number
+
+	if v := s.latestVerification(p, providerVersion); v != nil {
+		row.VerificationResult = &types.MatrixVerificationResult{
+			Success:    v.result.Success,
+			VerifiedAt: v.verifiedAt.Format(time.RFC3339),
+		}
+		row.VerificationResult.Links.Self.Href = s.verificationURL(p, v)
+	}
+
+	return row
+}
@@ -0,0 +1,467 @@
+package brokertest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// publishContractsRequest is the body of a request to publish contracts.
+type publishContractsRequest struct {
+	PacticipantName          string   `json:"pacticipantName"`
+	PacticipantVersionNumber string   `json:"pacticipantVersionNumber"`
+	Branch                   string   `json:"branch"`
+	Tags                     []string `json:"tags"`
+	BuildURL                 string   `json:"buildUrl"`
+	Contracts                []struct {
+		ConsumerName  string `json:"consumerName"`
+		ProviderName  string `json:"providerName"`
+		Specification string `json:"specification"`
+		Content       string `json:"content"`
+	} `json:"contracts"`
+}
+
+// publishContracts publishes the pacts of a consumer version, along with
+// its branch, tags and build URL.
+func (s *Server) publishContracts(w http.ResponseWriter, r *http.Request) {
+	var body publishContractsRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if body.PacticipantName == "" || body.PacticipantVersionNumber == "" {
+		writeError(w, http.StatusBadRequest, "pacticipantName and pacticipantVersionNumber are required")
+		return
+	}
+
+	v := s.version(body.PacticipantName, body.PacticipantVersionNumber)
+	if body.Branch != "" {
+		v.branch = body.Branch
+	}
+	if body.BuildURL != "" {
+		v.buildURL = body.BuildURL
+	}
+	for _, tag := range body.Tags {
+		s.tagVersion(v, tag)
+	}
+
+	var notices []map[string]string
+	var pacts []map[string]interface{}
+	for _, contract := range body.Contracts {
+		content, err := base64.StdEncoding.DecodeString(contract.Content)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		p, err := s.publishPact(body.PacticipantVersionNumber, content)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		notices = append(notices, map[string]string{
+			"type": "success",
+			"text": fmt.Sprintf("Pact published for %s version %s and provider %s.", p.consumer, p.version.number, p.provider),
+		})
+		pacts = append(pacts, map[string]interface{}{"_links": map[string]interface{}{"self": link(s.pactURL(p))}})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"notices":   notices,
+		"_embedded": map[string]interface{}{"pacts": pacts},
+	})
+}
+
+// putPact publishes a pact for a consumer version.
+func (s *Server) putPact(w http.ResponseWriter, r *http.Request, provider string, consumer string, number string) {
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, p, err := pacticipantNames(content)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c != consumer || p != provider {
+		writeError(w, http.StatusBadRequest, "the consumer and provider in the pact do not match the URL")
+		return
+	}
+
+	published, err := s.publishPact(number, content)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.getPact(w, published)
+}
+
+// publishPact stores the pact for the consumer version, replacing any
+// previously published for the same consumer version and provider.
+func (s *Server) publishPact(number string, content []byte) (*pact, error) {
+	consumer, provider, err := pacticipantNames(content)
+	if err != nil {
+		return nil, err
+	}
+
+	s.pacticipant(provider)
+	published := &pact{
+		consumer:  consumer,
+		provider:  provider,
+		version:   s.version(consumer, number),
+		content:   content,
+		sha:       sha(content),
+		createdAt: time.Now(),
+	}
+
+	for i, p := range s.pacts {
+		if p.consumer == consumer && p.provider == provider && p.version.number == number {
+			s.pacts[i] = published
+			return published, nil
+		}
+	}
+	s.pacts = append(s.pacts, published)
+
+	return published, nil
+}
+
+// getPact writes the pact, with links to publish verification results.
+func (s *Server) getPact(w http.ResponseWriter, p *pact) {
+	if p == nil {
+		writeError(w, http.StatusNotFound, "pact does not exist")
+		return
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(p.content, &doc); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	doc["_links"] = map[string]interface{}{
+		"self":                            link(s.pactURL(p)),
+		"pb:consumer-version":             link(s.versionURL(p.version)),
+		"pb:publish-verification-results": link(s.pactVersionURL(p) + "/verification-results"),
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// findPact finds the pact for a consumer version.
+func (s *Server) findPact(consumer string, provider string, number string) *pact {
+	for _, p := range s.pacts {
+		if p.consumer == consumer && p.provider == provider && p.version.number == number {
+			return p
+		}
+	}
+	return nil
+}
+
+// findPactBySHA finds the latest pact with the given content.
+func (s *Server) findPactBySHA(consumer string, provider string, sha string) *pact {
+	var found *pact
+	for _, p := range s.pacts {
+		if p.consumer == consumer && p.provider == provider && p.sha == sha && (found == nil || p.version.order > found.version.order) {
+			found = p
+		}
+	}
+	return found
+}
+
+// latestPact finds the pact of the latest consumer version accepted by the
+// filter.
+func (s *Server) latestPact(consumer string, provider string, filter func(*version) bool) *pact {
+	var latest *pact
+	for _, p := range s.pacts {
+		if p.consumer == consumer && p.provider == provider && (filter == nil || filter(p.version)) {
+			if latest == nil || p.version.order > latest.version.order {
+				latest = p
+			}
+		}
+	}
+	return latest
+}
+
+// pactURL is the URL of the pact for a consumer version.
+func (s *Server) pactURL(p *pact) string {
+	return fmt.Sprintf("%s/pacts/provider/%s/consumer/%s/version/%s", s.URL, url.PathEscape(p.provider), url.PathEscape(p.consumer), url.PathEscape(p.version.number))
+}
+
+// pactVersionURL is the URL of the content of a pact, shared by every
+// consumer version that published the same content.
+func (s *Server) pactVersionURL(p *pact) string {
+	return fmt.Sprintf("%s/pacts/provider/%s/consumer/%s/pact-version/%s", s.URL, url.PathEscape(p.provider), url.PathEscape(p.consumer), p.sha)
+}
+
+// latestPacts lists the latest pact of each consumer of the provider,
+// optionally only considering consumer versions with the tag.
+func (s *Server) latestPacts(w http.ResponseWriter, provider string, tag string) {
+	var links []map[string]string
+	for _, consumer := range s.sortedPacticipants() {
+		p := s.latestPact(consumer, provider, func(v *version) bool {
+			return tag == "" || v.hasTag(tag)
+		})
+		if p != nil {
+			links = append(links, map[string]string{
+				"href":  s.pactURL(p),
+				"title": fmt.Sprintf("Pact between %s (%s) and %s", p.consumer, p.version.number, p.provider),
+				"name":  p.consumer,
+			})
+		}
+	}
+
+	if len(links) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no pacts found for provider %s", provider))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_links": map[string]interface{}{"pb:pacts": links},
+	})
+}
+
+// pactsForVerificationRequest is the body of a request for the pacts to verify.
+type pactsForVerificationRequest struct {
+	ConsumerVersionSelectors []struct {
+		Tag    string `json:"tag"`
+		Branch string `json:"branch"`
+		Latest bool   `json:"latest"`
+	} `json:"consumerVersionSelectors"`
+	ProviderVersionTags   []string `json:"providerVersionTags"`
+	ProviderVersionBranch string   `json:"providerVersionBranch"`
+	IncludePendingStatus  bool     `json:"includePendingStatus"`
+	IncludeWIPPactsSince  string   `json:"includeWipPactsSince"`
+}
+
+// pactsForVerification finds the pacts a provider should verify, along with
+// whether they are pending or work in progress.
+func (s *Server) pactsForVerification(w http.ResponseWriter, r *http.Request, provider string) {
+	var body pactsForVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var selected []*pact
+	seen := make(map[string]bool)
+	add := func(p *pact) bool {
+		if p == nil || seen[p.sha] {
+			return false
+		}
+		seen[p.sha] = true
+		selected = append(selected, p)
+		return true
+	}
+
+	for _, consumer := range s.sortedPacticipants() {
+		if len(body.ConsumerVersionSelectors) == 0 {
+			add(s.latestPact(consumer, provider, nil))
+			continue
+		}
+
+		for _, selector := range body.ConsumerVersionSelectors {
+			tag, branch := selector.Tag, selector.Branch
+			filter := func(v *version) bool {
+				return (tag == "" || v.hasTag(tag)) && (branch == "" || v.branch == branch)
+			}
+
+			if selector.Latest || branch != "" {
+				add(s.latestPact(consumer, provider, filter))
+				continue
+			}
+
+			for _, p := range s.pacts {
+				if p.consumer == consumer && p.provider == provider && filter(p.version) {
+					add(p)
+				}
+			}
+		}
+	}
+
+	var pacts []map[string]interface{}
+	for _, p := range selected {
+		pending := body.IncludePendingStatus && s.isPending(p, body.ProviderVersionTags, body.ProviderVersionBranch)
+		pacts = append(pacts, s.pactForVerification(p, pending, false))
+	}
+
+	if body.IncludeWIPPactsSince != "" {
+		since, err := time.Parse(time.RFC3339, body.IncludeWIPPactsSince)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		for _, p := range s.wipPacts(provider, since) {
+			if s.isPending(p, body.ProviderVersionTags, body.ProviderVersionBranch) && add(p) {
+				pacts = append(pacts, s.pactForVerification(p, true, true))
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_embedded": map[string]interface{}{"pacts": pacts},
+	})
+}
+
+// wipPacts finds the latest pact of each tag and branch of the consumers of
+// the provider, that were published since the given time.
+func (s *Server) wipPacts(provider string, since time.Time) []*pact {
+	type head struct {
+		tag    string
+		branch string
+	}
+
+	var pacts []*pact
+	for _, consumer := range s.sortedPacticipants() {
+		var heads []head
+		seen := make(map[head]bool)
+		for _, p := range s.pacts {
+			if p.consumer != consumer || p.provider != provider {
+				continue
+			}
+			candidates := []head{{branch: p.version.branch}}
+			for _, tag := range p.version.tags {
+				candidates = append(candidates, head{tag: tag})
+			}
+			for _, h := range candidates {
+				if h != (head{}) && !seen[h] {
+					seen[h] = true
+					heads = append(heads, h)
+				}
+			}
+		}
+
+		for _, h := range heads {
+			h := h
+			p := s.latestPact(consumer, provider, func(v *version) bool {
+				return (h.tag == "" || v.hasTag(h.tag)) && (h.branch == "" || v.branch == h.branch)
+			})
+			if p != nil && !p.createdAt.Before(since) {
+				pacts = append(pacts, p)
+			}
+		}
+	}
+	return pacts
+}
+
+// isPending reports whether the pact has not been successfully verified by
+// a provider version with one of the tags, or on the branch. With no tags or
+// branch, any successful verification counts.
+func (s *Server) isPending(p *pact, tags []string, branch string) bool {
+	for _, v := range s.verifications {
+		if v.sha != p.sha || v.provider != p.provider || !v.result.Success {
+			continue
+		}
+		if len(tags) == 0 && branch == "" {
+			return false
+		}
+		if branch != "" && v.providerVersion.branch == branch {
+			return false
+		}
+		for _, tag := range tags {
+			if v.providerVersion.hasTag(tag) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pactForVerification describes a pact in the pacts for verification response.
+func (s *Server) pactForVerification(p *pact, pending bool, wip bool) map[string]interface{} {
+	notice := fmt.Sprintf("The pact at %s is being verified because it is the latest matching pact of %s.", s.pactURL(p), p.consumer)
+	switch {
+	case wip:
+		notice = fmt.Sprintf("The pact at %s is being verified because it is a work in progress pact (ie. it is pending and was published after the WIP date).", s.pactURL(p))
+	case pending:
+		notice = fmt.Sprintf("The pact at %s is pending, as it has not yet been successfully verified. Failures will not cause the verification to fail.", s.pactURL(p))
+	}
+
+	properties := map[string]interface{}{
+		"pending": pending,
+		"notices": []map[string]string{{"when": "before_verification", "text": notice}},
+	}
+	if wip {
+		properties["wip"] = true
+	}
+
+	return map[string]interface{}{
+		"shortDescription":       fmt.Sprintf("latest pact of %s version %s", p.consumer, p.version.number),
+		"verificationProperties": properties,
+		"_links":                 map[string]interface{}{"self": link(s.pactURL(p))},
+	}
+}
+
+// publishVerificationResult records the result of a provider version
+// verifying the content of a pact.
+func (s *Server) publishVerificationResult(w http.ResponseWriter, r *http.Request, consumer string, provider string, sha string) {
+	p := s.findPactBySHA(consumer, provider, sha)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "pact does not exist")
+		return
+	}
+
+	var result types.VerificationResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if result.ProviderApplicationVersion == "" {
+		writeError(w, http.StatusBadRequest, "providerApplicationVersion is required")
+		return
+	}
+
+	providerVersion := s.version(provider, result.ProviderApplicationVersion)
+	if result.ProviderVersionBranch != "" {
+		providerVersion.branch = result.ProviderVersionBranch
+	}
+	if result.BuildURL != "" {
+		providerVersion.buildURL = result.BuildURL
+	}
+
+	s.sequence++
+	v := &verification{
+		id:              s.sequence,
+		consumer:        consumer,
+		provider:        provider,
+		sha:             sha,
+		providerVersion: providerVersion,
+		result:          result,
+		verifiedAt:      time.Now(),
+	}
+	s.verifications = append(s.verifications, v)
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"success":    result.Success,
+		"verifiedAt": v.verifiedAt.Format(time.RFC3339),
+		"_links":     map[string]interface{}{"self": link(s.verificationURL(p, v))},
+	})
+}
+
+// latestVerification finds the latest verification of the pact by the
+// provider version, or nil if it has not been verified.
+func (s *Server) latestVerification(p *pact, providerVersion *version) *verification {
+	var latest *verification
+	for _, v := range s.verifications {
+		if v.sha == p.sha && v.provider == p.provider && v.providerVersion == providerVersion {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// verificationURL is the URL of a verification result.
+func (s *Server) verificationURL(p *pact, v *verification) string {
+	return fmt.Sprintf("%s/verification-results/%d", s.pactVersionURL(p), v.id)
+}
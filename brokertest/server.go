@@ -0,0 +1,465 @@
+/*
+Package brokertest provides an in-memory Pact Broker for use in tests and
+offline development.
+
+It implements the subset of the Pact Broker HAL API used by pact-go:
+publishing and tagging pacts, finding the latest pacts for a provider (by tag,
+or using the pacts for verification API), publishing verification results,
+querying the matrix (can-i-deploy) and recording deployments to environments.
+
+	broker := brokertest.NewServer()
+	defer broker.Close()
+
+	p := dsl.Publisher{}
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{"./pacts"},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+	})
+*/
+package brokertest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// Server is an in-memory Pact Broker.
+type Server struct {
+	// URL of the Broker, e.g. http://127.0.0.1:1234
+	URL string
+
+	// Username and Password, if set, are required as basic authentication
+	// credentials on every request.
+	Username string
+	Password string
+
+	server        *httptest.Server
+	mu            sync.Mutex
+	sequence      int
+	pacticipants  map[string]*pacticipant
+	pacts         []*pact
+	verifications []*verification
+	environments  []types.Environment
+	deployments   []*deployment
+}
+
+// pacticipant is a consumer or provider application.
+type pacticipant struct {
+	name     string
+	versions map[string]*version
+}
+
+// version is a version of a pacticipant.
+type version struct {
+	pacticipant string
+	number      string
+	branch      string
+	buildURL    string
+	tags        []string
+	order       int
+}
+
+// hasTag reports whether the version has the tag.
+func (v *version) hasTag(tag string) bool {
+	for _, t := range v.tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// pact is a pact published for a consumer version.
+type pact struct {
+	consumer  string
+	provider  string
+	version   *version
+	content   []byte
+	sha       string
+	createdAt time.Time
+}
+
+// verification is the result of a provider version verifying a pact.
+type verification struct {
+	id              int
+	consumer        string
+	provider        string
+	sha             string
+	providerVersion *version
+	result          types.VerificationResult
+	verifiedAt      time.Time
+}
+
+// deployment is a pacticipant version deployed or released to an environment.
+type deployment struct {
+	uuid                string
+	released            bool
+	version             *version
+	environment         string
+	applicationInstance string
+	current             bool
+}
+
+// NewServer starts an in-memory Pact Broker. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		pacticipants: make(map[string]*pacticipant),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close shuts down the Broker.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// PublishPact publishes a pact for the given consumer version, tagging the
+// version with any given tags. It is useful for setting up test fixtures.
+func (s *Server) PublishPact(consumerVersion string, content []byte, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.publishPact(consumerVersion, content)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		s.tagVersion(p.version, tag)
+	}
+
+	return nil
+}
+
+// Pact returns the content of the pact published for the consumer version,
+// or nil if there is none.
+func (s *Server) Pact(consumer string, provider string, consumerVersion string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.findPact(consumer, provider, consumerVersion); p != nil {
+		return p.content
+	}
+	return nil
+}
+
+// Tags returns the tags of a pacticipant version.
+func (s *Server) Tags(pacticipant string, number string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := s.findVersion(pacticipant, number); v != nil {
+		return append([]string(nil), v.tags...)
+	}
+	return nil
+}
+
+// Branch returns the branch of a pacticipant version.
+func (s *Server) Branch(pacticipant string, number string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := s.findVersion(pacticipant, number); v != nil {
+		return v.branch
+	}
+	return ""
+}
+
+// VerificationResults returns the verification results published for the
+// pacts between the consumer and provider, in the order they were published.
+func (s *Server) VerificationResults(consumer string, provider string) []types.VerificationResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []types.VerificationResult
+	for _, v := range s.verifications {
+		if v.consumer == consumer && v.provider == provider {
+			results = append(results, v.result)
+		}
+	}
+	return results
+}
+
+// handle routes a request to the Broker.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	log.Println("[DEBUG] broker test server:", r.Method, r.URL.Path)
+
+	if s.Username != "" || s.Password != "" {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != s.Username || password != s.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var params []string
+	route := func(method string, pattern string) bool {
+		var ok bool
+		params, ok = match(path, pattern)
+		return ok && r.Method == method
+	}
+
+	switch {
+	case route("GET", ""):
+		s.index(w, r)
+	case route("POST", "contracts/publish"):
+		s.publishContracts(w, r)
+	case route("PUT", "pacts/provider/*/consumer/*/version/*"):
+		s.putPact(w, r, params[0], params[1], params[2])
+	case route("GET", "pacts/provider/*/consumer/*/version/*"):
+		s.getPact(w, s.findPact(params[1], params[0], params[2]))
+	case route("GET", "pacts/provider/*/consumer/*/pact-version/*"):
+		s.getPact(w, s.findPactBySHA(params[1], params[0], params[2]))
+	case route("POST", "pacts/provider/*/consumer/*/pact-version/*/verification-results"):
+		s.publishVerificationResult(w, r, params[1], params[0], params[2])
+	case route("GET", "pacts/provider/*/latest"):
+		s.latestPacts(w, params[0], "")
+	case route("GET", "pacts/provider/*/latest/*"):
+		s.latestPacts(w, params[0], params[1])
+	case route("POST", "pacts/provider/*/for-verification"):
+		s.pactsForVerification(w, r, params[0])
+	case route("GET", "pacticipants/*/versions/*"):
+		s.getVersion(w, params[0], params[1])
+	case route("PUT", "pacticipants/*/versions/*"), route("PATCH", "pacticipants/*/versions/*"):
+		s.putVersion(w, r, params[0], params[1])
+	case route("PUT", "pacticipants/*/versions/*/tags/*"):
+		s.tagVersion(s.version(params[0], params[1]), params[2])
+		writeJSON(w, http.StatusCreated, map[string]string{"name": params[2]})
+	case route("PUT", "pacticipants/*/branches/*/versions/*"):
+		s.version(params[0], params[2]).branch = params[1]
+		writeJSON(w, http.StatusOK, map[string]string{"number": params[2]})
+	case route("POST", "pacticipants/*/versions/*/deployed-versions/environment/*"):
+		s.recordDeployment(w, r, params[0], params[1], params[2], false)
+	case route("POST", "pacticipants/*/versions/*/released-versions/environment/*"):
+		s.recordDeployment(w, r, params[0], params[1], params[2], true)
+	case route("GET", "matrix"):
+		s.matrix(w, r)
+	case route("GET", "environments"):
+		s.listEnvironments(w)
+	case route("POST", "environments"):
+		s.createEnvironment(w, r)
+	case route("GET", "environments/*/deployed-versions/currently-deployed"):
+		s.currentlyDeployed(w, r, params[0])
+	case route("PATCH", "deployed-versions/*"):
+		s.updateDeployment(w, r, params[0])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// match matches the path segments against a pattern, where "*" matches any
+// single segment. The matched segments are returned.
+func match(path []string, pattern string) ([]string, bool) {
+	segments := strings.Split(pattern, "/")
+	if len(segments) != len(path) {
+		return nil, false
+	}
+
+	var params []string
+	for i, segment := range segments {
+		switch {
+		case segment == "*":
+			params = append(params, path[i])
+		case segment != path[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// index is the entry point of the HAL API.
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_links": map[string]interface{}{
+			"self":                               link(s.URL),
+			"pb:publish-contracts":               link(s.URL + "/contracts/publish"),
+			"pb:latest-provider-pacts":           link(s.URL + "/pacts/provider/{provider}/latest"),
+			"pb:provider-pacts-for-verification": link(s.URL + "/pacts/provider/{provider}/for-verification"),
+			"pb:environments":                    link(s.URL + "/environments"),
+			"pb:matrix":                          link(s.URL + "/matrix"),
+		},
+	})
+}
+
+// pacticipant returns the named pacticipant, creating it if required.
+func (s *Server) pacticipant(name string) *pacticipant {
+	p, ok := s.pacticipants[name]
+	if !ok {
+		p = &pacticipant{name: name, versions: make(map[string]*version)}
+		s.pacticipants[name] = p
+	}
+	return p
+}
+
+// version returns the pacticipant version, creating it if required.
+func (s *Server) version(name string, number string) *version {
+	p := s.pacticipant(name)
+	v, ok := p.versions[number]
+	if !ok {
+		s.sequence++
+		v = &version{pacticipant: name, number: number, order: s.sequence}
+		p.versions[number] = v
+	}
+	return v
+}
+
+// findVersion returns the pacticipant version, or nil if it does not exist.
+func (s *Server) findVersion(name string, number string) *version {
+	if p, ok := s.pacticipants[name]; ok {
+		return p.versions[number]
+	}
+	return nil
+}
+
+// latestVersion returns the latest version of the pacticipant accepted by
+// the filter, or nil if there is none.
+func (s *Server) latestVersion(name string, filter func(*version) bool) *version {
+	var latest *version
+	if p, ok := s.pacticipants[name]; ok {
+		for _, v := range p.versions {
+			if (filter == nil || filter(v)) && (latest == nil || v.order > latest.order) {
+				latest = v
+			}
+		}
+	}
+	return latest
+}
+
+// tagVersion adds a tag to the version.
+func (s *Server) tagVersion(v *version, tag string) {
+	if !v.hasTag(tag) {
+		v.tags = append(v.tags, tag)
+	}
+}
+
+// getVersion returns a pacticipant version, with links to record
+// deployments and releases to each environment.
+func (s *Server) getVersion(w http.ResponseWriter, name string, number string) {
+	v := s.findVersion(name, number)
+	if v == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("version %s of %s does not exist", number, name))
+		return
+	}
+
+	var deploy, release []map[string]string
+	for _, e := range s.environments {
+		deploy = append(deploy, map[string]string{
+			"name": e.Name,
+			"href": fmt.Sprintf("%s/deployed-versions/environment/%s", s.versionURL(v), e.UUID),
+		})
+		release = append(release, map[string]string{
+			"name": e.Name,
+			"href": fmt.Sprintf("%s/released-versions/environment/%s", s.versionURL(v), e.UUID),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"number":   v.number,
+		"branch":   v.branch,
+		"buildUrl": v.buildURL,
+		"tags":     v.tags,
+		"_links": map[string]interface{}{
+			"self":                 link(s.versionURL(v)),
+			"pb:record-deployment": deploy,
+			"pb:record-release":    release,
+		},
+	})
+}
+
+// putVersion creates or updates a pacticipant version.
+func (s *Server) putVersion(w http.ResponseWriter, r *http.Request, name string, number string) {
+	var body struct {
+		BuildURL string `json:"buildUrl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := s.version(name, number)
+	if body.BuildURL != "" {
+		v.buildURL = body.BuildURL
+	}
+	s.getVersion(w, name, number)
+}
+
+// versionURL is the URL of a pacticipant version.
+func (s *Server) versionURL(v *version) string {
+	return fmt.Sprintf("%s/pacticipants/%s/versions/%s", s.URL, url.PathEscape(v.pacticipant), url.PathEscape(v.number))
+}
+
+// link creates a HAL link.
+func link(href string) map[string]string {
+	return map[string]string{"href": href}
+}
+
+// sha returns the SHA1 of the content, identifying the version of a pact.
+func sha(content []byte) string {
+	h := sha1.Sum(content)
+	return hex.EncodeToString(h[:])
+}
+
+// pacticipantNames reads the consumer and provider names from a pact.
+func pacticipantNames(content []byte) (string, string, error) {
+	var names struct {
+		Consumer struct {
+			Name string `json:"name"`
+		} `json:"consumer"`
+		Provider struct {
+			Name string `json:"name"`
+		} `json:"provider"`
+	}
+	if err := json.Unmarshal(content, &names); err != nil {
+		return "", "", err
+	}
+	if names.Consumer.Name == "" || names.Provider.Name == "" {
+		return "", "", errors.New("the pact must have a consumer and provider name")
+	}
+	return names.Consumer.Name, names.Provider.Name, nil
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/hal+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error response in the format used by the Pact Broker.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": map[string][]string{"base": {message}},
+	})
+}
+
+// sortedPacticipants returns the names of the pacticipants in a stable order.
+func (s *Server) sortedPacticipants() []string {
+	var names []string
+	for name := range s.pacticipants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
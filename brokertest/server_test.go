@@ -0,0 +1,230 @@
+package brokertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func pactFor(consumer string, provider string, description string) []byte {
+	return []byte(fmt.Sprintf(`{"consumer":{"name":"%s"},"provider":{"name":"%s"},"interactions":[{"description":"%s"}]}`, consumer, provider, description))
+}
+
+func TestServer_Publish(t *testing.T) {
+	broker := NewServer()
+	defer broker.Close()
+
+	dir, err := ioutil.TempDir("", "brokertest")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "billy-bobby.json"), pactFor("billy", "bobby", "a request"), 0644)
+
+	p := &dsl.Publisher{}
+	err = p.Publish(types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		Tags:            []string{"dev"},
+		Branch:          "main",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !strings.Contains(string(broker.Pact("billy", "bobby", "1.0.0")), "a request") {
+		t.Fatalf("Expected the pact to be published")
+	}
+	if tags := broker.Tags("billy", "1.0.0"); len(tags) != 1 || tags[0] != "dev" {
+		t.Fatalf("Expected version to be tagged 'dev' but got %v", tags)
+	}
+	if branch := broker.Branch("billy", "1.0.0"); branch != "main" {
+		t.Fatalf("Expected version to be on branch 'main' but got '%s'", branch)
+	}
+}
+
+func TestServer_LatestPacts(t *testing.T) {
+	broker := NewServer()
+	defer broker.Close()
+
+	broker.PublishPact("1.0.0", pactFor("billy", "bobby", "one"), "prod")
+	broker.PublishPact("2.0.0", pactFor("billy", "bobby", "two"))
+	broker.PublishPact("1.0.0", pactFor("jessica", "bobby", "three"))
+
+	var doc dsl.HalDoc
+	get(t, broker.URL+"/pacts/provider/bobby/latest", &doc)
+	if len(doc.Links.Pacts) != 2 || !strings.HasSuffix(doc.Links.Pacts[0].Href, "/consumer/billy/version/2.0.0") {
+		t.Fatalf("Expected the latest pact of each consumer but got %+v", doc.Links.Pacts)
+	}
+
+	doc = dsl.HalDoc{}
+	get(t, broker.URL+"/pacts/provider/bobby/latest/prod", &doc)
+	if len(doc.Links.Pacts) != 1 || !strings.HasSuffix(doc.Links.Pacts[0].Href, "/consumer/billy/version/1.0.0") {
+		t.Fatalf("Expected the latest prod pact but got %+v", doc.Links.Pacts)
+	}
+
+	res, err := http.Get(broker.URL + "/pacts/provider/sally/latest")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if res.StatusCode != 404 {
+		t.Fatalf("Expected 404 for a provider without pacts but got %d", res.StatusCode)
+	}
+}
+
+func TestServer_PactsForVerification(t *testing.T) {
+	broker := NewServer()
+	defer broker.Close()
+
+	broker.PublishPact("1.0.0", pactFor("billy", "bobby", "one"), "prod")
+	broker.PublishPact("2.0.0", pactFor("billy", "bobby", "two"), "feat-x")
+
+	b := &dsl.Broker{URL: broker.URL}
+	err := b.PublishVerificationResult(broker.URL+"/pacts/provider/bobby/consumer/billy/version/1.0.0", types.VerificationResult{
+		Success:                    true,
+		ProviderApplicationVersion: "5.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if results := broker.VerificationResults("billy", "bobby"); len(results) != 1 || results[0].ProviderApplicationVersion != "5.0.0" {
+		t.Fatalf("Expected the verification result to be published but got %+v", results)
+	}
+
+	var res struct {
+		Embedded struct {
+			Pacts []struct {
+				VerificationProperties struct {
+					Pending bool `json:"pending"`
+					WIP     bool `json:"wip"`
+				} `json:"verificationProperties"`
+				Links struct {
+					Self dsl.PactLink `json:"self"`
+				} `json:"_links"`
+			} `json:"pacts"`
+		} `json:"_embedded"`
+	}
+	post(t, broker.URL+"/pacts/provider/bobby/for-verification", `{"consumerVersionSelectors":[{"tag":"prod","latest":true}],"includePendingStatus":true,"includeWipPactsSince":"2000-01-01T00:00:00Z"}`, &res)
+
+	pacts := res.Embedded.Pacts
+	if len(pacts) != 2 {
+		t.Fatalf("Expected the prod pact and a WIP pact but got %+v", pacts)
+	}
+	if pacts[0].VerificationProperties.Pending || !strings.HasSuffix(pacts[0].Links.Self.Href, "/version/1.0.0") {
+		t.Fatalf("Expected the verified prod pact not to be pending but got %+v", pacts[0])
+	}
+	if !pacts[1].VerificationProperties.WIP || !pacts[1].VerificationProperties.Pending || !strings.HasSuffix(pacts[1].Links.Self.Href, "/version/2.0.0") {
+		t.Fatalf("Expected the feat-x pact to be WIP but got %+v", pacts[1])
+	}
+}
+
+func TestServer_CanIDeploy(t *testing.T) {
+	broker := NewServer()
+	defer broker.Close()
+	b := &dsl.Broker{URL: broker.URL}
+
+	broker.PublishPact("1.0.0", pactFor("billy", "bobby", "one"))
+	b.PublishVerificationResult(broker.URL+"/pacts/provider/bobby/consumer/billy/version/1.0.0", types.VerificationResult{
+		Success:                    true,
+		ProviderApplicationVersion: "5.0.0",
+	})
+
+	if _, err := b.CreateEnvironment(types.Environment{Name: "production", Production: true}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err := b.CanIDeploy(types.CanIDeployRequest{Pacticipant: "billy", Version: "1.0.0", ToEnvironment: "production"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !res.Summary.Deployable || len(res.Matrix) != 0 {
+		t.Fatalf("Expected billy to be deployable with no provider in production but got %+v", res)
+	}
+
+	err = b.RecordDeployment(types.DeploymentRequest{Pacticipant: "bobby", Version: "5.0.0", Environment: "production"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err = b.CanIDeploy(types.CanIDeployRequest{Pacticipant: "billy", Version: "1.0.0", ToEnvironment: "production"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !res.Summary.Deployable || res.Summary.Success != 1 || res.Matrix[0].Provider.Version.Number != "5.0.0" {
+		t.Fatalf("Expected billy to be deployable with bobby 5.0.0 but got %+v", res)
+	}
+
+	broker.PublishPact("2.0.0", pactFor("billy", "bobby", "two"))
+	res, err = b.CanIDeploy(types.CanIDeployRequest{Pacticipant: "billy", Latest: true, ToEnvironment: "production"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if res.Summary.Deployable || res.Summary.Unknown != 1 {
+		t.Fatalf("Expected the unverified billy 2.0.0 not to be deployable but got %+v", res)
+	}
+
+	res, err = b.CanIDeploy(types.CanIDeployRequest{Pacticipant: "bobby", Version: "5.0.0", ToEnvironment: "production"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !res.Summary.Deployable || len(res.Matrix) != 0 {
+		t.Fatalf("Expected bobby to be deployable with no consumer in production but got %+v", res)
+	}
+
+	err = b.RecordUndeployment(types.DeploymentRequest{Pacticipant: "bobby", Environment: "production"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	err = b.RecordUndeployment(types.DeploymentRequest{Pacticipant: "bobby", Environment: "production"})
+	if err == nil {
+		t.Fatalf("Expected error undeploying bobby twice but got none")
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	broker := NewServer()
+	defer broker.Close()
+	broker.Username = "foo"
+	broker.Password = "bar"
+
+	b := &dsl.Broker{URL: broker.URL}
+	if _, err := b.ListEnvironments(); err != dsl.ErrUnauthorized {
+		t.Fatalf("Expected unauthorized error but got: %v", err)
+	}
+
+	b = &dsl.Broker{URL: broker.URL, Username: "foo", Password: "bar"}
+	if _, err := b.ListEnvironments(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func get(t *testing.T, url string, out interface{}) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer res.Body.Close()
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func post(t *testing.T, url string, body string, out interface{}) {
+	res, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer res.Body.Close()
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
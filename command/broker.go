@@ -0,0 +1,29 @@
+package command
+
+import (
+	"os"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/spf13/cobra"
+)
+
+var brokerURL string
+var brokerUsername string
+var brokerPassword string
+
+// addBrokerFlags adds the flags required to talk to a Pact Broker, defaulting
+// to the standard Pact Broker environment variables.
+func addBrokerFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&brokerURL, "broker-base-url", "b", os.Getenv("PACT_BROKER_BASE_URL"), "The base URL of the Pact Broker")
+	cmd.Flags().StringVarP(&brokerUsername, "broker-username", "u", os.Getenv("PACT_BROKER_USERNAME"), "Pact Broker basic auth username")
+	cmd.Flags().StringVarP(&brokerPassword, "broker-password", "p", os.Getenv("PACT_BROKER_PASSWORD"), "Pact Broker basic auth password")
+}
+
+// newBroker creates a Pact Broker client from the broker flags.
+func newBroker() *dsl.Broker {
+	return &dsl.Broker{
+		URL:      brokerURL,
+		Username: brokerUsername,
+		Password: brokerPassword,
+	}
+}
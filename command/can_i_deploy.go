@@ -0,0 +1,91 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var canIDeployRequest types.CanIDeployRequest
+var canIDeployOutput string
+var canIDeployRetryInterval int
+
+var canIDeployCmd = &cobra.Command{
+	Use:   "can-i-deploy",
+	Short: "Check if a pacticipant version can be deployed",
+	Long: `Queries the Pact Broker matrix to check whether a version of a pacticipant
+is compatible with the versions it will be deployed alongside. Exits with a
+non-zero status if it is not safe to deploy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		canIDeployRequest.RetryInterval = time.Duration(canIDeployRetryInterval) * time.Second
+		res, err := newBroker().CanIDeploy(canIDeployRequest)
+		if err != nil {
+			log.Println("[ERROR] unable to query the Pact Broker matrix:", err)
+			os.Exit(1)
+		}
+
+		if err = printMatrix(os.Stdout, res, canIDeployOutput); err != nil {
+			log.Println("[ERROR] unable to print the matrix:", err)
+			os.Exit(1)
+		}
+
+		if !res.Summary.Deployable {
+			os.Exit(1)
+		}
+	},
+}
+
+// printMatrix writes the matrix as either a table or JSON.
+func printMatrix(w io.Writer, res types.MatrixResponse, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "CONSUMER\tC.VERSION\tPROVIDER\tP.VERSION\tSUCCESS?")
+		for _, row := range res.Matrix {
+			success := "???"
+			if row.VerificationResult != nil {
+				success = fmt.Sprintf("%t", row.VerificationResult.Success)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", row.Consumer.Name, row.Consumer.Version.Number, row.Provider.Name, row.Provider.Version.Number, success)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		decision := "Computer says no ¯\\_(ツ)_/¯"
+		if res.Summary.Deployable {
+			decision = "Computer says yes \\o/"
+		}
+		_, err := fmt.Fprintf(w, "\n%s\n\n%s\n", decision, res.Summary.Reason)
+		return err
+	default:
+		return fmt.Errorf("unknown output format '%s', must be one of 'table' or 'json'", format)
+	}
+}
+
+func init() {
+	addBrokerFlags(canIDeployCmd)
+	canIDeployCmd.Flags().StringVarP(&canIDeployRequest.Pacticipant, "pacticipant", "a", "", "The name of the pacticipant being deployed")
+	canIDeployCmd.Flags().StringVarP(&canIDeployRequest.Version, "version", "e", "", "The version of the pacticipant being deployed")
+	canIDeployCmd.Flags().BoolVar(&canIDeployRequest.Latest, "latest", false, "Use the latest version of the pacticipant")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.LatestTag, "latest-tag", "", "Use the latest version of the pacticipant with the given tag")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.To, "to", "", "The tag of the versions the pacticipant will be deployed alongside (e.g. prod)")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.ToEnvironment, "to-environment", "", "The environment the pacticipant will be deployed to")
+	canIDeployCmd.Flags().IntVar(&canIDeployRequest.RetryWhileUnknown, "retry-while-unknown", 0, "The number of times to retry while there are unknown verification results")
+	canIDeployCmd.Flags().IntVar(&canIDeployRetryInterval, "retry-interval", 10, "The time between retries in seconds")
+	canIDeployCmd.Flags().StringVarP(&canIDeployOutput, "output", "o", "table", "The output format, one of 'table' or 'json'")
+	RootCmd.AddCommand(canIDeployCmd)
+}
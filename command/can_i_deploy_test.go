@@ -0,0 +1,41 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestCanIDeploy_printMatrix(t *testing.T) {
+	var res types.MatrixResponse
+	err := json.Unmarshal([]byte(`{"summary":{"deployable":false,"reason":"Missing one or more verification results","unknown":1},"matrix":[{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":null}]}`), &res)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err = printMatrix(&out, res, "table"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, expected := range []string{"billy", "2.0.0", "???", "Computer says no", "Missing one or more verification results"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Expected table to contain '%s' but got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err = printMatrix(&out, res, "json"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var decoded types.MatrixResponse
+	if err = json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded.Matrix[0].Consumer.Name != "billy" {
+		t.Fatalf("Expected valid JSON output but got: %s", out.String())
+	}
+
+	if err = printMatrix(&out, res, "xml"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
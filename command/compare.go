@@ -0,0 +1,76 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/openapi"
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var comparePactURLs []string
+var compareOpenAPI string
+var compareOutput string
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Check pact files against a provider's OpenAPI document",
+	Long: `Checks that every interaction of the pact files is allowed by the OpenAPI
+document of the provider: its method, path, query, headers and body, and its
+response status, headers and body. Exits with a non-zero status if any
+interaction is not allowed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		res, err := openapi.CompareFiles(compareOpenAPI, comparePactURLs)
+		if err != nil {
+			log.Println("[ERROR] unable to compare the pacts with the OpenAPI document:", err)
+			os.Exit(1)
+		}
+
+		if err = printComparison(os.Stdout, res, compareOutput); err != nil {
+			log.Println("[ERROR] unable to print the results:", err)
+			os.Exit(1)
+		}
+
+		if res.Summary.FailureCount > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// printComparison writes the result of each interaction as either text or
+// JSON.
+func printComparison(w io.Writer, res types.ProviderVerifierResponse, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case "text":
+		for _, example := range res.Examples {
+			fmt.Fprintf(w, "%s %s\n", strings.ToUpper(example.Status), example.Description)
+			if example.Exception.Message != "" {
+				for _, line := range strings.Split(example.Exception.Message, "\n") {
+					fmt.Fprintf(w, "    %s\n", line)
+				}
+			}
+		}
+		_, err := fmt.Fprintf(w, "\n%s\n", res.SummaryLine)
+		return err
+	default:
+		return fmt.Errorf("unknown output format '%s', must be one of 'text' or 'json'", format)
+	}
+}
+
+func init() {
+	compareCmd.Flags().StringSliceVar(&comparePactURLs, "pact", nil, "Pact file, directory, glob or URL to compare (repeatable)")
+	compareCmd.Flags().StringVar(&compareOpenAPI, "openapi", "", "OpenAPI 3 document of the provider, in JSON or YAML, as a file or URL")
+	compareCmd.Flags().StringVarP(&compareOutput, "output", "o", "text", "Output format, one of 'text' or 'json'")
+	RootCmd.AddCommand(compareCmd)
+}
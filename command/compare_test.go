@@ -0,0 +1,53 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestCompare_printComparison(t *testing.T) {
+	var res types.ProviderVerifierResponse
+	err := json.Unmarshal([]byte(`{
+		"examples": [
+			{"description": "a request for pets", "status": "passed"},
+			{"description": "a request for owners", "status": "failed", "exception": {
+				"message": "$.request.path: /owners does not match a path of the OpenAPI document\n$.response.status: the status 500 is not a response of the operation"
+			}}
+		],
+		"summary_line": "2 examples, 1 failures"
+	}`), &res)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := printComparison(&out, res, "text"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, expected := range []string{
+		"PASSED a request for pets\n",
+		"FAILED a request for owners\n    $.request.path: /owners does not match",
+		"    $.response.status: the status 500",
+		"2 examples, 1 failures",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Expected output to contain '%s' but got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printComparison(&out, res, "json"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"status": "failed"`) {
+		t.Fatalf("Expected JSON to contain the results but got:\n%s", out.String())
+	}
+
+	if err := printComparison(&out, res, "xml"); err == nil {
+		t.Fatalf("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var deploymentRequest types.DeploymentRequest
+
+var recordDeploymentCmd = &cobra.Command{
+	Use:   "record-deployment",
+	Short: "Record the deployment of a pacticipant version to an environment",
+	Long: `Records that a pacticipant version has been deployed to an environment.
+The previously deployed version is automatically marked as undeployed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if err := newBroker().RecordDeployment(deploymentRequest); err != nil {
+			log.Println("[ERROR] unable to record deployment:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Recorded deployment of %s version %s to %s\n", deploymentRequest.Pacticipant, deploymentRequest.Version, deploymentRequest.Environment)
+	},
+}
+
+var recordReleaseCmd = &cobra.Command{
+	Use:   "record-release",
+	Short: "Record the release of a pacticipant version to an environment",
+	Long: `Records that a pacticipant version has been released to an environment,
+e.g. a mobile application published to an app store.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if err := newBroker().RecordRelease(deploymentRequest); err != nil {
+			log.Println("[ERROR] unable to record release:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Recorded release of %s version %s to %s\n", deploymentRequest.Pacticipant, deploymentRequest.Version, deploymentRequest.Environment)
+	},
+}
+
+var recordUndeploymentCmd = &cobra.Command{
+	Use:   "record-undeployment",
+	Short: "Record that a pacticipant is no longer deployed to an environment",
+	Long:  "Records that a pacticipant is no longer deployed to an environment",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if err := newBroker().RecordUndeployment(deploymentRequest); err != nil {
+			log.Println("[ERROR] unable to record undeployment:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Recorded undeployment of %s from %s\n", deploymentRequest.Pacticipant, deploymentRequest.Environment)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{recordDeploymentCmd, recordReleaseCmd, recordUndeploymentCmd} {
+		addBrokerFlags(cmd)
+		cmd.Flags().StringVarP(&deploymentRequest.Pacticipant, "pacticipant", "a", "", "The name of the pacticipant")
+		cmd.Flags().StringVar(&deploymentRequest.Environment, "environment", "", "The name of the environment")
+		RootCmd.AddCommand(cmd)
+	}
+
+	for _, cmd := range []*cobra.Command{recordDeploymentCmd, recordReleaseCmd} {
+		cmd.Flags().StringVarP(&deploymentRequest.Version, "version", "e", "", "The version of the pacticipant")
+	}
+
+	for _, cmd := range []*cobra.Command{recordDeploymentCmd, recordUndeploymentCmd} {
+		cmd.Flags().StringVar(&deploymentRequest.ApplicationInstance, "application-instance", "", "The instance of the application, if several are deployed to the environment")
+	}
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pact-foundation/pact-go/diff"
+	"github.com/spf13/cobra"
+)
+
+var diffOutput string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old pact> <new pact>",
+	Short: "Find breaking changes between two versions of a pact",
+	Long: `Reports the interactions that were added, removed or changed between two
+versions of a pact file, and whether the changes are breaking: that is, whether
+the new pact is stricter than the old one, so the provider may no longer
+satisfy it. Exits with a non-zero status if any change is breaking.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if len(args) != 2 {
+			log.Println("[ERROR] expected the old and new pact files to compare")
+			os.Exit(1)
+		}
+
+		report, err := diff.CompareFiles(args[0], args[1])
+		if err != nil {
+			log.Println("[ERROR] unable to compare the pacts:", err)
+			os.Exit(1)
+		}
+
+		if err = printDiff(os.Stdout, report, diffOutput); err != nil {
+			log.Println("[ERROR] unable to print the changes:", err)
+			os.Exit(1)
+		}
+
+		if report.Breaking {
+			os.Exit(1)
+		}
+	},
+}
+
+// printDiff writes the changes between two pacts as either text or JSON.
+func printDiff(w io.Writer, report diff.Report, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "text":
+		breaking := 0
+		for _, c := range report.Changes {
+			label := "  "
+			if c.Breaking {
+				label = "! "
+				breaking++
+			}
+			fmt.Fprintf(w, "%s%-7s %s\n", label, c.Kind, c)
+		}
+		_, err := fmt.Fprintf(w, "\n%d changes, %d breaking\n", len(report.Changes), breaking)
+		return err
+	default:
+		return fmt.Errorf("unknown output format '%s', must be one of 'text' or 'json'", format)
+	}
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "text", "Output format, one of 'text' or 'json'")
+	RootCmd.AddCommand(diffCmd)
+}
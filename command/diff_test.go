@@ -0,0 +1,45 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/diff"
+)
+
+func TestDiff_printDiff(t *testing.T) {
+	report := diff.Report{
+		Changes: []diff.Change{
+			{Kind: diff.Changed, Interaction: "a request for users", Path: "$.response.body.age", Message: "field became required", Breaking: true},
+			{Kind: diff.Removed, Interaction: "a request to delete a user", Message: "interaction DELETE /users/1 was removed"},
+		},
+		Breaking: true,
+	}
+
+	var out bytes.Buffer
+	if err := printDiff(&out, report, "text"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, expected := range []string{
+		"! changed 'a request for users' $.response.body.age: field became required\n",
+		"  removed 'a request to delete a user': interaction DELETE /users/1 was removed\n",
+		"2 changes, 1 breaking",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Expected output to contain '%s' but got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printDiff(&out, report, "json"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"breaking": true`) {
+		t.Fatalf("Expected JSON to contain the changes but got:\n%s", out.String())
+	}
+
+	if err := printDiff(&out, report, "xml"); err == nil {
+		t.Fatalf("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var environment types.Environment
+var environmentOutput string
+
+var createEnvironmentCmd = &cobra.Command{
+	Use:   "create-environment",
+	Short: "Create an environment in the Pact Broker",
+	Long:  "Creates an environment that pacticipant versions can be deployed or released to",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		created, err := newBroker().CreateEnvironment(environment)
+		if err != nil {
+			log.Println("[ERROR] unable to create environment:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Created environment %s with UUID %s\n", created.Name, created.UUID)
+	},
+}
+
+var listEnvironmentsCmd = &cobra.Command{
+	Use:   "list-environments",
+	Short: "List the environments in the Pact Broker",
+	Long:  "Lists the environments that pacticipant versions can be deployed or released to",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		environments, err := newBroker().ListEnvironments()
+		if err != nil {
+			log.Println("[ERROR] unable to list environments:", err)
+			os.Exit(1)
+		}
+
+		if err = printEnvironments(os.Stdout, environments, environmentOutput); err != nil {
+			log.Println("[ERROR] unable to print environments:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// printEnvironments writes the environments as either a table or JSON.
+func printEnvironments(w io.Writer, environments []types.Environment, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(environments)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "UUID\tNAME\tDISPLAY NAME\tPRODUCTION")
+		for _, e := range environments {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\n", e.UUID, e.Name, e.DisplayName, e.Production)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format '%s', must be one of 'table' or 'json'", format)
+	}
+}
+
+func init() {
+	addBrokerFlags(createEnvironmentCmd)
+	createEnvironmentCmd.Flags().StringVar(&environment.Name, "name", "", "The name of the environment")
+	createEnvironmentCmd.Flags().StringVar(&environment.DisplayName, "display-name", "", "A human readable name for the environment")
+	createEnvironmentCmd.Flags().BoolVar(&environment.Production, "production", false, "Whether the environment is a production environment")
+	RootCmd.AddCommand(createEnvironmentCmd)
+
+	addBrokerFlags(listEnvironmentsCmd)
+	listEnvironmentsCmd.Flags().StringVarP(&environmentOutput, "output", "o", "table", "The output format, one of 'table' or 'json'")
+	RootCmd.AddCommand(listEnvironmentsCmd)
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestEnvironment_printEnvironments(t *testing.T) {
+	environments := []types.Environment{
+		types.Environment{UUID: "1234", Name: "production", DisplayName: "Production", Production: true},
+	}
+
+	var out bytes.Buffer
+	if err := printEnvironments(&out, environments, "table"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(out.String(), "production") || !strings.Contains(out.String(), "true") {
+		t.Fatalf("Expected table to contain the environment but got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := printEnvironments(&out, environments, "json"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"uuid": "1234"`) {
+		t.Fatalf("Expected JSON to contain the environment but got:\n%s", out.String())
+	}
+
+	if err := printEnvironments(&out, environments, "xml"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/pact-foundation/pact-go/openapi"
+	"github.com/spf13/cobra"
+)
+
+var generateSpec string
+var generateOutput string
+var generateOptions openapi.GenerateOptions
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate consumer pact tests from an OpenAPI document",
+	Long: `Generates skeleton consumer pact tests for operations of an OpenAPI 3
+document, with body matchers derived from the schemas of the operations.
+The tests are a starting point, to be edited to add provider states and to
+call the API client.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		spec, err := openapi.Load(generateSpec)
+		if err != nil {
+			log.Println("[ERROR] unable to load the OpenAPI document:", err)
+			os.Exit(1)
+		}
+
+		var w io.Writer = os.Stdout
+		if generateOutput != "" {
+			f, err := os.Create(generateOutput)
+			if err != nil {
+				log.Println("[ERROR] unable to create the output file:", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err = openapi.Generate(w, spec, generateOptions); err != nil {
+			log.Println("[ERROR] unable to generate pact tests:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	generateCmd.Flags().StringVar(&generateSpec, "openapi", "", "OpenAPI 3 document, in JSON or YAML, as a file or URL")
+	generateCmd.Flags().StringVarP(&generateOutput, "output", "o", "", "File to write the tests to, defaults to stdout")
+	generateCmd.Flags().StringVar(&generateOptions.Package, "package", "main", "Package of the generated tests")
+	generateCmd.Flags().StringVar(&generateOptions.Consumer, "consumer", "", "Consumer name")
+	generateCmd.Flags().StringVar(&generateOptions.Provider, "provider", "", "Provider name, defaults to the title of the document")
+	generateCmd.Flags().StringSliceVar(&generateOptions.Operations, "operation", nil, "Operation ID, or method and path, to generate a test for (repeatable), defaults to all operations")
+	RootCmd.AddCommand(generateCmd)
+}
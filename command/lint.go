@@ -0,0 +1,91 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pact-foundation/pact-go/lint"
+	"github.com/spf13/cobra"
+)
+
+var lintPactURLs []string
+var lintConfig string
+var lintDisable []string
+var lintMaxBodySize int
+var lintOutput string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check pact files for common mistakes",
+	Long: `Checks pact files for common mistakes that make contracts brittle, such as
+literal UUIDs, dates and IDs that are matched exactly, write requests without a
+provider state, duplicate descriptions, Term examples that don't match their
+regex, and oversized bodies. Exits with a non-zero status if any issue has
+the severity 'error'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		config := lint.Config{Rules: make(map[string]string)}
+		if lintConfig != "" {
+			var err error
+			if config, err = lint.LoadConfig(lintConfig); err != nil {
+				log.Println("[ERROR] unable to load the lint config:", err)
+				os.Exit(1)
+			}
+			if config.Rules == nil {
+				config.Rules = make(map[string]string)
+			}
+		}
+		for _, rule := range lintDisable {
+			config.Rules[rule] = lint.Off
+		}
+		if lintMaxBodySize > 0 {
+			config.MaxBodySize = lintMaxBodySize
+		}
+
+		report, err := lint.LintFiles(lintPactURLs, config)
+		if err != nil {
+			log.Println("[ERROR] unable to lint the pacts:", err)
+			os.Exit(1)
+		}
+
+		if err = printLint(os.Stdout, report, lintOutput); err != nil {
+			log.Println("[ERROR] unable to print the issues:", err)
+			os.Exit(1)
+		}
+
+		if report.Errors > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// printLint writes the issues found in pacts as either text or JSON.
+func printLint(w io.Writer, report lint.Report, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "text":
+		for _, issue := range report.Issues {
+			fmt.Fprintf(w, "%-7s %s\n", issue.Severity, issue)
+		}
+		_, err := fmt.Fprintf(w, "\n%d errors, %d warnings\n", report.Errors, report.Warnings)
+		return err
+	default:
+		return fmt.Errorf("unknown output format '%s', must be one of 'text' or 'json'", format)
+	}
+}
+
+func init() {
+	lintCmd.Flags().StringSliceVar(&lintPactURLs, "pact", nil, "Pact file, directory, glob or URL to lint (repeatable)")
+	lintCmd.Flags().StringVar(&lintConfig, "config", "", "YAML or JSON file configuring the severity of each rule")
+	lintCmd.Flags().StringSliceVar(&lintDisable, "disable", nil, "Rule to disable (repeatable)")
+	lintCmd.Flags().IntVar(&lintMaxBodySize, "max-body-size", 0, fmt.Sprintf("Size in bytes of a body above which it is reported (default %d)", lint.DefaultMaxBodySize))
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "text", "Output format, one of 'text' or 'json'")
+	RootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/lint"
+)
+
+func TestLint_printLint(t *testing.T) {
+	report := lint.Report{
+		Issues: []lint.Issue{
+			{Rule: "literal-uuid", Severity: lint.Warning, Consumer: "Billy", Provider: "Bobby", Interaction: "a request for a user", Path: "$.response.body.uuid", Message: "UUID is matched exactly"},
+			{Rule: "duplicate-description", Severity: lint.Error, Consumer: "Billy", Provider: "Bobby", Interaction: "a request for a user", Message: "another interaction has the same description"},
+		},
+		Errors:   1,
+		Warnings: 1,
+	}
+
+	var out bytes.Buffer
+	if err := printLint(&out, report, "text"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, expected := range []string{
+		"warning Billy-Bobby 'a request for a user' $.response.body.uuid: UUID is matched exactly (literal-uuid)\n",
+		"error   Billy-Bobby 'a request for a user': another interaction has the same description (duplicate-description)\n",
+		"1 errors, 1 warnings",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Expected output to contain '%s' but got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printLint(&out, report, "json"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"rule": "literal-uuid"`) {
+		t.Fatalf("Expected JSON to contain the issues but got:\n%s", out.String())
+	}
+
+	if err := printLint(&out, report, "xml"); err == nil {
+		t.Fatalf("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var publishRequest types.PublishRequest
+
+var publishCmd = &cobra.Command{
+	Use:   "publish [pact files, directories or globs...]",
+	Short: "Publish Pact files to a Pact Broker",
+	Long: `Publishes Pact files to a Pact Broker. Directories are expanded to the
+*.json files they contain, and glob patterns (quoted to avoid shell expansion)
+are supported. Use --dry-run to list the Pact files that would be published.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if len(args) == 0 {
+			log.Println("[ERROR] at least one pact file, directory or glob is required")
+			os.Exit(1)
+		}
+
+		publishRequest.PactURLs = args
+		publishRequest.PactBroker = brokerURL
+		publishRequest.BrokerUsername = brokerUsername
+		publishRequest.BrokerPassword = brokerPassword
+
+		p := &dsl.Publisher{}
+		if publishRequest.DryRun {
+			pacts, err := p.FindPacts(publishRequest)
+			if err != nil {
+				log.Println("[ERROR] unable to find pacts:", err)
+				os.Exit(1)
+			}
+			for _, pact := range pacts {
+				fmt.Printf("Would publish %s (%s -> %s)\n", pact.URL, pact.Consumer, pact.Provider)
+			}
+			return
+		}
+
+		if err := p.Publish(publishRequest); err != nil {
+			log.Println("[ERROR] unable to publish pacts:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Published pacts for version %s\n", publishRequest.ConsumerVersion)
+	},
+}
+
+func init() {
+	addBrokerFlags(publishCmd)
+	publishCmd.Flags().StringVarP(&publishRequest.ConsumerVersion, "consumer-app-version", "a", "", "The version of the consumer application")
+	publishCmd.Flags().StringSliceVarP(&publishRequest.Tags, "tag", "t", nil, "Tag to apply to the consumer version (may be repeated)")
+	publishCmd.Flags().StringVar(&publishRequest.Branch, "branch", "", "The repository branch of the consumer version")
+	publishCmd.Flags().StringVar(&publishRequest.BuildURL, "build-url", "", "The URL of the CI build that created the pacts")
+	publishCmd.Flags().BoolVar(&publishRequest.AutoDetectVersionProperties, "auto-detect-version-properties", false, "Detect the version, branch and build URL from the CI environment or git")
+	publishCmd.Flags().StringSliceVar(&publishRequest.Consumers, "consumer", nil, "Only publish pacts for this consumer (may be repeated)")
+	publishCmd.Flags().StringSliceVar(&publishRequest.Providers, "provider", nil, "Only publish pacts for this provider (may be repeated)")
+	publishCmd.Flags().BoolVar(&publishRequest.DryRun, "dry-run", false, "List the pacts that would be published without publishing them")
+	RootCmd.AddCommand(publishCmd)
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/spf13/cobra"
+)
+
+var recorder dsl.Recorder
+var recordDraft string
+var recordPactDir string
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record a draft pact from real traffic to a provider",
+	Long: `Starts a reverse proxy in front of a real provider, recording the requests
+and responses that pass through it. When interrupted, the de-duplicated
+interactions are written to a draft pact file, with matchers inferred for
+JSON bodies. Review the draft with 'pact-go record review' before use.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if err := recorder.Start(); err != nil {
+			log.Println("[ERROR] unable to start the recorder:", err)
+			os.Exit(1)
+		}
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		<-c
+
+		recorder.Stop()
+		if err := recorder.WriteDraft(recordDraft); err != nil {
+			log.Println("[ERROR] unable to write the draft pact:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Recorded %d interactions to %s\n", len(recorder.Interactions()), recordDraft)
+	},
+}
+
+var recordReviewCmd = &cobra.Command{
+	Use:   "review [draft]",
+	Short: "Review a draft pact, naming its interactions and provider states",
+	Long: `Prompts for a description and provider state for each interaction of a draft
+pact, and whether to keep it, then writes the pact to the pact directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		draft := recordDraft
+		if len(args) > 0 {
+			draft = args[0]
+		}
+
+		pact, err := dsl.LoadPactFile(draft)
+		if err != nil {
+			log.Println("[ERROR] unable to load the draft pact:", err)
+			os.Exit(1)
+		}
+
+		if err = reviewDraft(os.Stdin, os.Stdout, pact); err != nil {
+			log.Println("[ERROR] unable to review the draft pact:", err)
+			os.Exit(1)
+		}
+
+		file := filepath.Join(recordPactDir, pactFileName(pact))
+		if err = pact.Write(file); err != nil {
+			log.Println("[ERROR] unable to write the pact:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Wrote %d interactions to %s\n", len(pact.Interactions), file)
+	},
+}
+
+// reviewDraft prompts for whether to keep each interaction of a draft pact,
+// and its description and provider state. Empty answers keep the current
+// values.
+func reviewDraft(in io.Reader, out io.Writer, pact *dsl.PactFile) error {
+	scanner := bufio.NewScanner(in)
+	ask := func(question string) (string, error) {
+		fmt.Fprint(out, question)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.ErrUnexpectedEOF
+		}
+		return strings.TrimSpace(scanner.Text()), nil
+	}
+
+	var kept []dsl.PactInteraction
+	for n, interaction := range pact.Interactions {
+		fmt.Fprintf(out, "\n[%d/%d] %s %s -> %d\n", n+1, len(pact.Interactions),
+			interaction.Request.Method, interaction.Request.Path, interaction.Response.Status)
+
+		answer, err := ask("Keep this interaction? [Y/n]: ")
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(strings.ToLower(answer), "n") {
+			continue
+		}
+
+		if answer, err = ask(fmt.Sprintf("Description [%s]: ", interaction.Description)); err != nil {
+			return err
+		}
+		if answer != "" {
+			interaction.Description = answer
+		}
+
+		if answer, err = ask(fmt.Sprintf("Provider state [%s]: ", interaction.ProviderState)); err != nil {
+			return err
+		}
+		if answer != "" {
+			interaction.ProviderState = answer
+		}
+
+		kept = append(kept, interaction)
+	}
+
+	pact.Interactions = kept
+	return nil
+}
+
+// pactFileName is the conventional file name of a pact, e.g.
+// "billy-bobby.json".
+func pactFileName(pact *dsl.PactFile) string {
+	name := fmt.Sprintf("%s-%s.json", pact.Consumer.Name, pact.Provider.Name)
+	return strings.ToLower(strings.Replace(name, " ", "_", -1))
+}
+
+func init() {
+	recordCmd.Flags().StringVar(&recorder.Target, "target", "", "Base URL of the provider to record, e.g. http://localhost:8000")
+	recordCmd.Flags().StringVar(&recorder.Consumer, "consumer", "", "Consumer name")
+	recordCmd.Flags().StringVar(&recorder.Provider, "provider", "", "Provider name")
+	recordCmd.Flags().StringVar(&recorder.Host, "host", "localhost", "Host to listen on")
+	recordCmd.Flags().IntVar(&recorder.Port, "port", 0, "Port to listen on, defaults to a free port")
+	recordCmd.PersistentFlags().StringVar(&recordDraft, "draft", "draft-pact.json", "Draft pact file")
+	recordReviewCmd.Flags().StringVar(&recordPactDir, "pact-dir", "pacts", "Directory to write the reviewed pact to")
+	recordCmd.AddCommand(recordReviewCmd)
+	RootCmd.AddCommand(recordCmd)
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/dsl"
+)
+
+func TestRecord_reviewDraft(t *testing.T) {
+	pact := &dsl.PactFile{
+		Consumer: dsl.PactName{Name: "Billy"},
+		Provider: dsl.PactName{Name: "Bobby Service"},
+		Interactions: []dsl.PactInteraction{
+			{Description: "a GET request to /users/1", Request: dsl.PactRequest{Method: "GET", Path: "/users/1"}, Response: dsl.PactResponse{Status: 200}},
+			{Description: "a GET request to /health", Request: dsl.PactRequest{Method: "GET", Path: "/health"}, Response: dsl.PactResponse{Status: 200}},
+			{Description: "a DELETE request to /users/1", Request: dsl.PactRequest{Method: "DELETE", Path: "/users/1"}, Response: dsl.PactResponse{Status: 204}},
+		},
+	}
+
+	answers := strings.Join([]string{
+		"", "a request for user 1", "user 1 exists",
+		"n",
+		"y", "", "",
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := reviewDraft(strings.NewReader(answers), &out, pact); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(pact.Interactions) != 2 {
+		t.Fatalf("Expected the rejected interaction to be removed, but got %+v", pact.Interactions)
+	}
+	if pact.Interactions[0].Description != "a request for user 1" || pact.Interactions[0].ProviderState != "user 1 exists" {
+		t.Fatalf("Expected the interaction to be renamed, but got %+v", pact.Interactions[0])
+	}
+	if pact.Interactions[1].Description != "a DELETE request to /users/1" || pact.Interactions[1].ProviderState != "" {
+		t.Fatalf("Expected empty answers to keep the draft values, but got %+v", pact.Interactions[1])
+	}
+	if !strings.Contains(out.String(), "[3/3] DELETE /users/1 -> 204") {
+		t.Fatalf("Expected each interaction to be summarised, but got:\n%s", out.String())
+	}
+
+	if name := pactFileName(pact); name != "billy-bobby_service.json" {
+		t.Fatalf("Unexpected pact file name: %s", name)
+	}
+}
+
+func TestRecord_reviewDraftEOF(t *testing.T) {
+	pact := &dsl.PactFile{Interactions: []dsl.PactInteraction{{Description: "a request"}}}
+
+	var out bytes.Buffer
+	if err := reviewDraft(strings.NewReader(""), &out, pact); err == nil {
+		t.Fatalf("Expected an error when the input ends")
+	}
+}
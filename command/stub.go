@@ -0,0 +1,45 @@
+package command
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/spf13/cobra"
+)
+
+var stubServer dsl.StubServer
+
+var stubCmd = &cobra.Command{
+	Use:   "stub",
+	Short: "Serve the example responses of one or more pact files",
+	Long: `Starts a stub server that matches incoming requests against the interactions
+in one or more pact files, and responds with their example responses.
+Runs until interrupted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if err := stubServer.Start(); err != nil {
+			log.Println("[ERROR] unable to start the stub server:", err)
+			os.Exit(1)
+		}
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		<-c
+
+		stubServer.Stop()
+	},
+}
+
+func init() {
+	stubCmd.Flags().StringSliceVar(&stubServer.PactURLs, "pact", nil, "Pact file, directory, glob or URL to serve (repeatable)")
+	stubCmd.Flags().StringVar(&stubServer.Host, "host", "localhost", "Host to listen on")
+	stubCmd.Flags().IntVar(&stubServer.Port, "port", 0, "Port to listen on, defaults to a free port")
+	stubCmd.Flags().StringSliceVar(&stubServer.ProviderStates, "state", nil, "Only serve interactions with this provider state, or no provider state (repeatable)")
+	stubCmd.Flags().BoolVar(&stubServer.Strict, "strict", false, "Respond with a 500 rather than a 404 to unmatched requests")
+	stubCmd.Flags().BoolVar(&stubServer.CORS, "cors", false, "Add CORS headers to responses and respond to preflight requests")
+	RootCmd.AddCommand(stubCmd)
+}
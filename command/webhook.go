@@ -0,0 +1,187 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var webhookFile string
+var webhookOutput string
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Manage Pact Broker webhooks",
+	Long: `Create, update, list, delete and test Pact Broker webhooks. Webhooks are
+defined in YAML files so that they can live alongside the pact tests.`,
+}
+
+var webhookCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create the webhooks defined in a YAML file",
+	Long: `Creates each of the webhooks defined in a YAML file. Webhooks with a uuid
+are created with that UUID, or updated if they already exist.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		for _, webhook := range loadWebhooks() {
+			created, err := newBroker().CreateWebhook(webhook)
+			if err != nil {
+				log.Println("[ERROR] unable to create webhook:", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Created webhook %s with UUID %s\n", created.Description, created.UUID)
+		}
+	},
+}
+
+var webhookUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update the webhooks defined in a YAML file",
+	Long:  "Updates each of the webhooks defined in a YAML file, which must all have a uuid",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		for _, webhook := range loadWebhooks() {
+			updated, err := newBroker().UpdateWebhook(webhook)
+			if err != nil {
+				log.Println("[ERROR] unable to update webhook:", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Updated webhook %s\n", updated.UUID)
+		}
+	},
+}
+
+var webhookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the webhooks in the Pact Broker",
+	Long:  "Lists the webhooks configured in the Pact Broker",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		webhooks, err := newBroker().ListWebhooks()
+		if err != nil {
+			log.Println("[ERROR] unable to list webhooks:", err)
+			os.Exit(1)
+		}
+
+		if err = printWebhooks(os.Stdout, webhooks, webhookOutput); err != nil {
+			log.Println("[ERROR] unable to print webhooks:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var webhookDeleteCmd = &cobra.Command{
+	Use:   "delete [uuid...]",
+	Short: "Delete webhooks from the Pact Broker",
+	Long:  "Deletes the webhooks with the given UUIDs",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		for _, uuid := range args {
+			if err := newBroker().DeleteWebhook(uuid); err != nil {
+				log.Println("[ERROR] unable to delete webhook:", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Deleted webhook %s\n", uuid)
+		}
+	},
+}
+
+var webhookTestCmd = &cobra.Command{
+	Use:   "test [uuid]",
+	Short: "Test execute a webhook",
+	Long:  "Executes a webhook using the latest matching pact, and prints the logs of the request",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if len(args) != 1 {
+			log.Println("[ERROR] the UUID of the webhook to test is required")
+			os.Exit(1)
+		}
+
+		res, err := newBroker().ExecuteWebhook(args[0])
+		if err != nil {
+			log.Println("[ERROR] unable to execute webhook:", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(res.Logs)
+		if !res.Success {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadWebhooks loads the webhooks from the --file flag, exiting on error.
+func loadWebhooks() []types.Webhook {
+	if webhookFile == "" {
+		log.Println("[ERROR] a YAML file of webhooks is required")
+		os.Exit(1)
+	}
+
+	webhooks, err := types.LoadWebhooks(webhookFile)
+	if err != nil {
+		log.Println("[ERROR] unable to load webhooks:", err)
+		os.Exit(1)
+	}
+
+	return webhooks
+}
+
+// printWebhooks writes the webhooks as either a table or JSON.
+func printWebhooks(w io.Writer, webhooks []types.Webhook, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(webhooks)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "UUID\tDESCRIPTION\tCONSUMER\tPROVIDER\tEVENTS\tENABLED")
+		for _, webhook := range webhooks {
+			events := make([]string, len(webhook.Events))
+			for i, event := range webhook.Events {
+				events[i] = event.Name
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%t\n", webhook.UUID, webhook.Description, webhookPacticipantName(webhook.Consumer), webhookPacticipantName(webhook.Provider), strings.Join(events, ","), webhook.Enabled == nil || *webhook.Enabled)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format '%s', must be one of 'table' or 'json'", format)
+	}
+}
+
+// webhookPacticipantName returns the name of the pacticipant, or "*" for any.
+func webhookPacticipantName(p *types.WebhookPacticipant) string {
+	if p == nil {
+		return "*"
+	}
+	return p.Name
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{webhookCreateCmd, webhookUpdateCmd, webhookListCmd, webhookDeleteCmd, webhookTestCmd} {
+		addBrokerFlags(cmd)
+		webhookCmd.AddCommand(cmd)
+	}
+
+	for _, cmd := range []*cobra.Command{webhookCreateCmd, webhookUpdateCmd} {
+		cmd.Flags().StringVarP(&webhookFile, "file", "f", "", "A YAML file of webhook definitions")
+	}
+
+	webhookListCmd.Flags().StringVarP(&webhookOutput, "output", "o", "table", "The output format, one of 'table' or 'json'")
+	RootCmd.AddCommand(webhookCmd)
+}
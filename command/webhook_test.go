@@ -0,0 +1,44 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestWebhook_printWebhooks(t *testing.T) {
+	disabled := false
+	webhooks := []types.Webhook{
+		types.Webhook{
+			UUID:        "1234",
+			Description: "Trigger build",
+			Provider:    &types.WebhookPacticipant{Name: "bobby"},
+			Events:      []types.WebhookEvent{{Name: types.WebhookEventContractContentChanged}},
+			Enabled:     &disabled,
+		},
+	}
+
+	var out bytes.Buffer
+	if err := printWebhooks(&out, webhooks, "table"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, expected := range []string{"1234", "Trigger build", "*", "bobby", "contract_content_changed", "false"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Expected table to contain '%s' but got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printWebhooks(&out, webhooks, "json"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"uuid": "1234"`) {
+		t.Fatalf("Expected JSON to contain the webhook but got:\n%s", out.String())
+	}
+
+	if err := printWebhooks(&out, webhooks, "xml"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
@@ -0,0 +1,536 @@
+// Package diff compares two versions of a Pact file, to find the changes in
+// a Consumer's expectations that could break its Provider.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/dsl"
+)
+
+// Kinds of Change.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference between two versions of a Pact.
+type Change struct {
+	// Kind of change: "added", "removed" or "changed".
+	Kind string `json:"kind"`
+
+	// Interaction is the description of the changed interaction, followed
+	// by its provider states if other interactions share its description.
+	Interaction string `json:"interaction"`
+
+	// Path to the changed value, e.g. "$.response.body.id". Empty for added
+	// and removed interactions.
+	Path string `json:"path,omitempty"`
+
+	// Message describing the change.
+	Message string `json:"message"`
+
+	// Breaking is true if the new Pact is stricter than the old one, so the
+	// Provider may no longer satisfy it.
+	Breaking bool `json:"breaking"`
+}
+
+func (c Change) String() string {
+	if c.Path == "" {
+		return fmt.Sprintf("'%s': %s", c.Interaction, c.Message)
+	}
+	return fmt.Sprintf("'%s' %s: %s", c.Interaction, c.Path, c.Message)
+}
+
+// Report is the list of changes between two versions of a Pact.
+type Report struct {
+	Changes []Change `json:"changes"`
+
+	// Breaking is true if any of the changes is breaking.
+	Breaking bool `json:"breaking"`
+}
+
+// CompareFiles loads two versions of a Pact file, from a file or URL, and
+// reports the changes between them.
+func CompareFiles(old string, new string) (Report, error) {
+	oldPact, err := dsl.LoadPactFile(old)
+	if err != nil {
+		return Report{}, err
+	}
+	newPact, err := dsl.LoadPactFile(new)
+	if err != nil {
+		return Report{}, err
+	}
+	return Compare(oldPact, newPact), nil
+}
+
+// Compare reports the changes from an old to a new version of a Pact.
+// Interactions are identified by their description and provider states, so
+// interactions sharing a description are told apart by their states. An
+// interaction whose states changed is still compared with its old version
+// if no other interaction has its description.
+//
+// Changes that make the new Pact stricter are breaking: new interactions
+// and provider states; changes to the method or path of a request; and in
+// responses, changes of status, new headers and body fields, matchers that
+// were tightened (e.g. type to exact, or a changed regex), increased
+// minimum or decreased maximum lengths, and changed example values that
+// are matched exactly.
+func Compare(old *dsl.PactFile, new *dsl.PactFile) Report {
+	d := &differ{}
+
+	oldStates := make(map[string]bool)
+	for _, i := range old.Interactions {
+		for _, state := range i.States() {
+			oldStates[state] = true
+		}
+	}
+
+	names := interactionNames(old.Interactions, new.Interactions)
+	previous, removed := pairInteractions(old.Interactions, new.Interactions)
+	for n, i := range new.Interactions {
+		d.interaction = names(i)
+
+		o, ok := previous[n]
+		if !ok {
+			d.change(Added, nil, true, "new interaction %s %s", i.Request.Method, i.Request.Path)
+			for _, state := range i.States() {
+				if !oldStates[state] {
+					d.change(Added, nil, true, "new provider state '%s'", state)
+				}
+			}
+			continue
+		}
+
+		d.compareInteraction(old.Interactions[o], i)
+	}
+
+	for _, o := range removed {
+		i := old.Interactions[o]
+		d.interaction = names(i)
+		d.change(Removed, nil, false, "interaction %s %s was removed", i.Request.Method, i.Request.Path)
+	}
+
+	report := Report{Changes: d.changes}
+	for _, c := range d.changes {
+		report.Breaking = report.Breaking || c.Breaking
+	}
+	return report
+}
+
+// interactionKey identifies an interaction by its description and provider
+// states.
+func interactionKey(i dsl.PactInteraction) string {
+	states := append([]string{}, i.States()...)
+	sort.Strings(states)
+	return strings.Join(append([]string{i.Description}, states...), "\x00")
+}
+
+// pairInteractions pairs each new interaction with the index of its old
+// version, by description and provider states, or else by description
+// alone if only one old and one new interaction have it. It also returns
+// the indexes of the old interactions that were not paired.
+func pairInteractions(old []dsl.PactInteraction, new []dsl.PactInteraction) (map[int]int, []int) {
+	byKey := make(map[string][]int)
+	for o, i := range old {
+		byKey[interactionKey(i)] = append(byKey[interactionKey(i)], o)
+	}
+
+	previous := make(map[int]int)
+	paired := make(map[int]bool)
+	for n, i := range new {
+		key := interactionKey(i)
+		if candidates := byKey[key]; len(candidates) > 0 {
+			previous[n] = candidates[0]
+			paired[candidates[0]] = true
+			byKey[key] = candidates[1:]
+		}
+	}
+
+	unpairedOld := make(map[string][]int)
+	for o, i := range old {
+		if !paired[o] {
+			unpairedOld[i.Description] = append(unpairedOld[i.Description], o)
+		}
+	}
+	unpairedNew := make(map[string][]int)
+	for n, i := range new {
+		if _, ok := previous[n]; !ok {
+			unpairedNew[i.Description] = append(unpairedNew[i.Description], n)
+		}
+	}
+	for description, news := range unpairedNew {
+		if olds := unpairedOld[description]; len(olds) == 1 && len(news) == 1 {
+			previous[news[0]] = olds[0]
+			paired[olds[0]] = true
+		}
+	}
+
+	var removed []int
+	for o := range old {
+		if !paired[o] {
+			removed = append(removed, o)
+		}
+	}
+	return previous, removed
+}
+
+// interactionNames returns a function naming an interaction in changes: by
+// its description, followed by its provider states if another interaction
+// in either Pact has the same description, e.g. "a request for a user
+// (given a user exists)".
+func interactionNames(old []dsl.PactInteraction, new []dsl.PactInteraction) func(dsl.PactInteraction) string {
+	shared := make(map[string]bool)
+	for _, interactions := range [][]dsl.PactInteraction{old, new} {
+		seen := make(map[string]bool)
+		for _, i := range interactions {
+			shared[i.Description] = shared[i.Description] || seen[i.Description]
+			seen[i.Description] = true
+		}
+	}
+
+	return func(i dsl.PactInteraction) string {
+		if !shared[i.Description] {
+			return i.Description
+		}
+		if len(i.States()) == 0 {
+			return i.Description + " (given no provider state)"
+		}
+		return fmt.Sprintf("%s (given %s)", i.Description, strings.Join(i.States(), ", "))
+	}
+}
+
+// differ collects the changes.
+type differ struct {
+	interaction string
+	changes     []Change
+}
+
+// change records a change to the current interaction.
+func (d *differ) change(kind string, path []string, breaking bool, format string, args ...interface{}) {
+	c := Change{
+		Kind:        kind,
+		Interaction: d.interaction,
+		Message:     fmt.Sprintf(format, args...),
+		Breaking:    breaking,
+	}
+	if path != nil {
+		c.Path = formatPath(path)
+	}
+	d.changes = append(d.changes, c)
+}
+
+// compareInteraction compares two versions of an interaction.
+func (d *differ) compareInteraction(old dsl.PactInteraction, new dsl.PactInteraction) {
+	oldStates := make(map[string]bool)
+	for _, state := range old.States() {
+		oldStates[state] = true
+	}
+	newStates := make(map[string]bool)
+	for _, state := range new.States() {
+		newStates[state] = true
+		if !oldStates[state] {
+			d.change(Changed, []string{"providerState"}, true, "new provider state '%s'", state)
+		}
+	}
+	for _, state := range old.States() {
+		if !newStates[state] {
+			d.change(Changed, []string{"providerState"}, false, "provider state '%s' was removed", state)
+		}
+	}
+
+	d.compareRequest(old.Request, new.Request)
+	d.compareResponse(old.Response, new.Response)
+}
+
+// compareRequest compares two versions of a request. Only changes to the
+// method and path are breaking, as the Provider must then serve a different
+// endpoint.
+func (d *differ) compareRequest(old dsl.PactRequest, new dsl.PactRequest) {
+	if !strings.EqualFold(old.Method, new.Method) {
+		d.change(Changed, []string{"request", "method"}, true, "changed from %s to %s", old.Method, new.Method)
+	}
+	if old.Path != new.Path {
+		d.change(Changed, []string{"request", "path"}, true, "changed from %s to %s", old.Path, new.Path)
+	}
+	if old.Query.String() != new.Query.String() {
+		d.change(Changed, []string{"request", "query"}, false, "changed from '%s' to '%s'", old.Query.String(), new.Query.String())
+	}
+	for _, name := range headerNames(old.Headers, new.Headers) {
+		oldValue, inOld := findHeader(old.Headers, name)
+		newValue, inNew := findHeader(new.Headers, name)
+		switch {
+		case !inOld:
+			d.change(Changed, []string{"request", "headers", name}, false, "header added with value '%s'", newValue)
+		case !inNew:
+			d.change(Changed, []string{"request", "headers", name}, false, "header removed")
+		case oldValue != newValue:
+			d.change(Changed, []string{"request", "headers", name}, false, "changed from '%s' to '%s'", oldValue, newValue)
+		}
+	}
+	if !reflect.DeepEqual(old.Body, new.Body) {
+		d.change(Changed, []string{"request", "body"}, false, "the request body changed")
+	}
+}
+
+// compareResponse compares two versions of an expected response.
+func (d *differ) compareResponse(old dsl.PactResponse, new dsl.PactResponse) {
+	if old.Status != new.Status {
+		d.change(Changed, []string{"response", "status"}, true, "changed from %d to %d", old.Status, new.Status)
+	}
+
+	for _, name := range headerNames(old.Headers, new.Headers) {
+		path := []string{"response", "headers", name}
+		oldValue, inOld := findHeader(old.Headers, name)
+		newValue, inNew := findHeader(new.Headers, name)
+		switch {
+		case !inOld:
+			d.change(Changed, path, true, "header became required, with value '%s'", newValue)
+		case !inNew:
+			d.change(Changed, path, false, "header is no longer expected")
+		default:
+			d.compareValue(path[1:], old.MatchingRules, new.MatchingRules, oldValue, newValue)
+		}
+	}
+
+	switch {
+	case old.Body == nil && new.Body != nil:
+		d.change(Changed, []string{"response", "body"}, true, "body became required")
+	case old.Body != nil && new.Body == nil:
+		d.change(Changed, []string{"response", "body"}, false, "body is no longer expected")
+	case new.Body != nil:
+		d.compareValue([]string{"body"}, old.MatchingRules, new.MatchingRules, old.Body, new.Body)
+	}
+}
+
+// compareValue compares the old and new expected values at a path of a
+// response, and their matchers.
+func (d *differ) compareValue(path []string, oldRules map[string]interface{}, newRules map[string]interface{}, old interface{}, new interface{}) {
+	responsePath := append([]string{"response"}, path...)
+	oldMatcher := findMatcher(oldRules, path)
+	newMatcher := findMatcher(newRules, path)
+
+	if oldMatcher.kind != newMatcher.kind || oldMatcher.regex != newMatcher.regex {
+		switch {
+		case newMatcher.kind == "exact" && !isContainer(new):
+			d.change(Changed, responsePath, true, "matcher tightened from %s to an exact match", oldMatcher)
+		case newMatcher.kind == "regex" && oldMatcher.kind != "exact":
+			d.change(Changed, responsePath, true, "matcher changed from %s to %s", oldMatcher, newMatcher)
+		case oldMatcher.kind == "exact" && !isContainer(old):
+			d.change(Changed, responsePath, false, "matcher loosened from an exact match to %s", newMatcher)
+		case oldMatcher.kind != "exact" && newMatcher.kind != "exact":
+			d.change(Changed, responsePath, false, "matcher changed from %s to %s", oldMatcher, newMatcher)
+		}
+	}
+
+	if newMatcher.min > oldMatcher.min {
+		d.change(Changed, responsePath, true, "minimum length increased from %d to %d", oldMatcher.min, newMatcher.min)
+	}
+	if newMatcher.max >= 0 && (oldMatcher.max < 0 || newMatcher.max < oldMatcher.max) {
+		d.change(Changed, responsePath, true, "maximum length decreased from %s to %d", describeMax(oldMatcher.max), newMatcher.max)
+	}
+
+	switch newValue := new.(type) {
+	case map[string]interface{}:
+		oldValue, ok := old.(map[string]interface{})
+		if !ok {
+			d.change(Changed, responsePath, true, "changed from %s to an object", describe(old))
+			return
+		}
+		for _, k := range sortedKeys(newValue, oldValue) {
+			child := append(append([]string{}, path...), k)
+			o, inOld := oldValue[k]
+			n, inNew := newValue[k]
+			switch {
+			case !inOld:
+				d.change(Changed, append([]string{"response"}, child...), true, "field became required")
+			case !inNew:
+				d.change(Changed, append([]string{"response"}, child...), false, "field is no longer expected")
+			default:
+				d.compareValue(child, oldRules, newRules, o, n)
+			}
+		}
+	case []interface{}:
+		oldValue, ok := old.([]interface{})
+		if !ok {
+			d.change(Changed, responsePath, true, "changed from %s to an array", describe(old))
+			return
+		}
+		if newMatcher.kind == "exact" && len(newValue) != len(oldValue) {
+			d.change(Changed, responsePath, true, "expected length changed from %d to %d", len(oldValue), len(newValue))
+		}
+		for i := 0; i < len(newValue) && i < len(oldValue); i++ {
+			d.compareValue(append(append([]string{}, path...), fmt.Sprintf("[%d]", i)), oldRules, newRules, oldValue[i], newValue[i])
+		}
+	default:
+		if newMatcher.kind == "exact" && oldMatcher.kind == "exact" && !reflect.DeepEqual(old, new) {
+			d.change(Changed, responsePath, true, "expected value changed from %s to %s", describe(old), describe(new))
+		}
+		if newMatcher.kind == "type" && jsonType(old) != jsonType(new) {
+			d.change(Changed, responsePath, true, "expected type changed from %s to %s", jsonType(old), jsonType(new))
+		}
+	}
+}
+
+// matcher summarises the matchers that apply to a value.
+type matcher struct {
+	kind  string
+	regex string
+	min   int
+	max   int
+}
+
+func (m matcher) String() string {
+	switch m.kind {
+	case "regex":
+		return fmt.Sprintf("regex '%s'", m.regex)
+	case "exact":
+		return "an exact match"
+	}
+	return m.kind
+}
+
+// findMatcher finds the matcher that applies to the value at a path. Bounds
+// only apply to the path the rule is defined at.
+func findMatcher(rules map[string]interface{}, path []string) matcher {
+	m := matcher{kind: "exact", max: -1}
+	p := formatPath(path)
+	matchers, rulePath := dsl.FindMatchingRules(rules, p)
+
+	for _, rule := range matchers {
+		switch rule.Match {
+		case "regex":
+			m.kind, m.regex = "regex", rule.Regex
+		case "type", "min", "max":
+			if m.kind == "exact" {
+				m.kind = "type"
+			}
+		case "", "equality":
+		default:
+			m.kind = rule.Match
+		}
+		if strings.EqualFold(rulePath, p) {
+			if rule.Min != nil && *rule.Min > m.min {
+				m.min = *rule.Min
+			}
+			if rule.Max != nil {
+				m.max = *rule.Max
+			}
+		}
+	}
+
+	return m
+}
+
+// formatPath formats path segments, e.g. "$.body.items[0]".
+func formatPath(path []string) string {
+	var b bytes.Buffer
+	b.WriteString("$")
+	for _, segment := range path {
+		switch {
+		case strings.HasPrefix(segment, "["):
+			b.WriteString(segment)
+		case strings.ContainsAny(segment, ". []'"):
+			b.WriteString("['" + segment + "']")
+		default:
+			b.WriteString("." + segment)
+		}
+	}
+	return b.String()
+}
+
+// describeMax describes a maximum length, where -1 is unbounded.
+func describeMax(max int) string {
+	if max < 0 {
+		return "unbounded"
+	}
+	return strconv.Itoa(max)
+}
+
+// isContainer reports whether the value is an object or array.
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// jsonType is the JSON type of a value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return "number"
+}
+
+// describe describes a value in a change.
+func describe(v interface{}) string {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return strconv.Quote(value)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// findHeader finds a header, ignoring the case of its name.
+func findHeader(headers map[string]string, name string) (string, bool) {
+	for k, value := range headers {
+		if strings.EqualFold(k, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// headerNames returns the names of the headers of either version, in order,
+// ignoring case.
+func headerNames(old map[string]string, new map[string]string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, headers := range []map[string]string{new, old} {
+		for name := range headers {
+			if !seen[strings.ToLower(name)] {
+				seen[strings.ToLower(name)] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys returns the keys of either object, in order.
+func sortedKeys(new map[string]interface{}, old map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]interface{}{new, old} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
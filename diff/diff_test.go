@@ -0,0 +1,292 @@
+package diff
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/dsl"
+)
+
+func parsePact(t *testing.T, pact string) *dsl.PactFile {
+	var p dsl.PactFile
+	if err := json.Unmarshal([]byte(pact), &p); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return &p
+}
+
+var oldPact = `{
+  "consumer": {"name": "Consumer"},
+  "provider": {"name": "Provider"},
+  "interactions": [
+    {
+      "description": "a request for users",
+      "providerState": "users exist",
+      "request": {"method": "GET", "path": "/users"},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "body": {"users": [{"id": 1, "name": "Billy", "email": "billy@example.com"}], "total": 1},
+        "matchingRules": {
+          "$.body.users": {"min": 1, "match": "type"},
+          "$.body.users[*].name": {"match": "type"},
+          "$.body.total": {"match": "type"}
+        }
+      }
+    },
+    {
+      "description": "a request for a user",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {
+        "status": 200,
+        "body": {"id": 1, "role": "admin", "created": "2018-01-01"},
+        "matchingRules": {
+          "$.body.id": {"match": "type"},
+          "$.body.created": {"match": "regex", "regex": "\\d{4}-\\d{2}-\\d{2}"}
+        }
+      }
+    },
+    {
+      "description": "a request to delete a user",
+      "request": {"method": "DELETE", "path": "/users/1"},
+      "response": {"status": 204}
+    }
+  ]
+}`
+
+func TestCompare_Unchanged(t *testing.T) {
+	report := Compare(parsePact(t, oldPact), parsePact(t, oldPact))
+	if len(report.Changes) != 0 || report.Breaking {
+		t.Fatalf("Expected no changes but got %v", report.Changes)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	newPact := `{
+  "consumer": {"name": "Consumer"},
+  "provider": {"name": "Provider"},
+  "interactions": [
+    {
+      "description": "a request for users",
+      "providerState": "users exist",
+      "request": {"method": "GET", "path": "/users", "query": "page=1"},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "body": {"users": [{"id": 1, "name": "Billy", "email": "billy@example.com", "age": 21}], "total": 1},
+        "matchingRules": {
+          "$.body.users": {"min": 2, "match": "type"},
+          "$.body.users[*].name": {"match": "type"}
+        }
+      }
+    },
+    {
+      "description": "a request for a user",
+      "providerState": "user 1 exists",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {
+        "status": 200,
+        "body": {"id": 1, "created": "2018-01-01"},
+        "matchingRules": {
+          "$.body.id": {"match": "type"},
+          "$.body.created": {"match": "regex", "regex": "\\d{4}-\\d{2}-\\d{2}T.*"}
+        }
+      }
+    },
+    {
+      "description": "a request to create a user",
+      "providerState": "no users exist",
+      "request": {"method": "POST", "path": "/users"},
+      "response": {"status": 201}
+    }
+  ]
+}`
+
+	report := Compare(parsePact(t, oldPact), parsePact(t, newPact))
+	if !report.Breaking {
+		t.Fatalf("Expected the changes to be breaking")
+	}
+
+	expected := []Change{
+		{Kind: Changed, Interaction: "a request for users", Path: "$.request.query", Breaking: false},
+		{Kind: Changed, Interaction: "a request for users", Path: "$.response.body.total", Breaking: true},
+		{Kind: Changed, Interaction: "a request for users", Path: "$.response.body.users", Breaking: true},
+		{Kind: Changed, Interaction: "a request for users", Path: "$.response.body.users[0].age", Breaking: true},
+		{Kind: Changed, Interaction: "a request for a user", Path: "$.providerState", Breaking: true},
+		{Kind: Changed, Interaction: "a request for a user", Path: "$.response.body.created", Breaking: true},
+		{Kind: Changed, Interaction: "a request for a user", Path: "$.response.body.role", Breaking: false},
+		{Kind: Added, Interaction: "a request to create a user", Breaking: true},
+		{Kind: Added, Interaction: "a request to create a user", Breaking: true},
+		{Kind: Removed, Interaction: "a request to delete a user", Breaking: false},
+	}
+
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes but got %d: %v", len(expected), len(report.Changes), report.Changes)
+	}
+	for i, c := range report.Changes {
+		e := expected[i]
+		if c.Kind != e.Kind || c.Interaction != e.Interaction || c.Path != e.Path || c.Breaking != e.Breaking {
+			t.Fatalf("Expected change %d to be %+v but got %+v", i, e, c)
+		}
+	}
+}
+
+func TestCompare_Matchers(t *testing.T) {
+	pact := func(rules string, body string) *dsl.PactFile {
+		return parsePact(t, `{
+		  "consumer": {"name": "Consumer"},
+		  "provider": {"name": "Provider"},
+		  "interactions": [{
+		    "description": "a request",
+		    "request": {"method": "GET", "path": "/"},
+		    "response": {"status": 200, "body": `+body+`, "matchingRules": {`+rules+`}}
+		  }]
+		}`)
+	}
+
+	cases := []struct {
+		name     string
+		old      *dsl.PactFile
+		new      *dsl.PactFile
+		changes  int
+		breaking bool
+	}{
+		{"type to exact", pact(`"$.body.name": {"match": "type"}`, `{"name": "a"}`), pact(``, `{"name": "a"}`), 1, true},
+		{"exact to type", pact(``, `{"name": "a"}`), pact(`"$.body.name": {"match": "type"}`, `{"name": "a"}`), 1, false},
+		{"type to regex", pact(`"$.body.name": {"match": "type"}`, `{"name": "a"}`), pact(`"$.body.name": {"match": "regex", "regex": "a"}`, `{"name": "a"}`), 1, true},
+		{"exact value", pact(``, `{"name": "a"}`), pact(``, `{"name": "b"}`), 1, true},
+		{"typed value", pact(`"$.body.name": {"match": "type"}`, `{"name": "a"}`), pact(`"$.body.name": {"match": "type"}`, `{"name": "b"}`), 0, false},
+		{"type of value", pact(`"$.body.name": {"match": "type"}`, `{"name": "a"}`), pact(`"$.body.name": {"match": "type"}`, `{"name": 1}`), 1, true},
+		{"cascaded type", pact(`"$.body": {"match": "type"}`, `{"a": {"b": 1}}`), pact(`"$.body": {"match": "type"}`, `{"a": {"b": 2}}`), 0, false},
+		{"min decreased", pact(`"$.body": {"min": 2, "match": "type"}`, `[1, 2]`), pact(`"$.body": {"min": 1, "match": "type"}`, `[1]`), 0, false},
+		{"max added", pact(`"$.body": {"match": "type"}`, `[1]`), pact(`"$.body": {"max": 5, "match": "type"}`, `[1]`), 1, true},
+		{"max decreased", pact(`"$.body": {"max": 5, "match": "type"}`, `[1]`), pact(`"$.body": {"max": 3, "match": "type"}`, `[1]`), 1, true},
+		{"array length", pact(``, `[1]`), pact(``, `[1, 2]`), 1, true},
+	}
+
+	for _, c := range cases {
+		report := Compare(c.old, c.new)
+		if len(report.Changes) != c.changes || report.Breaking != c.breaking {
+			t.Fatalf("%s: expected %d changes (breaking: %v) but got %v", c.name, c.changes, c.breaking, report.Changes)
+		}
+	}
+}
+
+func TestCompare_Headers(t *testing.T) {
+	pact := func(headers string) *dsl.PactFile {
+		return parsePact(t, `{
+		  "consumer": {"name": "Consumer"},
+		  "provider": {"name": "Provider"},
+		  "interactions": [{
+		    "description": "a request",
+		    "request": {"method": "GET", "path": "/"},
+		    "response": {"status": 200, "headers": {`+headers+`}}
+		  }]
+		}`)
+	}
+
+	report := Compare(pact(`"Content-Type": "application/json"`), pact(`"content-type": "application/json", "ETag": "1"`))
+	if len(report.Changes) != 1 || !report.Breaking || report.Changes[0].Path != "$.response.headers.ETag" {
+		t.Fatalf("Expected the new ETag header to be breaking but got %v", report.Changes)
+	}
+
+	report = Compare(pact(`"Content-Type": "application/json"`), pact(`"Content-Type": "text/plain"`))
+	if len(report.Changes) != 1 || !report.Breaking {
+		t.Fatalf("Expected the changed header to be breaking but got %v", report.Changes)
+	}
+}
+
+func TestCompare_SameDescription(t *testing.T) {
+	oldPact := `{
+  "interactions": [
+    {
+      "description": "a request for a user",
+      "providerState": "a user exists",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 200, "body": {"id": 1}}
+    },
+    {
+      "description": "a request for a user",
+      "providerState": "no users exist",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 404}
+    }
+  ]
+}`
+	newPact := `{
+  "interactions": [
+    {
+      "description": "a request for a user",
+      "providerStates": [{"name": "no users exist"}],
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 404}
+    },
+    {
+      "description": "a request for a user",
+      "providerStates": [{"name": "a user exists"}],
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 200, "body": {"id": 1, "name": "Billy"}}
+    },
+    {
+      "description": "a request for a user",
+      "providerStates": [{"name": "the user is deleted"}],
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 410}
+    }
+  ]
+}`
+
+	report := Compare(parsePact(t, oldPact), parsePact(t, newPact))
+
+	expected := []string{
+		"'a request for a user (given a user exists)' $.response.body.name: field became required",
+		"'a request for a user (given the user is deleted)': new interaction GET /users/1",
+		"'a request for a user (given the user is deleted)': new provider state 'the user is deleted'",
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes but got %v", len(expected), report.Changes)
+	}
+	for i, c := range report.Changes {
+		if !strings.HasPrefix(c.String(), expected[i]) {
+			t.Fatalf("Expected change %d to be '%s' but got '%s'", i, expected[i], c)
+		}
+	}
+}
+
+func TestCompare_ChangedState(t *testing.T) {
+	newPact := strings.Replace(oldPact, `"providerState": "users exist"`, `"providerState": "some users exist"`, 1)
+
+	report := Compare(parsePact(t, oldPact), parsePact(t, newPact))
+	if len(report.Changes) != 2 || report.Changes[0].Path != "$.providerState" || report.Changes[0].Interaction != "a request for users" {
+		t.Fatalf("Expected the provider state of the interaction to have changed but got %v", report.Changes)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pact-diff")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "pact.json")
+	if err = ioutil.WriteFile(file, []byte(oldPact), 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	report, err := CompareFiles(file, file)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(report.Changes) != 0 {
+		t.Fatalf("Expected no changes but got %v", report.Changes)
+	}
+
+	if _, err = CompareFiles(file, filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf("Expected an error for a missing pact file")
+	}
+}
@@ -0,0 +1,173 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// BinaryBody is a body of binary content, such as a PDF, image or protobuf
+// message. Use ContentType to match any content of a media type, or Binary
+// to match content exactly.
+//
+// A BinaryBody is sent to the mock service, and written to a PactFile, as
+// its example encoded in base64. Pact.WritePact, and adding it to a
+// PactFile, write a ContentType body as a "contentType" matching rule for
+// "$.body", which the StubServer checks by the magic bytes of the content,
+// or by MIME sniffing.
+type BinaryBody struct {
+	contentType string
+	example     []byte
+	exact       bool
+}
+
+// magicBytes are the prefixes of the content of common binary media types.
+var magicBytes = map[string][]string{
+	"application/pdf":  {"%PDF-"},
+	"application/zip":  {"PK\x03\x04"},
+	"application/gzip": {"\x1f\x8b"},
+	"image/png":        {"\x89PNG\r\n\x1a\n"},
+	"image/jpeg":       {"\xff\xd8\xff"},
+	"image/gif":        {"GIF87a", "GIF89a"},
+}
+
+// ContentType creates a binary body matching any content of the media type,
+// e.g. "application/pdf". Unless an Example is given, the example is the
+// magic bytes of the media type, if known. Content of a media type that has
+// no known magic bytes, and that MIME sniffing does not recognise, such as
+// "application/x-protobuf", can't be checked by its type, so it must equal
+// the Example, as with Binary.
+//
+// Pact.Verify matches a request body by its content type, and Pact.WritePact
+// writes the "contentType" rule into the Pact file. Responses are returned
+// as the decoded example, by both Pact.Verify and the StubServer, which also
+// checks the rule. However, the provider verifier of the pact CLI tools does
+// not support the rule, so it compares the body with the example.
+func ContentType(contentType string) *BinaryBody {
+	b := &BinaryBody{contentType: contentType}
+	if magic, ok := magicBytes[mediaType(contentType)]; ok {
+		b.example = []byte(magic[0])
+	}
+	return b
+}
+
+// Binary creates a binary body that must equal the content exactly. Its
+// media type is detected from the content.
+func Binary(content []byte) *BinaryBody {
+	return &BinaryBody{contentType: detectContentType(content), example: content, exact: true}
+}
+
+// Example sets the example content of the body.
+func (b *BinaryBody) Example(content []byte) *BinaryBody {
+	b.example = content
+	return b
+}
+
+// ContentType returns the media type of the body, for its Content-Type
+// header.
+func (b *BinaryBody) ContentType() string {
+	return b.contentType
+}
+
+// Bytes returns the example content.
+func (b *BinaryBody) Bytes() []byte {
+	return b.example
+}
+
+// String returns the example content encoded in base64.
+func (b *BinaryBody) String() string {
+	return base64.StdEncoding.EncodeToString(b.example)
+}
+
+// MarshalJSON writes the example content as a base64 JSON string.
+func (b *BinaryBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// bodyRules adds a contentType rule for the body, unless it is exact.
+func (b *BinaryBody) bodyRules(path []string, rules map[string]interface{}) {
+	if !b.exact {
+		rules[formatPath(path)] = map[string]interface{}{"match": "contentType", "value": b.contentType}
+	}
+}
+
+// validateBody checks the media type, and that the example is of that type.
+func (b *BinaryBody) validateBody(path []string) []string {
+	if _, _, err := mime.ParseMediaType(b.contentType); err != nil {
+		return []string{fmt.Sprintf("%s: invalid content type %q: %v", formatPath(path), b.contentType, err)}
+	}
+	if !b.exact && len(b.example) > 0 && !matchesContentType(b.example, b.contentType, b.example) {
+		return []string{fmt.Sprintf("%s: example is %s, not %s", formatPath(path), detectContentType(b.example), b.contentType)}
+	}
+	return nil
+}
+
+// binaryBody converts a []byte body to a string if it is valid UTF-8 text,
+// or a BinaryBody otherwise, rather than it being encoded as JSON.
+func binaryBody(body interface{}) interface{} {
+	content, ok := body.([]byte)
+	if !ok {
+		return body
+	}
+	if utf8.Valid(content) && isTextContentType(detectContentType(content)) {
+		return string(content)
+	}
+	return Binary(content)
+}
+
+// matchesContentType reports whether content is of a media type: by its
+// magic bytes if known, or else by MIME sniffing. Content of a media type
+// that can't be recognised either way, such as a protobuf message, must
+// equal the example.
+func matchesContentType(content []byte, contentType string, example []byte) bool {
+	expected := mediaType(contentType)
+	if magic, ok := magicBytes[expected]; ok {
+		for _, prefix := range magic {
+			if bytes.HasPrefix(content, []byte(prefix)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if detectContentType(content) == expected {
+		return true
+	}
+	return example != nil && bytes.Equal(content, example)
+}
+
+// detectContentType sniffs the media type of content.
+func detectContentType(content []byte) string {
+	return mediaType(http.DetectContentType(content))
+}
+
+// mediaType removes the parameters of a content type, e.g. "; charset=utf-8".
+func mediaType(contentType string) string {
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		return parsed
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// isTextContentType reports whether the content type is text based, such as
+// text/*, JSON, NDJSON, XML, or a form.
+func isTextContentType(contentType string) bool {
+	m := mediaType(contentType)
+	return strings.HasPrefix(m, "text/") ||
+		strings.HasPrefix(m, "multipart/") ||
+		m == "application/json" || strings.HasSuffix(m, "+json") ||
+		m == "application/xml" || strings.HasSuffix(m, "+xml") ||
+		m == "application/javascript" || m == NDJSONContentType ||
+		m == "application/x-www-form-urlencoded"
+}
+
+// isBinaryContentType reports whether bodies of the content type are binary,
+// and so written to Pact files in base64.
+func isBinaryContentType(contentType string) bool {
+	return contentType != "" && !isTextContentType(contentType)
+}
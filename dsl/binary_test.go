@@ -0,0 +1,172 @@
+package dsl
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var pdf = []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj")
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestBinary_matchesContentType(t *testing.T) {
+	message := []byte("\x0a\x05Billy\x10\x01")
+	cases := []struct {
+		content     []byte
+		contentType string
+		example     []byte
+		matches     bool
+	}{
+		{pdf, "application/pdf", nil, true},
+		{png, "application/pdf", nil, false},
+		{png, "image/png", nil, true},
+		{[]byte("GIF89a..."), "image/gif", nil, true},
+		{message, "application/x-protobuf", message, true},
+		{message, "application/x-protobuf", nil, false},
+		{[]byte("\x0a\x05Sally\x10\x02"), "application/x-protobuf", message, false},
+		{[]byte("plain text"), "application/x-protobuf", message, false},
+		{[]byte("<html><body></body></html>"), "application/x-protobuf", message, false},
+		{[]byte("<html><body></body></html>"), "text/html; charset=utf-8", nil, true},
+		{[]byte("plain text"), "text/csv", nil, false},
+	}
+
+	for _, c := range cases {
+		if matchesContentType(c.content, c.contentType, c.example) != c.matches {
+			t.Fatalf("Expected %q to match %s with the example %q: %v", c.content, c.contentType, c.example, c.matches)
+		}
+	}
+}
+
+func TestBinary_binaryBody(t *testing.T) {
+	if body := binaryBody([]byte(`{"name": "billy"}`)); body != `{"name": "billy"}` {
+		t.Fatalf("Expected text to be a string but got %v", body)
+	}
+	if body, ok := binaryBody(pdf).(*BinaryBody); !ok || body.ContentType() != "application/pdf" || string(body.Bytes()) != string(pdf) {
+		t.Fatalf("Expected a PDF to be a binary body but got %v", body)
+	}
+	if body := binaryBody(42); body != 42 {
+		t.Fatalf("Expected other bodies to be unchanged but got %v", body)
+	}
+
+	i := (&Interaction{}).
+		WithRequest(Request{Body: png}).
+		WillRespondWith(Response{Body: []byte("OK")})
+	if _, ok := i.Request.Body.(*BinaryBody); !ok {
+		t.Fatalf("Expected the request body to be binary but got %T", i.Request.Body)
+	}
+	if i.Response.Body != "OK" {
+		t.Fatalf("Expected the response body to be a string but got %v", i.Response.Body)
+	}
+}
+
+func TestBinary_PactFile(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request to upload a document",
+		Request: Request{
+			Method:  "PUT",
+			Path:    String("/documents/1"),
+			Headers: MapMatcher{"Content-Type": String("application/pdf")},
+			Body:    ContentType("application/pdf"),
+		},
+		Response: Response{
+			Status:  200,
+			Headers: MapMatcher{"Content-Type": String("image/png")},
+			Body:    Binary(png),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	interaction := pact.Interactions[0]
+	if interaction.Request.Body != base64.StdEncoding.EncodeToString([]byte("%PDF-")) {
+		t.Fatalf("Expected the request body to be the magic bytes in base64 but got %v", interaction.Request.Body)
+	}
+	if interaction.Response.Body != base64.StdEncoding.EncodeToString(png) {
+		t.Fatalf("Expected the response body to be the example in base64 but got %v", interaction.Response.Body)
+	}
+	if interaction.Response.MatchingRules != nil {
+		t.Fatalf("Expected an exact binary body to have no matching rules but got %v", interaction.Response.MatchingRules)
+	}
+
+	cases := []struct {
+		body       []byte
+		mismatches int
+	}{
+		{pdf, 0},
+		{png, 1},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("PUT", "/documents/1", nil)
+		req.Header.Set("Content-Type", "application/pdf")
+		if mismatches := matchRequest(interaction.Request, req, c.body); len(mismatches) != c.mismatches {
+			t.Fatalf("Expected %d mismatches for %q but got %v", c.mismatches, c.body, mismatches)
+		}
+	}
+
+	// Exact bodies must equal the example
+	exact := interaction.Request
+	exact.Body = interaction.Response.Body
+	exact.MatchingRules = nil
+	req := httptest.NewRequest("PUT", "/documents/1", nil)
+	req.Header.Set("Content-Type", "application/pdf")
+	if mismatches := matchRequest(exact, req, png); len(mismatches) != 0 {
+		t.Fatalf("Expected the exact body to match but got %v", mismatches)
+	}
+	if mismatches := matchRequest(exact, req, pdf); len(mismatches) != 1 {
+		t.Fatalf("Expected a different body not to match but got %v", mismatches)
+	}
+
+	rec := httptest.NewRecorder()
+	writeStubResponse(rec, interaction.Response)
+	res := rec.Result()
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != string(png) {
+		t.Fatalf("Expected the stub server to decode the body but got %q", body)
+	}
+}
+
+func TestBinary_addBodyRules(t *testing.T) {
+	interaction := &Interaction{
+		Description: "a request to upload a document",
+		Request:     Request{Method: "PUT", Path: String("/documents/1"), Body: ContentType("application/pdf")},
+		Response:    Response{Status: 200, Body: Binary(png)},
+	}
+	pact := PactFile{
+		Interactions: []PactInteraction{{Description: "a request to upload a document"}},
+		Metadata:     map[string]interface{}{"pactSpecification": map[string]interface{}{"version": "3.0.0"}},
+	}
+	pact.addBodyRules([]*Interaction{interaction})
+
+	expected := map[string]interface{}{
+		"body": map[string]interface{}{
+			"$": map[string]interface{}{"matchers": []interface{}{map[string]interface{}{"match": "contentType", "value": "application/pdf"}}},
+		},
+	}
+	if rules := pact.Interactions[0].Request.MatchingRules; !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Expected the contentType rule %v but got %v", expected, rules)
+	}
+	if rules := pact.Interactions[0].Response.MatchingRules; rules != nil {
+		t.Fatalf("Expected an exact binary body to have no matching rules but got %v", rules)
+	}
+}
+
+func TestBinary_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		WithRequest(Request{Method: "PUT", Path: String("/documents/1"), Body: ContentType("application/pdf").Example(png)}).
+		WillRespondWith(Response{Status: 200, Body: ContentType("not a type")})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 2 || !strings.Contains(problems[0], "example is image/png, not application/pdf") || !strings.HasPrefix(problems[1], "$.response.body: invalid content type") {
+		t.Fatalf("Expected the example and content type to be invalid but got %v", problems)
+	}
+}
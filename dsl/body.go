@@ -0,0 +1,39 @@
+package dsl
+
+// structuredBody is a body built with its own Matchers, rather than a JSON
+// document: an XMLElement, FormBody, MultipartBody, BinaryBody or
+// StreamBody. It is written to a Pact file as its example String, along
+// with its matching rules.
+type structuredBody interface {
+	// String returns the example body, as written to a Pact file.
+	String() string
+
+	// ContentType returns the media type of the body, for its Content-Type
+	// header.
+	ContentType() string
+
+	// bodyRules adds the matching rules of the body at path, e.g. ["body"].
+	bodyRules(path []string, rules map[string]interface{})
+
+	// validateBody checks the Matchers of the body at path.
+	validateBody(path []string) []string
+}
+
+// extractBody returns the example of a body, adding its matching rules at
+// path.
+func extractBody(path []string, body interface{}, rules map[string]interface{}) (interface{}, error) {
+	if b, ok := body.(structuredBody); ok {
+		b.bodyRules(path, rules)
+		return b.String(), nil
+	}
+	return extractMatchers(path, body, rules)
+}
+
+// bodyProblems returns the problems found in the Matchers of a structured
+// body, which validateMatchers can't see through.
+func bodyProblems(path []string, body interface{}) []string {
+	if b, ok := body.(structuredBody); ok {
+		return b.validateBody(path)
+	}
+	return nil
+}
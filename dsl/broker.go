@@ -1,19 +1,22 @@
 package dsl
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/pact-foundation/pact-go/types"
 )
 
 var (
-	pactURLPattern        = "%s/pacts/provider/%s/latest"
-	pactURLPatternWithTag = "%s/pacts/provider/%s/latest/%s"
+	pactURLPattern                 = "%s/pacts/provider/%s/latest"
+	pactURLPatternWithTag          = "%s/pacts/provider/%s/latest/%s"
+	pactsForVerificationURLPattern = "%s/pacts/provider/%s/for-verification"
 
 	// ErrNoConsumers is returned when no consumer are not found for a provider.
 	ErrNoConsumers = errors.New("no consumers found")
@@ -40,17 +43,58 @@ type HalDoc struct {
 	Links HalLinks `json:"_links"`
 }
 
+// consumerVersionSelector selects the consumer versions to verify when using
+// the pacts for verification API.
+type consumerVersionSelector struct {
+	Tag    string `json:"tag"`
+	Latest bool   `json:"latest"`
+}
+
+// pactsForVerificationRequest is the body sent to the pacts for verification
+// API of a Pact Broker.
+type pactsForVerificationRequest struct {
+	ConsumerVersionSelectors []consumerVersionSelector `json:"consumerVersionSelectors,omitempty"`
+	ProviderVersionTags      []string                  `json:"providerVersionTags,omitempty"`
+	IncludePendingStatus     bool                      `json:"includePendingStatus"`
+	IncludeWIPPactsSince     string                    `json:"includeWipPactsSince,omitempty"`
+}
+
+// pactsForVerificationResponse is a subset of the HAL response from the
+// pacts for verification API.
+type pactsForVerificationResponse struct {
+	Embedded struct {
+		Pacts []struct {
+			ShortDescription       string `json:"shortDescription"`
+			VerificationProperties struct {
+				Pending bool `json:"pending"`
+				WIP     bool `json:"wip"`
+				Notices []struct {
+					When string `json:"when"`
+					Text string `json:"text"`
+				} `json:"notices"`
+			} `json:"verificationProperties"`
+			Links struct {
+				Self PactLink `json:"self"`
+			} `json:"_links"`
+		} `json:"pacts"`
+	} `json:"_embedded"`
+}
+
 // findConsumers navigates a Pact Broker's HAL system to find consumers
 // based on the latest Pacts or using tags.
 //
 // There are 2 Scenarios:
 //
-//   1. Ask for all 'latest' consumers
-//   2. Pass a set of tags (e.g. 'latest' and 'prod') and find all consumers
-//      that match
+//  1. Ask for all 'latest' consumers
+//  2. Pass a set of tags (e.g. 'latest' and 'prod') and find all consumers
+//     that match
 func findConsumers(provider string, request *types.VerifyRequest) error {
 	log.Println("[DEBUG] broker - find consumers for provider:", provider)
 
+	if request.EnablePending || request.IncludeWIPPactsSince != nil {
+		return findPactsForVerification(provider, request)
+	}
+
 	client := &http.Client{}
 	var urls []string
 	pactURLs := make(map[string]string)
@@ -123,3 +167,202 @@ func findConsumers(provider string, request *types.VerifyRequest) error {
 
 	return nil
 }
+
+// findPactsForVerification uses the pacts for verification API of a Pact
+// Broker to find the pacts to verify, along with their pending status.
+// Pending and WIP pacts are added to the PendingPactURLs of the request, all
+// others to the PactURLs.
+func findPactsForVerification(provider string, request *types.VerifyRequest) error {
+	log.Println("[DEBUG] broker - find pacts for verification for provider:", provider)
+
+	body := pactsForVerificationRequest{
+		ProviderVersionTags:  request.ProviderTags,
+		IncludePendingStatus: request.EnablePending,
+	}
+	for _, tag := range request.Tags {
+		body.ConsumerVersionSelectors = append(body.ConsumerVersionSelectors, consumerVersionSelector{
+			Tag:    tag,
+			Latest: true,
+		})
+	}
+	if request.IncludeWIPPactsSince != nil {
+		body.IncludeWIPPactsSince = request.IncludeWIPPactsSince.Format(time.RFC3339)
+	}
+
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf(pactsForVerificationURLPattern, request.BrokerURL, provider), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/hal+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	if request.BrokerUsername != "" && request.BrokerPassword != "" {
+		req.SetBasicAuth(request.BrokerUsername, request.BrokerPassword)
+	}
+
+	res, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case 401:
+		return ErrUnauthorized
+	case 404:
+		return ErrNoConsumers
+	}
+
+	responseBody, err := ioutil.ReadAll(res.Body)
+	log.Printf("[DEBUG] pact broker response Body: %s\n", responseBody)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.New(string(responseBody))
+	}
+
+	var doc pactsForVerificationResponse
+	err = json.Unmarshal(responseBody, &doc)
+	if err != nil {
+		return err
+	}
+
+	if len(doc.Embedded.Pacts) == 0 {
+		return ErrNoConsumers
+	}
+
+	for _, p := range doc.Embedded.Pacts {
+		for _, notice := range p.VerificationProperties.Notices {
+			log.Println("[INFO] broker -", notice.Text)
+		}
+
+		if p.VerificationProperties.Pending || p.VerificationProperties.WIP {
+			request.PendingPactURLs = append(request.PendingPactURLs, p.Links.Self.Href)
+		} else {
+			request.PactURLs = append(request.PactURLs, p.Links.Self.Href)
+		}
+	}
+
+	log.Println("[DEBUG] pacts to verify:", request.PactURLs, "pending:", request.PendingPactURLs)
+
+	return nil
+}
+
+// Broker is a client for the HAL API of a Pact Broker.
+type Broker struct {
+	// URL of the Pact Broker.
+	URL string
+
+	// Username for Pact Broker basic authentication. Optional
+	Username string
+
+	// Password for Pact Broker basic authentication. Optional
+	Password string
+
+	client *http.Client
+}
+
+// SetClient allows dsl users to configure the http.Client used when talking
+// to a Pact Broker.
+func (b *Broker) SetClient(client *http.Client) {
+	b.client = client
+}
+
+// validate checks the Broker has been configured.
+func (b *Broker) validate() error {
+	if b.URL == "" {
+		return errors.New("Broker URL is mandatory")
+	}
+
+	return nil
+}
+
+// call sends a request to the Pact Broker, decoding any JSON response into out.
+func (b *Broker) call(method string, url string, body interface{}, out interface{}) error {
+	if b.client == nil {
+		b.client = &http.Client{}
+	}
+
+	var content []byte
+	if body != nil {
+		var err error
+		content, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/hal+json")
+	if body != nil {
+		if method == "PATCH" {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		} else {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	if b.Username != "" && b.Password != "" {
+		req.SetBasicAuth(b.Username, b.Password)
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	responseBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	log.Printf("[DEBUG] pact broker response Body: %s\n", responseBody)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode == 401 {
+		return ErrUnauthorized
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.New(string(responseBody))
+	}
+
+	if out != nil && len(responseBody) > 0 {
+		return json.Unmarshal(responseBody, out)
+	}
+
+	return nil
+}
+
+// PublishVerificationResult publishes the result of verifying the Pact at
+// pactURL, following the Pact's 'pb:publish-verification-results' link.
+// The result may come from any verifier, see types.NewVerificationResult.
+func (b *Broker) PublishVerificationResult(pactURL string, result types.VerificationResult) error {
+	log.Println("[DEBUG] broker - publishing verification result for pact:", pactURL)
+
+	var pact struct {
+		Links struct {
+			PublishVerificationResults PactLink `json:"pb:publish-verification-results"`
+		} `json:"_links"`
+	}
+	err := b.call("GET", pactURL, nil, &pact)
+	if err != nil {
+		return err
+	}
+
+	href := pact.Links.PublishVerificationResults.Href
+	if href == "" {
+		return fmt.Errorf("unable to publish verification results: pact %s has no 'pb:publish-verification-results' link", pactURL)
+	}
+
+	log.Println("[DEBUG] broker - publishing verification result to:", href)
+	return b.call("POST", href, result, nil)
+}
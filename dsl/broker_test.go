@@ -1,12 +1,16 @@
 package dsl
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pact-foundation/pact-go/brokertest"
 	"github.com/pact-foundation/pact-go/types"
 	"github.com/pact-foundation/pact-go/utils"
 )
@@ -142,6 +146,165 @@ func TestBroker_findConsumersAuthenticatedFail(t *testing.T) {
 	}
 }
 
+func TestBroker_findConsumersPending(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	request := types.VerifyRequest{
+		BrokerURL:     s.URL,
+		Tags:          []string{"dev"},
+		ProviderTags:  []string{"master"},
+		EnablePending: true,
+	}
+	err := findConsumers("bobby", &request)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if len(request.PactURLs) != 1 {
+		t.Fatalf("Expected 1 PactURLs but got: %d, %s", len(request.PactURLs), request.PactURLs)
+	}
+
+	if len(request.PendingPactURLs) != 1 {
+		t.Fatalf("Expected 1 PendingPactURLs but got: %d, %s", len(request.PendingPactURLs), request.PendingPactURLs)
+	}
+}
+
+func TestBroker_findConsumersWIP(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	since := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	request := types.VerifyRequest{
+		BrokerURL:            s.URL,
+		IncludeWIPPactsSince: &since,
+	}
+	err := findConsumers("bobby", &request)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	if len(request.PendingPactURLs) != 2 {
+		t.Fatalf("Expected 2 PendingPactURLs but got: %d, %s", len(request.PendingPactURLs), request.PendingPactURLs)
+	}
+}
+
+func TestBroker_findConsumersPendingNoConsumers(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	request := types.VerifyRequest{
+		BrokerURL:     s.URL,
+		EnablePending: true,
+	}
+	err := findConsumers("idontexist", &request)
+
+	if err != ErrNoConsumers {
+		t.Fatalf("Expected error to be 'ErrNoConsumers' but got %v", err)
+	}
+}
+
+func TestBroker_findConsumersPendingAuthenticatedFail(t *testing.T) {
+	s := setupMockBroker(true)
+	defer s.Close()
+	request := types.VerifyRequest{
+		BrokerURL:     s.URL,
+		EnablePending: true,
+	}
+	err := findConsumers("bobby", &request)
+
+	if err != ErrUnauthorized {
+		t.Fatalf("Expected error to be 'ErrUnauthorized' but got %v", err)
+	}
+}
+
+func TestBroker_findConsumersPendingBrokerTest(t *testing.T) {
+	broker := brokertest.NewServer()
+	defer broker.Close()
+	broker.PublishPact("1.0.0", []byte(`{"consumer":{"name":"billy"},"provider":{"name":"bobby"}}`), "prod")
+	broker.PublishPact("1.0.0", []byte(`{"consumer":{"name":"jessica"},"provider":{"name":"bobby"}}`), "prod")
+
+	err := (&Broker{URL: broker.URL}).PublishVerificationResult(broker.URL+"/pacts/provider/bobby/consumer/billy/version/1.0.0", types.VerificationResult{
+		Success:                    true,
+		ProviderApplicationVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	request := types.VerifyRequest{
+		BrokerURL:     broker.URL,
+		Tags:          []string{"prod"},
+		EnablePending: true,
+	}
+	if err = findConsumers("bobby", &request); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(request.PactURLs) != 1 || !strings.Contains(request.PactURLs[0], "/consumer/billy/") {
+		t.Fatalf("Expected the verified billy pact but got: %v", request.PactURLs)
+	}
+	if len(request.PendingPactURLs) != 1 || !strings.Contains(request.PendingPactURLs[0], "/consumer/jessica/") {
+		t.Fatalf("Expected the unverified jessica pact to be pending but got: %v", request.PendingPactURLs)
+	}
+}
+
+func TestBroker_PublishVerificationResult(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	err := broker.PublishVerificationResult(fmt.Sprintf("%s/pacts/provider/bobby/consumer/billy/version/1.0.0", s.URL), types.VerificationResult{
+		Success:                    true,
+		ProviderApplicationVersion: "1.0.0",
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func TestBroker_PublishVerificationResultNoLink(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	err := broker.PublishVerificationResult(fmt.Sprintf("%s/pacts/provider/bobby/consumer/nobroker/version/1.0.0", s.URL), types.VerificationResult{
+		Success:                    true,
+		ProviderApplicationVersion: "1.0.0",
+	})
+
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestBroker_PublishVerificationResultAuthenticated(t *testing.T) {
+	s := setupMockBroker(true)
+	defer s.Close()
+	pactURL := fmt.Sprintf("%s/pacts/provider/bobby/consumer/billy/version/1.0.0", s.URL)
+	result := types.VerificationResult{
+		Success:                    false,
+		ProviderApplicationVersion: "1.0.0",
+	}
+
+	broker := &Broker{URL: s.URL, Username: "foo", Password: "bar"}
+	if err := broker.PublishVerificationResult(pactURL, result); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	broker = &Broker{URL: s.URL}
+	if err := broker.PublishVerificationResult(pactURL, result); err != ErrUnauthorized {
+		t.Fatalf("Expected error to be 'ErrUnauthorized' but got %v", err)
+	}
+}
+
+func TestBroker_SetClient(t *testing.T) {
+	b := &Broker{}
+	client := &http.Client{}
+	b.SetClient(client)
+	if b.client != client {
+		t.Fatalf("SetClient Failed To Set Client On Broker")
+	}
+}
+
 // Pretend to be a Broker for fetching Pacts
 func setupMockBroker(auth bool) *httptest.Server {
 	mux := http.NewServeMux()
@@ -213,13 +376,44 @@ func setupMockBroker(auth bool) *httptest.Server {
 		w.Header().Add("Content-Type", "application/hal+json")
 	}))
 
+	// Find 'bobby' pacts for verification, with their pending status
+	mux.Handle("/pacts/provider/bobby/for-verification", authFunc(func(w http.ResponseWriter, req *http.Request) {
+		log.Println("[DEBUG] get pacts for verification for provider 'bobby'")
+		var body pactsForVerificationRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || req.Method != "POST" {
+			w.WriteHeader(400)
+			return
+		}
+		wip := body.IncludeWIPPactsSince != ""
+		fmt.Fprintf(w, `{"_embedded":{"pacts":[{"shortDescription":"latest","verificationProperties":{"pending":false,"wip":%t,"notices":[{"when":"before_verification","text":"The pact at ... is being verified because it matches the following configured selection criterion: latest"}]},"_links":{"self":{"href":"%s/pacts/provider/bobby/consumer/jessica/version/2.0.0","name":"Pact between jessica (v2.0.0) and bobby"}}},{"shortDescription":"latest dev","verificationProperties":{"pending":%t,"wip":%t,"notices":[]},"_links":{"self":{"href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.1","name":"Pact between billy (v1.0.1) and bobby"}}}]}}`, wip, server.URL, body.IncludePendingStatus, wip, server.URL)
+		w.Header().Add("Content-Type", "application/hal+json")
+	}))
+
 	// Actual Consumer Pact
 	// curl -v --user pactuser:pact -H "accept: application/json" http://pact.onegeek.com.au/pacts/provider/bobby/consumer/billy/version/1.0.0
-	mux.Handle("/pacts/provider/bobby/consumer/billy/version/", authFunc(func(w http.ResponseWriter, req *http.Request) {
+	mux.Handle("/pacts/provider/bobby/consumer/", authFunc(func(w http.ResponseWriter, req *http.Request) {
 		log.Println("[DEBUG] get all pacts for provider 'bobby' where any tag exists")
-		fmt.Fprintf(w, `{"consumer":{"name":"billy"},"provider":{"name":"bobby"},"interactions":[{"description":"Some name for the test","provider_state":"Some state","request":{"method":"GET","path":"/foobar"},"response":{"status":200,"headers":{"Content-Type":"application/json"}}},{"description":"Some name for the test","provider_state":"Some state2","request":{"method":"GET","path":"/bazbat"},"response":{"status":200,"headers":{},"body":[[{"colour":"red","size":10,"tag":[["jumper","shirt"],["jumper","shirt"]]}]],"matchingRules":{"$.body":{"min":1},"$.body[*].*":{"match":"type"},"$.body[*]":{"min":1},"$.body[*][*].*":{"match":"type"},"$.body[*][*].colour":{"match":"regex","regex":"red|green|blue"},"$.body[*][*].size":{"match":"type"},"$.body[*][*].tag":{"min":2},"$.body[*][*].tag[*].*":{"match":"type"},"$.body[*][*].tag[*][0]":{"match":"type"},"$.body[*][*].tag[*][1]":{"match":"type"}}}}],"metadata":{"pactSpecificationVersion":"2.0.0"},"updatedAt":"2016-06-11T13:11:33+00:00","createdAt":"2016-06-09T12:46:42+00:00","_links":{"self":{"title":"Pact","name":"Pact between billy (v1.0.0) and bobby","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0"},"pb:consumer":{"title":"Consumer","name":"billy","href":"%s/pacticipants/billy"},"pb:provider":{"title":"Provider","name":"bobby","href":"%s/pacticipants/bobby"},"pb:latest-pact-version":{"title":"Pact","name":"Latest version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/latest"},"pb:previous-distinct":{"title":"Pact","name":"Previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/previous-distinct"},"pb:diff-previous-distinct":{"title":"Diff","name":"Diff with previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/diff/previous-distinct"},"pb:pact-webhooks":{"title":"Webhooks for the pact between billy and bobby","href":"%s/webhooks/provider/bobby/consumer/billy"},"pb:tag-prod-version":{"title":"Tag this version as 'production'","href":"%s/pacticipants/billy/versions/1.0.0/tags/prod"},"pb:tag-version":{"title":"Tag version","href":"%s/pacticipants/billy/versions/1.0.0/tags/{tag}"},"curies":[{"name":"pb","href":"%s/doc/{rel}","templated":true}]}}`, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL)
+		fmt.Fprintf(w, `{"consumer":{"name":"billy"},"provider":{"name":"bobby"},"interactions":[{"description":"Some name for the test","provider_state":"Some state","request":{"method":"GET","path":"/foobar"},"response":{"status":200,"headers":{"Content-Type":"application/json"}}},{"description":"Some name for the test","provider_state":"Some state2","request":{"method":"GET","path":"/bazbat"},"response":{"status":200,"headers":{},"body":[[{"colour":"red","size":10,"tag":[["jumper","shirt"],["jumper","shirt"]]}]],"matchingRules":{"$.body":{"min":1},"$.body[*].*":{"match":"type"},"$.body[*]":{"min":1},"$.body[*][*].*":{"match":"type"},"$.body[*][*].colour":{"match":"regex","regex":"red|green|blue"},"$.body[*][*].size":{"match":"type"},"$.body[*][*].tag":{"min":2},"$.body[*][*].tag[*].*":{"match":"type"},"$.body[*][*].tag[*][0]":{"match":"type"},"$.body[*][*].tag[*][1]":{"match":"type"}}}}],"metadata":{"pactSpecificationVersion":"2.0.0"},"updatedAt":"2016-06-11T13:11:33+00:00","createdAt":"2016-06-09T12:46:42+00:00","_links":{"self":{"title":"Pact","name":"Pact between billy (v1.0.0) and bobby","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0"},"pb:consumer":{"title":"Consumer","name":"billy","href":"%s/pacticipants/billy"},"pb:provider":{"title":"Provider","name":"bobby","href":"%s/pacticipants/bobby"},"pb:latest-pact-version":{"title":"Pact","name":"Latest version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/latest"},"pb:previous-distinct":{"title":"Pact","name":"Previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/previous-distinct"},"pb:diff-previous-distinct":{"title":"Diff","name":"Diff with previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/diff/previous-distinct"},"pb:pact-webhooks":{"title":"Webhooks for the pact between billy and bobby","href":"%s/webhooks/provider/bobby/consumer/billy"},"pb:tag-prod-version":{"title":"Tag this version as 'production'","href":"%s/pacticipants/billy/versions/1.0.0/tags/prod"},"pb:tag-version":{"title":"Tag version","href":"%s/pacticipants/billy/versions/1.0.0/tags/{tag}"},"pb:publish-verification-results":{"title":"Publish verification results","href":"%s/pacts/provider/bobby/consumer/billy/pact-version/1234/verification-results"},"curies":[{"name":"pb","href":"%s/doc/{rel}","templated":true}]}}`, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL)
 		w.Header().Add("Content-Type", "application/hal+json")
 	}))
 
+	// Publish verification results
+	mux.Handle("/pacts/provider/bobby/consumer/billy/pact-version/1234/verification-results", authFunc(func(w http.ResponseWriter, req *http.Request) {
+		log.Println("[DEBUG] publish verification results for billy and bobby")
+		var result types.VerificationResult
+		if err := json.NewDecoder(req.Body).Decode(&result); err != nil || req.Method != "POST" || result.ProviderApplicationVersion == "" {
+			w.WriteHeader(400)
+			return
+		}
+		w.Header().Add("Content-Type", "application/hal+json")
+		w.WriteHeader(201)
+		fmt.Fprintf(w, `{"success":%t,"providerApplicationVersion":"%s"}`, result.Success, result.ProviderApplicationVersion)
+	}))
+
+	// A pact that was not retrieved from a Pact Broker
+	mux.Handle("/pacts/provider/bobby/consumer/nobroker/version/1.0.0", authFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `{"consumer":{"name":"nobroker"},"provider":{"name":"bobby"},"interactions":[]}`)
+	}))
+
 	return server
 }
@@ -0,0 +1,70 @@
+package dsl
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// CanIDeploy queries the matrix of the Pact Broker to find out whether the
+// requested version of a pacticipant is compatible with the versions it will
+// be deployed alongside. The matrix rows are returned for reporting; check
+// Summary.Deployable for the decision.
+//
+// When RetryWhileUnknown is set, the matrix is polled until there are no
+// unknown verification results, or the retries are exhausted.
+func (b *Broker) CanIDeploy(request types.CanIDeployRequest) (types.MatrixResponse, error) {
+	log.Println("[DEBUG] broker - can i deploy:", request.Pacticipant)
+	var response types.MatrixResponse
+
+	if err := request.Validate(); err != nil {
+		return response, err
+	}
+
+	if err := b.validate(); err != nil {
+		return response, err
+	}
+
+	query := url.Values{}
+	query.Set("q[][pacticipant]", request.Pacticipant)
+	switch {
+	case request.Version != "":
+		query.Set("q[][version]", request.Version)
+	case request.LatestTag != "":
+		query.Set("q[][latest]", "true")
+		query.Set("q[][tag]", request.LatestTag)
+	default:
+		query.Set("q[][latest]", "true")
+	}
+	query.Set("latestby", "cvp")
+
+	switch {
+	case request.ToEnvironment != "":
+		query.Set("environment", request.ToEnvironment)
+	case request.To != "":
+		query.Set("latest", "true")
+		query.Set("tag", request.To)
+	default:
+		query.Set("latest", "true")
+	}
+
+	endpoint := fmt.Sprintf("%s/matrix?%s", b.URL, query.Encode())
+
+	for attempt := 0; ; attempt++ {
+		response = types.MatrixResponse{}
+		err := b.call("GET", endpoint, nil, &response)
+		if err != nil {
+			return response, err
+		}
+
+		if response.Summary.Unknown == 0 || attempt >= request.RetryWhileUnknown {
+			return response, nil
+		}
+
+		log.Printf("[INFO] broker - %d verification results are unknown, retrying in %s (%d/%d)", response.Summary.Unknown, request.RetryInterval, attempt+1, request.RetryWhileUnknown)
+		time.Sleep(request.RetryInterval)
+	}
+}
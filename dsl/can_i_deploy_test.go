@@ -0,0 +1,154 @@
+package dsl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// Pretend to be a Broker for the matrix API. The first 'unknown' calls report
+// a missing verification result.
+func setupMockMatrix(unknown int) (*httptest.Server, *[]string) {
+	var queries []string
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/matrix" {
+			w.WriteHeader(404)
+			return
+		}
+		queries = append(queries, req.URL.RawQuery)
+		calls++
+
+		w.Header().Add("Content-Type", "application/hal+json")
+		if calls <= unknown {
+			fmt.Fprint(w, `{"summary":{"deployable":null,"reason":"Missing one or more verification results","success":0,"failed":0,"unknown":1},"matrix":[{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":null}]}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"summary":{"deployable":true,"reason":"All required verification results are published and successful","success":1,"failed":0,"unknown":0},"matrix":[{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":{"success":true,"verifiedAt":"2018-06-01T00:00:00+00:00","_links":{"self":{"href":"http://broker/verification-results/1"}}}}]}`)
+	}))
+
+	return server, &queries
+}
+
+func TestBroker_CanIDeploy(t *testing.T) {
+	s, queries := setupMockMatrix(0)
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	res, err := broker.CanIDeploy(types.CanIDeployRequest{
+		Pacticipant: "billy",
+		Version:     "1.0.0",
+		To:          "prod",
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !res.Summary.Deployable {
+		t.Fatalf("Expected billy to be deployable")
+	}
+
+	if len(res.Matrix) != 1 || res.Matrix[0].VerificationResult == nil || !res.Matrix[0].VerificationResult.Success {
+		t.Fatalf("Expected a successful matrix row but got %+v", res.Matrix)
+	}
+
+	expected := "latest=true&latestby=cvp&q%5B%5D%5Bpacticipant%5D=billy&q%5B%5D%5Bversion%5D=1.0.0&tag=prod"
+	if (*queries)[0] != expected {
+		t.Fatalf("Expected query '%s' but got '%s'", expected, (*queries)[0])
+	}
+}
+
+func TestBroker_CanIDeployToEnvironment(t *testing.T) {
+	s, queries := setupMockMatrix(0)
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	_, err := broker.CanIDeploy(types.CanIDeployRequest{
+		Pacticipant:   "billy",
+		LatestTag:     "master",
+		ToEnvironment: "production",
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := "environment=production&latestby=cvp&q%5B%5D%5Blatest%5D=true&q%5B%5D%5Bpacticipant%5D=billy&q%5B%5D%5Btag%5D=master"
+	if (*queries)[0] != expected {
+		t.Fatalf("Expected query '%s' but got '%s'", expected, (*queries)[0])
+	}
+}
+
+func TestBroker_CanIDeployRetryWhileUnknown(t *testing.T) {
+	s, queries := setupMockMatrix(2)
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	res, err := broker.CanIDeploy(types.CanIDeployRequest{
+		Pacticipant:       "billy",
+		Latest:            true,
+		RetryWhileUnknown: 5,
+		RetryInterval:     time.Millisecond,
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !res.Summary.Deployable {
+		t.Fatalf("Expected billy to be deployable")
+	}
+
+	if len(*queries) != 3 {
+		t.Fatalf("Expected 3 calls to the matrix but got %d", len(*queries))
+	}
+}
+
+func TestBroker_CanIDeployRetriesExhausted(t *testing.T) {
+	s, queries := setupMockMatrix(10)
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	res, err := broker.CanIDeploy(types.CanIDeployRequest{
+		Pacticipant:       "billy",
+		Latest:            true,
+		RetryWhileUnknown: 2,
+		RetryInterval:     time.Millisecond,
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if res.Summary.Deployable || res.Summary.Unknown != 1 {
+		t.Fatalf("Expected an unknown result but got %+v", res.Summary)
+	}
+
+	if len(*queries) != 3 {
+		t.Fatalf("Expected 3 calls to the matrix but got %d", len(*queries))
+	}
+}
+
+func TestBroker_CanIDeployFail(t *testing.T) {
+	broker := &Broker{}
+	requests := []types.CanIDeployRequest{
+		types.CanIDeployRequest{},
+		types.CanIDeployRequest{Pacticipant: "billy"},
+		types.CanIDeployRequest{Pacticipant: "billy", Version: "1.0.0", Latest: true},
+		types.CanIDeployRequest{Pacticipant: "billy", Version: "1.0.0", To: "prod", ToEnvironment: "production"},
+		types.CanIDeployRequest{Pacticipant: "billy", Version: "1.0.0"},
+	}
+
+	for _, request := range requests {
+		if _, err := broker.CanIDeploy(request); err == nil {
+			t.Fatalf("Expected error for request %+v but got none", request)
+		}
+	}
+}
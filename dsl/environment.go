@@ -0,0 +1,195 @@
+package dsl
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// environmentsResponse is a subset of the HAL response from the environments
+// API of a Pact Broker.
+type environmentsResponse struct {
+	Embedded struct {
+		Environments []types.Environment `json:"environments"`
+	} `json:"_embedded"`
+}
+
+// ListEnvironments lists all of the Environments known to the Pact Broker.
+func (b *Broker) ListEnvironments() ([]types.Environment, error) {
+	log.Println("[DEBUG] broker - list environments")
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	var res environmentsResponse
+	err := b.call("GET", fmt.Sprintf("%s/environments", b.URL), nil, &res)
+
+	return res.Embedded.Environments, err
+}
+
+// CreateEnvironment creates a new Environment in the Pact Broker, returning
+// it with the UUID assigned by the Broker.
+func (b *Broker) CreateEnvironment(environment types.Environment) (types.Environment, error) {
+	log.Println("[DEBUG] broker - create environment:", environment.Name)
+	var created types.Environment
+
+	if err := b.validate(); err != nil {
+		return created, err
+	}
+
+	if environment.Name == "" {
+		return created, fmt.Errorf("Environment name is mandatory")
+	}
+
+	err := b.call("POST", fmt.Sprintf("%s/environments", b.URL), environment, &created)
+
+	return created, err
+}
+
+// findEnvironment finds an Environment by name.
+func (b *Broker) findEnvironment(name string) (types.Environment, error) {
+	environments, err := b.ListEnvironments()
+	if err != nil {
+		return types.Environment{}, err
+	}
+
+	for _, environment := range environments {
+		if environment.Name == name {
+			return environment, nil
+		}
+	}
+
+	return types.Environment{}, fmt.Errorf("environment '%s' does not exist in the Pact Broker", name)
+}
+
+// versionResponse is a subset of the HAL response for a pacticipant version.
+type versionResponse struct {
+	Links struct {
+		RecordDeployment []PactLink `json:"pb:record-deployment"`
+		RecordRelease    []PactLink `json:"pb:record-release"`
+	} `json:"_links"`
+}
+
+// RecordDeployment records that a pacticipant version has been deployed to an
+// Environment. Any version previously deployed to the same Environment (and
+// application instance) is marked as no longer deployed by the Pact Broker.
+func (b *Broker) RecordDeployment(request types.DeploymentRequest) error {
+	log.Println("[DEBUG] broker - record deployment:", request.Pacticipant, request.Version, request.Environment)
+
+	link, err := b.versionEnvironmentLink(request, func(v versionResponse) []PactLink {
+		return v.Links.RecordDeployment
+	})
+	if err != nil {
+		return err
+	}
+
+	body := map[string]string{}
+	if request.ApplicationInstance != "" {
+		body["applicationInstance"] = request.ApplicationInstance
+	}
+
+	return b.call("POST", link, body, nil)
+}
+
+// RecordRelease records that a pacticipant version has been released to an
+// Environment, e.g. a mobile app published to an app store. Unlike
+// deployments, several versions may be released at the same time.
+func (b *Broker) RecordRelease(request types.DeploymentRequest) error {
+	log.Println("[DEBUG] broker - record release:", request.Pacticipant, request.Version, request.Environment)
+
+	link, err := b.versionEnvironmentLink(request, func(v versionResponse) []PactLink {
+		return v.Links.RecordRelease
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.call("POST", link, map[string]string{}, nil)
+}
+
+// versionEnvironmentLink finds the link for the requested Environment on a
+// pacticipant version resource.
+func (b *Broker) versionEnvironmentLink(request types.DeploymentRequest, links func(versionResponse) []PactLink) (string, error) {
+	if err := b.validate(); err != nil {
+		return "", err
+	}
+
+	if err := request.Validate(true); err != nil {
+		return "", err
+	}
+
+	var version versionResponse
+	err := b.call("GET", fmt.Sprintf("%s/pacticipants/%s/versions/%s", b.URL, url.PathEscape(request.Pacticipant), url.PathEscape(request.Version)), nil, &version)
+	if err != nil {
+		return "", err
+	}
+
+	for _, link := range links(version) {
+		if link.Name == request.Environment {
+			return link.Href, nil
+		}
+	}
+
+	return "", fmt.Errorf("environment '%s' does not exist in the Pact Broker", request.Environment)
+}
+
+// deployedVersionsResponse is a subset of the HAL response for the currently
+// deployed versions in an Environment.
+type deployedVersionsResponse struct {
+	Embedded struct {
+		DeployedVersions []struct {
+			ApplicationInstance string `json:"applicationInstance"`
+			Links               struct {
+				Self PactLink `json:"self"`
+			} `json:"_links"`
+		} `json:"deployedVersions"`
+	} `json:"_embedded"`
+}
+
+// RecordUndeployment records that a pacticipant is no longer deployed to an
+// Environment. If an ApplicationInstance is given, only that instance is
+// undeployed. The Version of the request is not required.
+func (b *Broker) RecordUndeployment(request types.DeploymentRequest) error {
+	log.Println("[DEBUG] broker - record undeployment:", request.Pacticipant, request.Environment)
+
+	if err := b.validate(); err != nil {
+		return err
+	}
+
+	if err := request.Validate(false); err != nil {
+		return err
+	}
+
+	environment, err := b.findEnvironment(request.Environment)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("pacticipant", request.Pacticipant)
+	if request.ApplicationInstance != "" {
+		query.Set("applicationInstance", request.ApplicationInstance)
+	}
+
+	var deployed deployedVersionsResponse
+	err = b.call("GET", fmt.Sprintf("%s/environments/%s/deployed-versions/currently-deployed?%s", b.URL, environment.UUID, query.Encode()), nil, &deployed)
+	if err != nil {
+		return err
+	}
+
+	if len(deployed.Embedded.DeployedVersions) == 0 {
+		return fmt.Errorf("%s is not currently deployed to environment '%s'", request.Pacticipant, request.Environment)
+	}
+
+	for _, version := range deployed.Embedded.DeployedVersions {
+		err = b.call("PATCH", version.Links.Self.Href, map[string]bool{"currentlyDeployed": false}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// Pretend to be a Broker for the environments and deployment APIs, recording
+// each modifying request that is made.
+func setupMockEnvironmentBroker() (*httptest.Server, *[]string) {
+	var calls []string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	record := func(req *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		calls = append(calls, fmt.Sprintf("%s %s %v", req.Method, req.URL.Path, body))
+	}
+
+	mux.HandleFunc("/environments", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Content-Type", "application/hal+json")
+		if req.Method == "POST" {
+			var env types.Environment
+			json.NewDecoder(req.Body).Decode(&env)
+			env.UUID = "1234"
+			w.WriteHeader(201)
+			json.NewEncoder(w).Encode(env)
+			return
+		}
+		fmt.Fprint(w, `{"_embedded":{"environments":[{"uuid":"1234","name":"production","displayName":"Production","production":true},{"uuid":"5678","name":"test","production":false}]}}`)
+	})
+
+	mux.HandleFunc("/pacticipants/billy/versions/1.0.0", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Content-Type", "application/hal+json")
+		fmt.Fprintf(w, `{"number":"1.0.0","_links":{"pb:record-deployment":[{"name":"production","href":"%s/pacticipants/billy/versions/1.0.0/deployed-versions/environment/1234"}],"pb:record-release":[{"name":"production","href":"%s/pacticipants/billy/versions/1.0.0/released-versions/environment/1234"}]}}`, server.URL, server.URL)
+	})
+
+	mux.HandleFunc("/pacticipants/billy/versions/1.0.0/deployed-versions/environment/1234", func(w http.ResponseWriter, req *http.Request) {
+		record(req)
+		w.WriteHeader(201)
+	})
+
+	mux.HandleFunc("/pacticipants/billy/versions/1.0.0/released-versions/environment/1234", func(w http.ResponseWriter, req *http.Request) {
+		record(req)
+		w.WriteHeader(201)
+	})
+
+	mux.HandleFunc("/environments/1234/deployed-versions/currently-deployed", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Content-Type", "application/hal+json")
+		if req.URL.Query().Get("pacticipant") != "billy" {
+			fmt.Fprint(w, `{"_embedded":{"deployedVersions":[]}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"_embedded":{"deployedVersions":[{"applicationInstance":null,"_links":{"self":{"href":"%s/deployed-versions/abcd"}}}]}}`, server.URL)
+	})
+
+	mux.HandleFunc("/deployed-versions/abcd", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Content-Type") != "application/merge-patch+json" {
+			w.WriteHeader(415)
+			return
+		}
+		record(req)
+	})
+
+	return server, &calls
+}
+
+func TestBroker_ListEnvironments(t *testing.T) {
+	s, _ := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	environments, err := broker.ListEnvironments()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(environments) != 2 || environments[0].Name != "production" || !environments[0].Production {
+		t.Fatalf("Expected 2 environments but got %+v", environments)
+	}
+}
+
+func TestBroker_CreateEnvironment(t *testing.T) {
+	s, _ := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	environment, err := broker.CreateEnvironment(types.Environment{
+		Name:       "production",
+		Production: true,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if environment.UUID != "1234" {
+		t.Fatalf("Expected the environment to have a UUID but got %+v", environment)
+	}
+
+	if _, err = broker.CreateEnvironment(types.Environment{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestBroker_RecordDeployment(t *testing.T) {
+	s, calls := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	err := broker.RecordDeployment(types.DeploymentRequest{
+		Pacticipant:         "billy",
+		Version:             "1.0.0",
+		Environment:         "production",
+		ApplicationInstance: "customer-1",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := "POST /pacticipants/billy/versions/1.0.0/deployed-versions/environment/1234 map[applicationInstance:customer-1]"
+	if len(*calls) != 1 || (*calls)[0] != expected {
+		t.Fatalf("Expected call '%s' but got %v", expected, *calls)
+	}
+}
+
+func TestBroker_RecordRelease(t *testing.T) {
+	s, calls := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	err := broker.RecordRelease(types.DeploymentRequest{
+		Pacticipant: "billy",
+		Version:     "1.0.0",
+		Environment: "production",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := "POST /pacticipants/billy/versions/1.0.0/released-versions/environment/1234 map[]"
+	if len(*calls) != 1 || (*calls)[0] != expected {
+		t.Fatalf("Expected call '%s' but got %v", expected, *calls)
+	}
+}
+
+func TestBroker_RecordDeploymentFail(t *testing.T) {
+	s, _ := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	requests := []types.DeploymentRequest{
+		types.DeploymentRequest{Version: "1.0.0", Environment: "production"},
+		types.DeploymentRequest{Pacticipant: "billy", Environment: "production"},
+		types.DeploymentRequest{Pacticipant: "billy", Version: "1.0.0"},
+		types.DeploymentRequest{Pacticipant: "billy", Version: "1.0.0", Environment: "idontexist"},
+		types.DeploymentRequest{Pacticipant: "billy", Version: "2.0.0", Environment: "production"},
+	}
+
+	for _, request := range requests {
+		if err := broker.RecordDeployment(request); err == nil {
+			t.Fatalf("Expected error for request %+v but got none", request)
+		}
+	}
+
+	if err := (&Broker{}).RecordDeployment(types.DeploymentRequest{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestBroker_RecordUndeployment(t *testing.T) {
+	s, calls := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	err := broker.RecordUndeployment(types.DeploymentRequest{
+		Pacticipant: "billy",
+		Environment: "production",
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := "PATCH /deployed-versions/abcd map[currentlyDeployed:false]"
+	if len(*calls) != 1 || (*calls)[0] != expected {
+		t.Fatalf("Expected call '%s' but got %v", expected, *calls)
+	}
+}
+
+func TestBroker_RecordUndeploymentFail(t *testing.T) {
+	s, _ := setupMockEnvironmentBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	requests := []types.DeploymentRequest{
+		types.DeploymentRequest{Environment: "production"},
+		types.DeploymentRequest{Pacticipant: "billy", Environment: "idontexist"},
+		types.DeploymentRequest{Pacticipant: "jessica", Environment: "production"},
+	}
+
+	for _, request := range requests {
+		if err := broker.RecordUndeployment(request); err == nil {
+			t.Fatalf("Expected error for request %+v but got none", request)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// FormBody is an application/x-www-form-urlencoded body, whose fields are
+// either Strings or Matchers such as Like or Term. Use Form to create one.
+//
+// Pact.Verify matches requests with a FormBody field by field, with its
+// Matchers, in front of the mock service, which is sent its example, e.g.
+// "grant_type=password&username=billy". Pact.WritePact, and adding it to a
+// PactFile, write its Matchers as matching rules for each field, e.g.
+// "$.body.username".
+type FormBody struct {
+	fields MapMatcher
+}
+
+// Form creates an application/x-www-form-urlencoded body.
+func Form(fields MapMatcher) *FormBody {
+	return &FormBody{fields: fields}
+}
+
+// String returns the example body.
+func (f *FormBody) String() string {
+	values := url.Values{}
+	for name, value := range f.fields {
+		example, _ := matcherExample(value)
+		values.Set(name, example)
+	}
+	return values.Encode()
+}
+
+// ContentType returns the media type of the body, for its Content-Type
+// header.
+func (f *FormBody) ContentType() string {
+	return "application/x-www-form-urlencoded"
+}
+
+// MarshalJSON writes the example body as a JSON string.
+func (f *FormBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+// bodyRules adds the matching rules of the fields at path.
+func (f *FormBody) bodyRules(path []string, rules map[string]interface{}) {
+	for name, value := range f.fields {
+		if _, rule := matcherExample(value); rule != nil {
+			rules[formatPath(child(path, name))] = rule
+		}
+	}
+}
+
+// validateBody checks the Matchers of the fields, like validateMatchers.
+func (f *FormBody) validateBody(path []string) []string {
+	var problems []string
+	for name, value := range f.fields {
+		problems = append(problems, validateMatchers(child(path, name), value)...)
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// MultipartBody is a multipart/form-data body of fields and files. Use
+// Multipart to create one, e.g.
+//
+//	body := dsl.Multipart().
+//		Field("description", dsl.Like("My holiday")).
+//		File("photo", dsl.Like("photo.jpg"), dsl.Term("image/jpeg", `^image/`), photo).
+//		FileSize("photo", 1, 10*1024*1024)
+//
+// Pact.Verify matches requests with a MultipartBody part by part, with its
+// Matchers and any boundary, in front of the mock service, which is sent its
+// example. Pact.WritePact, and adding it to a PactFile, write its Matchers as
+// matching rules, e.g. "$.body.description" for a field, and
+// "$.body.photo.contentType" and "$.body.photo.size" for a file, that the
+// StubServer understands.
+type MultipartBody struct {
+	boundary string
+	parts    []multipartPart
+}
+
+// multipartPart is a field, or file if filename is set, of a MultipartBody.
+type multipartPart struct {
+	name        string
+	value       StringMatcher
+	filename    StringMatcher
+	contentType StringMatcher
+	content     []byte
+	minSize     int
+	maxSize     int
+}
+
+// DefaultMultipartBoundary is the boundary of the example of a
+// MultipartBody, unless another is set. Requests may use any boundary.
+const DefaultMultipartBoundary = "pact-go-boundary"
+
+// Multipart creates a multipart/form-data body.
+func Multipart() *MultipartBody {
+	return &MultipartBody{boundary: DefaultMultipartBoundary}
+}
+
+// Boundary sets the boundary separating the parts of the body.
+func (m *MultipartBody) Boundary(boundary string) *MultipartBody {
+	m.boundary = boundary
+	return m
+}
+
+// Field adds a form field, which is either a String or a Matcher such as
+// Like or Term.
+func (m *MultipartBody) Field(name string, value StringMatcher) *MultipartBody {
+	m.parts = append(m.parts, multipartPart{name: name, value: value})
+	return m
+}
+
+// File adds a file. Its filename and content type are either Strings or
+// Matchers such as Like or Term. Unless FileSize is used, the content must
+// match exactly.
+func (m *MultipartBody) File(name string, filename StringMatcher, contentType StringMatcher, content []byte) *MultipartBody {
+	m.parts = append(m.parts, multipartPart{name: name, filename: filename, contentType: contentType, content: content})
+	return m
+}
+
+// FileSize allows the content of the named file to differ from the example,
+// as long as its size in bytes is between min and max. A max of 0 means
+// there is no maximum.
+func (m *MultipartBody) FileSize(name string, min int, max int) *MultipartBody {
+	for i := range m.parts {
+		if m.parts[i].name == name && m.parts[i].filename != nil {
+			m.parts[i].minSize = min
+			m.parts[i].maxSize = max
+		}
+	}
+	return m
+}
+
+// ContentType returns the Content-Type header of the body, including its
+// boundary.
+func (m *MultipartBody) ContentType() string {
+	return mime.FormatMediaType("multipart/form-data", map[string]string{"boundary": m.boundary})
+}
+
+// String returns the example body.
+func (m *MultipartBody) String() string {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	if err := w.SetBoundary(m.boundary); err != nil {
+		return ""
+	}
+
+	for _, part := range m.parts {
+		if part.filename == nil {
+			example, _ := matcherExample(part.value)
+			w.WriteField(part.name, example)
+			continue
+		}
+
+		filename, _ := matcherExample(part.filename)
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": part.name, "filename": filename}))
+		if part.contentType != nil {
+			contentType, _ := matcherExample(part.contentType)
+			header.Set("Content-Type", contentType)
+		}
+		if pw, err := w.CreatePart(header); err == nil {
+			pw.Write(part.content)
+		}
+	}
+	w.Close()
+
+	return b.String()
+}
+
+// MarshalJSON writes the example body as a JSON string.
+func (m *MultipartBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// bodyRules adds the matching rules of the parts at path.
+func (m *MultipartBody) bodyRules(path []string, rules map[string]interface{}) {
+	for _, part := range m.parts {
+		if part.filename == nil {
+			if _, rule := matcherExample(part.value); rule != nil {
+				rules[formatPath(child(path, part.name))] = rule
+			}
+			continue
+		}
+
+		if _, rule := matcherExample(part.filename); rule != nil {
+			rules[formatPath(append(child(path, part.name), "filename"))] = rule
+		}
+		if part.contentType != nil {
+			if _, rule := matcherExample(part.contentType); rule != nil {
+				rules[formatPath(append(child(path, part.name), "contentType"))] = rule
+			}
+		}
+		if part.minSize > 0 || part.maxSize > 0 {
+			rule := map[string]interface{}{"match": "type", "min": part.minSize}
+			if part.maxSize > 0 {
+				rule["max"] = part.maxSize
+			}
+			rules[formatPath(append(child(path, part.name), "size"))] = rule
+		}
+	}
+}
+
+// validateBody checks the Matchers of the parts, like validateMatchers.
+func (m *MultipartBody) validateBody(path []string) []string {
+	var problems []string
+	for _, part := range m.parts {
+		if part.filename == nil {
+			problems = append(problems, validateMatchers(child(path, part.name), part.value)...)
+			continue
+		}
+		problems = append(problems, validateMatchers(append(child(path, part.name), "filename"), part.filename)...)
+		if part.contentType != nil {
+			problems = append(problems, validateMatchers(append(child(path, part.name), "contentType"), part.contentType)...)
+		}
+		if part.minSize < 0 || (part.maxSize > 0 && part.maxSize < part.minSize) {
+			problems = append(problems, fmt.Sprintf("%s: invalid file size bounds %d to %d", formatPath(append(child(path, part.name), "size")), part.minSize, part.maxSize))
+		}
+	}
+	return problems
+}
+
+// isFormContentType reports whether the content type is
+// application/x-www-form-urlencoded.
+func isFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// formPart is a parsed part of a multipart/form-data body.
+type formPart struct {
+	filename    string
+	contentType string
+	content     []byte
+}
+
+// parseMultipart parses a multipart/form-data body. If the boundary is
+// empty, it is taken from the first line of the body.
+func parseMultipart(body []byte, boundary string) (map[string]formPart, error) {
+	if boundary == "" {
+		line := string(body)
+		if i := strings.Index(line, "\n"); i >= 0 {
+			line = line[:i]
+		}
+		boundary = strings.TrimPrefix(strings.TrimSpace(line), "--")
+	}
+
+	parts := map[string]formPart{}
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		parts[p.FormName()] = formPart{
+			filename:    p.FileName(),
+			contentType: p.Header.Get("Content-Type"),
+			content:     content,
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no parts found")
+	}
+	return parts, nil
+}
+
+// compareMultipart compares multipart/form-data bodies. Fields are compared
+// as values; files by their filename, content type, and content, or by the
+// size of the content if it has a matching rule at "$.body.<name>.size".
+func (c *comparison) compareMultipart(path []string, expected map[string]formPart, actual map[string]formPart) {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e := expected[name]
+		partPath := child(path, name)
+		a, ok := actual[name]
+		if !ok {
+			c.mismatch(partPath, "expected the part %q but it was missing", name)
+			continue
+		}
+
+		if e.filename == "" {
+			c.compare(partPath, string(e.content), string(a.content))
+			continue
+		}
+
+		c.compare(child(partPath, "filename"), e.filename, a.filename)
+		c.compare(child(partPath, "contentType"), e.contentType, a.contentType)
+
+		sizePath := child(partPath, "size")
+		if rules := c.rules.resolve(sizePath); rules != nil && len(rules.path) == len(sizePath) {
+			min, max := rules.bounds()
+			if min != nil && len(a.content) < *min {
+				c.mismatch(sizePath, "expected a file of at least %d bytes but received %d", *min, len(a.content))
+			}
+			if max != nil && len(a.content) > *max {
+				c.mismatch(sizePath, "expected a file of at most %d bytes but received %d", *max, len(a.content))
+			}
+			continue
+		}
+		if !bytes.Equal(e.content, a.content) {
+			c.mismatch(child(partPath, "content"), "expected the %d bytes of the example but received %d different bytes", len(e.content), len(a.content))
+		}
+	}
+
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			c.mismatch(child(path, name), "unexpected part %q", name)
+		}
+	}
+}
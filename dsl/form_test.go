@@ -0,0 +1,174 @@
+package dsl
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestForm_String(t *testing.T) {
+	body := Form(MapMatcher{
+		"grant_type": String("password"),
+		"username":   Like("billy"),
+		"scope":      Term("read write", `^[a-z ]+$`),
+	})
+
+	if body.String() != "grant_type=password&scope=read+write&username=billy" {
+		t.Fatalf("Unexpected example body: %s", body.String())
+	}
+}
+
+func TestForm_matchRequest(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request for a token",
+		Request: Request{
+			Method:  "POST",
+			Path:    String("/oauth/token"),
+			Headers: MapMatcher{"Content-Type": String("application/x-www-form-urlencoded")},
+			Body: Form(MapMatcher{
+				"grant_type": String("password"),
+				"username":   Like("billy"),
+			}),
+		},
+		Response: Response{Status: 200},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := pact.Interactions[0].Request
+
+	cases := []struct {
+		body  string
+		paths string
+	}{
+		{"username=bob&grant_type=password", ""},
+		{"grant_type=client_credentials&client_id=1", "$.body.grant_type,$.body.username,$.body.client_id"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		var paths []string
+		for _, m := range matchRequest(expected, req, []byte(c.body)) {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != c.paths {
+			t.Fatalf("Expected mismatches at '%s' for %s but got %v", c.paths, c.body, paths)
+		}
+	}
+}
+
+func photoUpload() *MultipartBody {
+	return Multipart().
+		Field("description", Like("My holiday")).
+		File("photo", Like("photo.jpg"), Term("image/jpeg", `^image/`), []byte("a photo")).
+		FileSize("photo", 1, 16).
+		File("terms", String("terms.txt"), String("text/plain"), []byte("I agree"))
+}
+
+func TestMultipart_String(t *testing.T) {
+	parts, err := parseMultipart([]byte(photoUpload().String()), "")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if string(parts["description"].content) != "My holiday" || parts["description"].filename != "" {
+		t.Fatalf("Unexpected field: %+v", parts["description"])
+	}
+	photo := parts["photo"]
+	if photo.filename != "photo.jpg" || photo.contentType != "image/jpeg" || string(photo.content) != "a photo" {
+		t.Fatalf("Unexpected file: %+v", photo)
+	}
+	if photoUpload().ContentType() != "multipart/form-data; boundary=pact-go-boundary" {
+		t.Fatalf("Unexpected content type: %s", photoUpload().ContentType())
+	}
+}
+
+func TestMultipart_matchRequest(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request to upload a photo",
+		Request: Request{
+			Method: "POST",
+			Path:   String("/photos"),
+			Body:   photoUpload(),
+		},
+		Response: Response{Status: 201},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := pact.Interactions[0].Request
+	if _, ok := expected.MatchingRules["$.body.photo.size"]; !ok {
+		t.Fatalf("Expected a matching rule for the file size but got %v", expected.MatchingRules)
+	}
+
+	type file struct {
+		name, filename, contentType, content string
+	}
+	request := func(description string, files ...file) (string, string) {
+		var b bytes.Buffer
+		w := multipart.NewWriter(&b)
+		w.WriteField("description", description)
+		for _, f := range files {
+			h := make(map[string][]string)
+			h["Content-Disposition"] = []string{`form-data; name="` + f.name + `"; filename="` + f.filename + `"`}
+			h["Content-Type"] = []string{f.contentType}
+			pw, _ := w.CreatePart(h)
+			pw.Write([]byte(f.content))
+		}
+		w.Close()
+		return b.String(), w.FormDataContentType()
+	}
+
+	cases := []struct {
+		files []file
+		paths string
+	}{
+		{[]file{{"photo", "beach.png", "image/png", "another photo"}, {"terms", "terms.txt", "text/plain", "I agree"}}, ""},
+		{[]file{{"photo", "beach.png", "text/plain", "a photo that is too large"}, {"terms", "terms.txt", "text/plain", "I disagree"}},
+			"$.body.photo.contentType,$.body.photo.size,$.body.terms.content"},
+		{[]file{{"photo", "beach.png", "image/png", "a photo"}}, "$.body.terms"},
+	}
+
+	for _, c := range cases {
+		body, contentType := request("Beach", c.files...)
+		req := httptest.NewRequest("POST", "/photos", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+
+		var paths []string
+		for _, m := range matchRequest(expected, req, []byte(body)) {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != c.paths {
+			t.Fatalf("Expected mismatches at '%s' but got %v", c.paths, paths)
+		}
+	}
+}
+
+func TestMultipart_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		UponReceiving("a request to upload a photo").
+		WithRequest(Request{
+			Method: "POST",
+			Path:   String("/photos"),
+			Body: Multipart().
+				Field("id", Term("abc", `^\d+$`)).
+				File("photo", String("photo.jpg"), String("image/jpeg"), nil).
+				FileSize("photo", 10, 1),
+		}).
+		WillRespondWith(Response{Status: 201})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 2 || !strings.HasPrefix(problems[0], "$.request.body.id: ") || !strings.HasPrefix(problems[1], "$.request.body.photo.size: ") {
+		t.Fatalf("Expected the field and file size to be invalid but got %v", problems)
+	}
+}
@@ -0,0 +1,181 @@
+package dsl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// generator is a v3 generator, which replaces an example value in a Pact
+// file with a generated one.
+type generator struct {
+	Type   string `json:"type"`
+	Min    *int   `json:"min"`
+	Max    *int   `json:"max"`
+	Digits int    `json:"digits"`
+	Size   int    `json:"size"`
+	Format string `json:"format"`
+}
+
+// generate creates a value, or returns the example if the generator is
+// not supported.
+func (g generator) generate(example interface{}) interface{} {
+	switch g.Type {
+	case "RandomInt":
+		min, max := 0, 2147483647
+		if g.Min != nil {
+			min = *g.Min
+		}
+		if g.Max != nil {
+			max = *g.Max
+		}
+		if max <= min {
+			return min
+		}
+		return min + rand.Intn(max-min+1)
+	case "RandomDecimal":
+		digits := g.Digits
+		if digits < 2 {
+			digits = 6
+		}
+		whole := digits / 2
+		return fmt.Sprintf("%d.%0*d", rand.Intn(pow10(whole)), digits-whole, rand.Intn(pow10(digits-whole)))
+	case "RandomHexadecimal":
+		digits := g.Digits
+		if digits == 0 {
+			digits = 8
+		}
+		b := make([]byte, (digits+1)/2)
+		rand.Read(b)
+		return hex.EncodeToString(b)[:digits]
+	case "RandomString":
+		size := g.Size
+		if size == 0 {
+			size = 20
+		}
+		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+		b := make([]byte, size)
+		for i := range b {
+			b[i] = letters[rand.Intn(len(letters))]
+		}
+		return string(b)
+	case "RandomBoolean":
+		return rand.Intn(2) == 1
+	case "Uuid":
+		b := make([]byte, 16)
+		rand.Read(b)
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "Date":
+		return g.formatTime("2006-01-02")
+	case "Time":
+		return g.formatTime("15:04:05")
+	case "DateTime", "Timestamp":
+		return g.formatTime(time.RFC3339)
+	default:
+		log.Printf("[DEBUG] generator %q is not supported, using the example value", g.Type)
+		return example
+	}
+}
+
+// formatTime formats the current time with the generator's format, or the
+// default layout.
+func (g generator) formatTime(layout string) string {
+	if g.Format != "" {
+		layout = javaDateLayout(g.Format)
+	}
+	return time.Now().Format(layout)
+}
+
+// pow10 returns 10 to the power of n.
+func pow10(n int) int {
+	result := 1
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// parseGenerators parses the generators of a category (e.g. "body" or
+// "header") of a v3 Pact file, keyed by path.
+func parseGenerators(raw map[string]interface{}, category string) map[string]generator {
+	generators := make(map[string]generator)
+
+	values, ok := raw[category].(map[string]interface{})
+	if !ok {
+		return generators
+	}
+
+	for path, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		var g generator
+		if err = json.Unmarshal(data, &g); err != nil {
+			log.Printf("[WARN] ignoring invalid generator for %s: %v", path, err)
+			continue
+		}
+		generators[path] = g
+	}
+
+	return generators
+}
+
+// applyBodyGenerators replaces the values in a body with generated values.
+func applyBodyGenerators(body interface{}, raw map[string]interface{}) interface{} {
+	for path, g := range parseGenerators(raw, "body") {
+		body = applyGenerator(body, nil, parseRulePath(path), g)
+	}
+	return body
+}
+
+// applyGenerator walks the value, generating each value whose path matches
+// the generator's path.
+func applyGenerator(value interface{}, path []string, target []string, g generator) interface{} {
+	if len(path) == len(target) {
+		if weight(target, path) > 0 || len(target) == 0 {
+			return g.generate(value)
+		}
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = applyGenerator(child, append(append([]string{}, path...), k), target, g)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = applyGenerator(child, append(append([]string{}, path...), fmt.Sprintf("[%d]", i)), target, g)
+		}
+	}
+	return value
+}
+
+// applyHeaderGenerators replaces header values with generated values.
+func applyHeaderGenerators(headers map[string]string, raw map[string]interface{}) map[string]string {
+	generators := parseGenerators(raw, "header")
+	if len(generators) == 0 {
+		return headers
+	}
+
+	generated := make(map[string]string, len(headers))
+	for name, value := range headers {
+		generated[name] = value
+	}
+	for name, g := range generators {
+		for existing := range generated {
+			if strings.EqualFold(existing, name) {
+				name = existing
+			}
+		}
+		generated[name] = fmt.Sprintf("%v", g.generate(generated[name]))
+	}
+	return generated
+}
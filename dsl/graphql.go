@@ -0,0 +1,258 @@
+package dsl
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// GraphQLInteraction builds an Interaction for a GraphQL request, POSTed as
+// JSON to "/graphql" unless another path is given. The query is matched by a
+// Term that ignores insignificant whitespace, commas and comments, so a
+// consumer can format its query as it likes. The response uses the usual
+// body Matchers, e.g.
+//
+//	pact.AddGraphQLInteraction().
+//		Given("User billy exists").
+//		UponReceiving("A query for a user").
+//		WithQuery(`query User($id: ID!) { user(id: $id) { name } }`).
+//		WithOperation("User").
+//		WithVariables(map[string]interface{}{"id": dsl.Like("1")}).
+//		WillRespondWith(dsl.Response{
+//			Status: 200,
+//			Body:   map[string]interface{}{"data": map[string]interface{}{"user": map[string]interface{}{"name": dsl.Like("billy")}}},
+//		})
+type GraphQLInteraction struct {
+	interaction *Interaction
+
+	path      StringMatcher
+	headers   MapMatcher
+	query     string
+	operation string
+	variables map[string]interface{}
+}
+
+// NewGraphQLInteraction creates a GraphQL builder for an Interaction.
+func NewGraphQLInteraction(i *Interaction) *GraphQLInteraction {
+	g := &GraphQLInteraction{
+		interaction: i,
+		path:        String("/graphql"),
+	}
+	g.buildRequest()
+
+	return g
+}
+
+// AddGraphQLInteraction creates a new GraphQL Interaction.
+func (p *Pact) AddGraphQLInteraction() *GraphQLInteraction {
+	return NewGraphQLInteraction(p.AddInteraction())
+}
+
+// Interaction returns the Interaction being built. Its request is set by the
+// GraphQL builder, and should not be replaced with WithRequest.
+func (g *GraphQLInteraction) Interaction() *Interaction {
+	return g.interaction
+}
+
+// Given specifies a provider state. Optional.
+func (g *GraphQLInteraction) Given(state string) *GraphQLInteraction {
+	g.interaction.Given(state)
+
+	return g
+}
+
+// UponReceiving specifies the name of the test case. Mandatory.
+func (g *GraphQLInteraction) UponReceiving(description string) *GraphQLInteraction {
+	g.interaction.UponReceiving(description)
+
+	return g
+}
+
+// WithPath specifies the path of the GraphQL endpoint. Defaults to
+// "/graphql".
+func (g *GraphQLInteraction) WithPath(path StringMatcher) *GraphQLInteraction {
+	g.path = path
+	g.buildRequest()
+
+	return g
+}
+
+// WithHeaders specifies headers to send along with the Content-Type, such as
+// Authorization. A Content-Type header, in any case, replaces the default
+// "application/json". Optional.
+func (g *GraphQLInteraction) WithHeaders(headers MapMatcher) *GraphQLInteraction {
+	g.headers = headers
+	g.buildRequest()
+
+	return g
+}
+
+// WithQuery specifies the query or mutation document. Mandatory.
+func (g *GraphQLInteraction) WithQuery(query string) *GraphQLInteraction {
+	g.query = query
+	g.buildRequest()
+
+	return g
+}
+
+// WithOperation specifies the name of the operation to execute, for
+// documents containing more than one. Optional.
+func (g *GraphQLInteraction) WithOperation(operation string) *GraphQLInteraction {
+	g.operation = operation
+	g.buildRequest()
+
+	return g
+}
+
+// WithVariables specifies the variables of the query, which may contain
+// Matchers. Optional.
+func (g *GraphQLInteraction) WithVariables(variables map[string]interface{}) *GraphQLInteraction {
+	g.variables = variables
+	g.buildRequest()
+
+	return g
+}
+
+// WillRespondWith specifies the response to the query. Mandatory.
+func (g *GraphQLInteraction) WillRespondWith(response Response) *GraphQLInteraction {
+	g.interaction.WillRespondWith(response)
+
+	return g
+}
+
+// Validate checks the Matchers of the Interaction, as Interaction.Validate.
+func (g *GraphQLInteraction) Validate() error {
+	return g.interaction.Validate()
+}
+
+// buildRequest sets the request of the Interaction from the GraphQL details.
+func (g *GraphQLInteraction) buildRequest() {
+	headers := MapMatcher{}
+	contentType := false
+	for name, value := range g.headers {
+		headers[name] = value
+		contentType = contentType || strings.EqualFold(name, "Content-Type")
+	}
+	if !contentType {
+		headers["Content-Type"] = String("application/json")
+	}
+
+	body := map[string]interface{}{
+		"query": Term(strings.TrimSpace(g.query), GraphQLQueryRegex(g.query)),
+	}
+	if g.operation != "" {
+		body["operationName"] = g.operation
+	}
+	if g.variables != nil {
+		body["variables"] = g.variables
+	}
+
+	g.interaction.WithRequest(Request{
+		Method:  "POST",
+		Path:    g.path,
+		Headers: headers,
+		Body:    body,
+	})
+}
+
+// GraphQLQueryRegex returns a regex matching the GraphQL query however it is
+// formatted: whitespace, commas and comments may be added or removed between
+// its tokens, but not within string values.
+func GraphQLQueryRegex(query string) string {
+	const ignored = `(?:[\s,]|#[^\n]*)`
+
+	var b bytes.Buffer
+	b.WriteString(`\A` + ignored + `*`)
+	var previous string
+	for _, token := range graphQLTokens(query) {
+		if previous != "" {
+			if isGraphQLWord(previous) && isGraphQLWord(token) {
+				b.WriteString(ignored + `+`)
+			} else {
+				b.WriteString(ignored + `*`)
+			}
+		}
+		b.WriteString(regexp.QuoteMeta(token))
+		previous = token
+	}
+	b.WriteString(ignored + `*\z`)
+
+	return b.String()
+}
+
+// graphQLTokens splits a GraphQL document into its names, numbers,
+// punctuators and strings, dropping whitespace, commas and comments.
+func graphQLTokens(query string) []string {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' && runes[i] != '\r' {
+				i++
+			}
+		case r == '"':
+			end := graphQLStringEnd(runes, i)
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		case r == '.' && strings.HasPrefix(string(runes[i:]), "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case isGraphQLNameRune(r) || r == '-':
+			start, number := i, r == '-' || unicode.IsDigit(r)
+			i++
+			for i < len(runes) && (isGraphQLNameRune(runes[i]) || (number && strings.ContainsRune(".+-", runes[i]))) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// graphQLStringEnd returns the index after the string or block string
+// starting at i, or the end of the document if it is unterminated.
+func graphQLStringEnd(runes []rune, i int) int {
+	if strings.HasPrefix(string(runes[i:]), `"""`) {
+		for j := i + 3; j < len(runes); j++ {
+			if runes[j] == '\\' && strings.HasPrefix(string(runes[j:]), `\"""`) {
+				j += 3
+				continue
+			}
+			if strings.HasPrefix(string(runes[j:]), `"""`) {
+				return j + 3
+			}
+		}
+		return len(runes)
+	}
+
+	for j := i + 1; j < len(runes); j++ {
+		switch runes[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(runes)
+}
+
+// isGraphQLNameRune reports whether r can be part of a name or number.
+func isGraphQLNameRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// isGraphQLWord reports whether a token is a name or number, which must be
+// separated from an adjacent name or number.
+func isGraphQLWord(token string) bool {
+	r := []rune(token)[0]
+	return isGraphQLNameRune(r) || r == '-'
+}
@@ -0,0 +1,121 @@
+package dsl
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const userQuery = `query User($id: ID!, $avatar: Boolean = false) {
+  # the user's profile
+  user(id: $id) {
+    name
+    ...Avatar @include(if: $avatar)
+    posts(first: -10, tag: "a, b  c") { title }
+  }
+}`
+
+func TestGraphQL_QueryRegex(t *testing.T) {
+	re := regexp.MustCompile(GraphQLQueryRegex(userQuery))
+
+	cases := []struct {
+		query   string
+		matches bool
+	}{
+		{userQuery, true},
+		{`query User($id:ID!$avatar:Boolean=false){user(id:$id){name ...Avatar@include(if:$avatar) posts(first:-10 tag:"a, b  c"){title}}}`, true},
+		{"\n  query  User ( $id : ID! , $avatar : Boolean = false ) {\n\tuser ( id : $id ) { name, ... Avatar @include ( if : $avatar ) posts ( first : -10 , tag : \"a, b  c\" ) { title } } }  # done\n", true},
+		{`query User($id: ID!, $avatar: Boolean = false) { user(id: $id) { name ...Avatar @include(if: $avatar) posts(first: -10, tag: "a, b c") { title } } }`, false},
+		{`query User($id: ID!, $avatar: Boolean = false) { user(id: $id) { firstname ...Avatar @include(if: $avatar) posts(first: -10, tag: "a, b  c") { title } } }`, false},
+		{`query User($id: ID!, $avatar: Boolean = false) { user(id: $id) { na me ...Avatar @include(if: $avatar) posts(first: -10, tag: "a, b  c") { title } } }`, false},
+		{`query User($id: ID!, $avatar: Boolean = false) { user(id: $id) { name ...Avatar @include(if: $avatar) posts(first: -10, tag: "a, b  c") { title } } } extra`, false},
+	}
+
+	for _, c := range cases {
+		if re.MatchString(c.query) != c.matches {
+			t.Fatalf("Expected the regex %s matching %q to be %v", re, c.query, c.matches)
+		}
+	}
+}
+
+func TestGraphQL_Interaction(t *testing.T) {
+	i := NewGraphQLInteraction(&Interaction{}).
+		Given("User billy exists").
+		UponReceiving("A query for a user").
+		WithHeaders(MapMatcher{"Authorization": Like("Bearer 1234")}).
+		WithQuery(userQuery).
+		WithOperation("User").
+		WithVariables(map[string]interface{}{"id": Like("1")}).
+		WillRespondWith(Response{
+			Status: 200,
+			Body: map[string]interface{}{
+				"data": map[string]interface{}{"user": map[string]interface{}{"name": Like("billy")}},
+			},
+		})
+
+	if err := i.Validate(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	interaction := i.Interaction()
+	if interaction.State != "User billy exists" || interaction.Request.Method != "POST" || interaction.Request.Path != String("/graphql") {
+		t.Fatalf("Unexpected interaction: %+v", interaction)
+	}
+	if interaction.Request.Headers["Content-Type"] != String("application/json") || interaction.Request.Headers["Authorization"] == nil {
+		t.Fatalf("Unexpected headers: %v", interaction.Request.Headers)
+	}
+
+	var pact PactFile
+	if err := pact.AddInteraction(*interaction); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := pact.Interactions[0].Request
+
+	cases := []struct {
+		body  map[string]interface{}
+		paths string
+	}{
+		{map[string]interface{}{
+			"query":         `query User($id:ID!,$avatar:Boolean=false){user(id:$id){name ...Avatar @include(if:$avatar) posts(first:-10,tag:"a, b  c"){title}}}`,
+			"operationName": "User",
+			"variables":     map[string]interface{}{"id": "42"},
+		}, ""},
+		{map[string]interface{}{
+			"query":         `query User($id: ID!) { user(id: $id) { name } }`,
+			"operationName": "Users",
+			"variables":     map[string]interface{}{"id": 42},
+		}, "$.body.operationName,$.body.query,$.body.variables.id"},
+	}
+
+	for _, c := range cases {
+		body, _ := json.Marshal(c.body)
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer 5678")
+
+		var paths []string
+		for _, m := range matchRequest(expected, req, body) {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != c.paths {
+			t.Fatalf("Expected mismatches at '%s' but got %v", c.paths, paths)
+		}
+	}
+}
+
+func TestGraphQL_ContentType(t *testing.T) {
+	i := NewGraphQLInteraction(&Interaction{}).
+		UponReceiving("A query with a charset").
+		WithHeaders(MapMatcher{"content-type": String("application/json; charset=utf-8")}).
+		WithQuery(userQuery).
+		WillRespondWith(Response{Status: 200})
+
+	if err := i.Validate(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	headers := i.Interaction().Request.Headers
+	if len(headers) != 1 || headers["content-type"] != String("application/json; charset=utf-8") {
+		t.Fatalf("Expected the Content-Type to be replaced but got %v", headers)
+	}
+}
@@ -0,0 +1,143 @@
+package dsl
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// mediaTypeToken matches a character of the name of a media type
+// parameter.
+const mediaTypeToken = `[^;=\s]`
+
+// MediaType matches a Content-Type header (or another header of a media
+// type, such as Accept for a single type) by its media type and parameters,
+// ignoring case, whitespace, the order of the parameters, and the charset
+// parameter. The header is the example, e.g.
+//
+//	"Content-Type": dsl.MediaType("application/json; charset=utf-8")
+//
+// matches "application/json", "Application/JSON;charset=UTF-8" and
+// "application/json; charset=iso-8859-1". Other parameters, such as the
+// boundary of a multipart body, must have the example's value when they are
+// sent, and parameters that are not in the example are ignored.
+func MediaType(contentType string) Matcher {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Term(contentType, `^`+regexp.QuoteMeta(contentType)+`$`)
+	}
+
+	var names, alternatives []string
+	for _, name := range sortedParamNames(params) {
+		if name == "charset" {
+			continue
+		}
+		names = append(names, name)
+		value := regexp.QuoteMeta(params[name])
+		alternatives = append(alternatives, regexp.QuoteMeta(name)+`\s*=\s*(?:`+value+`|"`+value+`")\s*`)
+	}
+	alternatives = append(alternatives, `(?:`+otherParamName(names, "")+`\s*(?:=[^;]*)?)?`)
+
+	regex := `(?i)^\s*` + regexp.QuoteMeta(mediaType) + `\s*(?:;\s*(?:` + strings.Join(alternatives, "|") + `))*$`
+	return Term(contentType, regex)
+}
+
+// HeaderValues matches a header of comma separated values, such as Accept
+// or Cache-Control, in order and ignoring the whitespace around the commas.
+// The values may be sent in a single header, or in repeated headers, e.g.
+//
+//	"Cache-Control": dsl.HeaderValues("no-cache", "no-store")
+//
+// matches "no-cache, no-store" and "no-cache,no-store".
+func HeaderValues(values ...string) Matcher {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(strings.TrimSpace(v))
+	}
+
+	return Term(strings.Join(values, ", "), `^\s*`+strings.Join(quoted, `\s*,\s*`)+`\s*$`)
+}
+
+// validateHeaderNames checks that no two headers have the same name,
+// ignoring case.
+func validateHeaderNames(path []string, headers MapMatcher) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	seen := map[string]string{}
+	for _, name := range names {
+		canonical := http.CanonicalHeaderKey(name)
+		if other, ok := seen[canonical]; ok {
+			problems = append(problems, fmt.Sprintf("%s: the headers %q and %q are the same, as header names are case insensitive", formatPath(path), other, name))
+			continue
+		}
+		seen[canonical] = name
+	}
+	return problems
+}
+
+// headerValues returns the values of a header, ignoring the case of its
+// name.
+func headerValues(headers http.Header, name string) ([]string, bool) {
+	var values []string
+	found := false
+	for _, key := range sortedStringKeys(headers) {
+		if strings.EqualFold(key, name) {
+			values = append(values, headers[key]...)
+			found = true
+		}
+	}
+	return values, found
+}
+
+// sortedParamNames returns the names of media type parameters in order.
+func sortedParamNames(params map[string]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// otherParamName returns a regex matching the rest of a parameter name
+// after the prefix, when the name is not one of the names. It follows the
+// names a character at a time, so it grows with their length.
+func otherParamName(names []string, prefix string) string {
+	var next []string
+	seen := map[string]bool{}
+	ended := false
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if len(name) == len(prefix) {
+			ended = true
+			continue
+		}
+		if c := name[len(prefix) : len(prefix)+1]; !seen[c] {
+			seen[c] = true
+			next = append(next, c)
+		}
+	}
+
+	var alternatives []string
+	if prefix != "" && !ended {
+		alternatives = append(alternatives, "")
+	}
+	others := `[^;=\s`
+	for _, c := range next {
+		others += strings.Replace(regexp.QuoteMeta(c), "-", `\-`, 1)
+		alternatives = append(alternatives, regexp.QuoteMeta(c)+otherParamName(names, prefix+c))
+	}
+	alternatives = append(alternatives, others+`]`+mediaTypeToken+`*`)
+
+	return `(?:` + strings.Join(alternatives, "|") + `)`
+}
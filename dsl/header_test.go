@@ -0,0 +1,188 @@
+package dsl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func termRegex(t *testing.T, m Matcher) (string, string) {
+	data := m["data"].(map[string]interface{})
+	example := data["generate"].(string)
+	regex := data["matcher"].(map[string]interface{})["s"].(string)
+	if err := CheckTerm(example, regex); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return example, regex
+}
+
+func TestHeader_MediaType(t *testing.T) {
+	cases := []struct {
+		contentType string
+		values      map[string]bool
+	}{
+		{"application/json; charset=utf-8", map[string]bool{
+			"application/json; charset=utf-8":      true,
+			"application/json":                     true,
+			"Application/JSON;charset=UTF-8":       true,
+			"application/json; charset=iso-8859-1": true,
+			" application/json ; charset=utf-8 ":   true,
+			"application/json-patch+json":          false,
+			"text/json; charset=utf-8":             false,
+			"application/jsonp":                    false,
+			"x-application/json":                   false,
+			"application/json; charset=utf-8; q=1": true,
+		}},
+		{`multipart/form-data; boundary=abc; charset=utf-8`, map[string]bool{
+			"multipart/form-data; boundary=abc":                true,
+			`multipart/form-data;charset=utf-8;boundary="abc"`: true,
+			"multipart/form-data; boundary=xyz":                false,
+			"multipart/form-data":                              true,
+			"multipart/form-data; Boundary=xyz; x=1":           false,
+			"multipart/form-data; boundary = xyz":              false,
+			"multipart/form-data; other=abc; boundary=abc":     true,
+			"multipart/form-data; boundaryx=1; boundary=abc":   true,
+			"multipart/form-data; x=1; boundary=abc; y=2":      true,
+		}},
+		{`text/plain; format=flowed; delsp=yes`, map[string]bool{
+			"text/plain; delsp=yes; format=flowed": true,
+			"text/plain; format=flowed; delsp=yes": true,
+			"text/plain; format=fixed; delsp=yes":  false,
+			"text/plain; delsp=no":                 false,
+			"text/plain; format=flowed":            true,
+		}},
+		{`application/vnd.api+json; ext-a=1; ext-b=2; ext=3; profile="a b"`, map[string]bool{
+			`application/vnd.api+json; profile="a b"; ext=3; ext-b=2; ext-a=1`: true,
+			`application/vnd.api+json; ext-c=4; ex=5; e=6`:                     true,
+			`application/vnd.api+json; ext-a=2`:                                false,
+			`application/vnd.api+json; EXT=4`:                                  false,
+		}},
+	}
+
+	for _, c := range cases {
+		example, regex := termRegex(t, MediaType(c.contentType))
+		if example != c.contentType {
+			t.Fatalf("Expected the example to be %s but got %s", c.contentType, example)
+		}
+		re, err := compileRegex(regex)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		for value, matches := range c.values {
+			if re.MatchString(value) != matches {
+				t.Fatalf("Expected %s matching %q to be %v, using %s", c.contentType, value, matches, regex)
+			}
+		}
+	}
+}
+
+func TestHeader_HeaderValues(t *testing.T) {
+	example, regex := termRegex(t, HeaderValues("no-cache", "max-age=0"))
+	if example != "no-cache, max-age=0" {
+		t.Fatalf("Unexpected example: %s", example)
+	}
+	re, _ := compileRegex(regex)
+	for value, matches := range map[string]bool{
+		"no-cache, max-age=0": true,
+		"no-cache,max-age=0":  true,
+		"max-age=0, no-cache": false,
+		"no-cache":            false,
+	} {
+		if re.MatchString(value) != matches {
+			t.Fatalf("Expected %q matching to be %v", value, matches)
+		}
+	}
+}
+
+func TestHeader_matchRequest(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request for a user",
+		Request: Request{
+			Method: "GET",
+			Path:   String("/users/1"),
+			Headers: MapMatcher{
+				"content-type":  MediaType("application/json; charset=utf-8"),
+				"Cache-Control": HeaderValues("no-cache", "no-store"),
+				"X-Request-Id":  String("1"),
+			},
+		},
+		Response: Response{Status: 200},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := pact.Interactions[0].Request
+
+	cases := []struct {
+		headers http.Header
+		paths   string
+	}{
+		{http.Header{
+			"Content-Type":  {"application/json"},
+			"Cache-Control": {"no-cache", "no-store"},
+			"X-Request-Id":  {"1"},
+			"X-Extra":       {"allowed"},
+		}, ""},
+		{http.Header{
+			"content-type":  {"Application/JSON;charset=UTF-8"},
+			"cache-control": {"no-cache,no-store"},
+			"x-request-id":  {"1"},
+		}, ""},
+		{http.Header{
+			"Content-Type":  {"text/plain"},
+			"Cache-Control": {"no-store"},
+		}, "$.headers.cache-control,$.headers.x-request-id,$.headers.content-type"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		req.Header = c.headers
+
+		var paths []string
+		for _, m := range matchRequest(expected, req, nil) {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != c.paths {
+			t.Fatalf("Expected mismatches at '%s' for %v but got %v", c.paths, c.headers, paths)
+		}
+	}
+}
+
+func TestHeader_extraHeaders(t *testing.T) {
+	s, cleanup := createStubServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name": "sally"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+	req.Header.Add("X-Forwarded-For", "10.0.0.2")
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected the headers that are not in the interaction to be accepted but got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHeader_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		UponReceiving("a request for a user").
+		WithRequest(Request{
+			Method:  "GET",
+			Path:    String("/users/1"),
+			Headers: MapMatcher{"Accept": String("application/json"), "accept": String("text/plain")},
+		}).
+		WillRespondWith(Response{Status: 200})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], `$.request.headers: the headers "Accept" and "accept" are the same`) {
+		t.Fatalf("Expected the duplicate header to be invalid but got %v", problems)
+	}
+}
@@ -37,9 +37,12 @@ func (i *Interaction) UponReceiving(description string) *Interaction {
 
 // WithRequest specifies the details of the HTTP request that will be used to
 // confirm that the Provider provides an API listening on the given interface.
-// Mandatory.
+// A []byte body is sent as text if it is valid UTF-8 text, or otherwise as a
+// Binary body. Invalid Matchers are logged as the request is added, ahead
+// of Verify failing. Mandatory.
 func (i *Interaction) WithRequest(request Request) *Interaction {
 	i.Request = request
+	i.Request.Body = binaryBody(request.Body)
 
 	// Check if someone tried to add an object as a string representation
 	// as per original allowed implementation, e.g.
@@ -49,18 +52,52 @@ func (i *Interaction) WithRequest(request Request) *Interaction {
 			"no structural matching will occur. Support for structured strings has been" +
 			"deprecated as of 0.13.0")
 	}
+	logInvalidMatchers(i.Description, i.requestProblems())
 
 	return i
 }
 
 // WillRespondWith specifies the details of the HTTP response that will be used to
-// confirm that the Provider must satisfy. Mandatory.
+// confirm that the Provider must satisfy. A []byte body, and invalid
+// Matchers, are handled as by WithRequest. Mandatory.
 func (i *Interaction) WillRespondWith(response Response) *Interaction {
 	i.Response = response
+	i.Response.Body = binaryBody(response.Body)
+	logInvalidMatchers(i.Description, i.responseProblems())
 
 	return i
 }
 
+// Validate checks the Matchers of the request and response: that Term
+// examples match their regex, and that EachLike requires at least one
+// element. It also checks that no two headers differ only in the case of
+// their names, and that the parameters of a PathTemplate are in its
+// template. It returns a *MatcherError listing every problem found. Terms
+// whose regex Go can't compile, such as the lookaheads of Timestamp and
+// Date, are logged as warnings, and listed in the MatcherError if there
+// are other problems.
+func (i *Interaction) Validate() error {
+	return newMatcherError(i.Description, append(i.requestProblems(), i.responseProblems()...))
+}
+
+// requestProblems returns the problems found in the Matchers of the request.
+func (i *Interaction) requestProblems() []string {
+	problems := validateMatchers([]string{"request"}, i.Request)
+	problems = append(problems, validateHeaderNames([]string{"request", "headers"}, i.Request.Headers)...)
+	if path, ok := i.Request.Path.(*PathMatcher); ok {
+		problems = append(problems, path.validate([]string{"request", "path"})...)
+	}
+	return append(problems, bodyProblems([]string{"request", "body"}, i.Request.Body)...)
+}
+
+// responseProblems returns the problems found in the Matchers of the
+// response.
+func (i *Interaction) responseProblems() []string {
+	problems := validateMatchers([]string{"response"}, i.Response)
+	problems = append(problems, validateHeaderNames([]string{"response", "headers"}, i.Response.Headers)...)
+	return append(problems, bodyProblems([]string{"response", "body"}, i.Response.Body)...)
+}
+
 // Checks to see if someone has tried to submit a JSON string
 // for an object, which is no longer supported
 func isJSONFormattedObject(stringOrObject interface{}) bool {
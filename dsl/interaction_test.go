@@ -1,8 +1,12 @@
 package dsl
 
 import (
+	"bytes"
 	"encoding/json"
+	"log"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -124,3 +128,69 @@ func TestInteraction_isStringLikeObject(t *testing.T) {
 		}
 	}
 }
+
+func TestInteraction_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		UponReceiving("a request for a user").
+		WithRequest(Request{
+			Method:  "GET",
+			Path:    Term("/users/abc", `/users/\d+`),
+			Query:   MapMatcher{"page": Term("1", `\d+`)},
+			Headers: MapMatcher{"Accept": Term("text/html", "application/json")},
+		}).
+		WillRespondWith(Response{
+			Status: 200,
+			Body:   EachLike(map[string]interface{}{"ip": IPv6Address()}, 0),
+		})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+
+	matcherErr, ok := err.(*MatcherError)
+	if !ok {
+		t.Fatalf("Expected a *MatcherError but got %T", err)
+	}
+	expected := []string{
+		"$.request.headers.Accept: ",
+		"$.request.path: ",
+		"$.response.body: EachLike must have a minimum of at least 1",
+	}
+	if len(matcherErr.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems but got %v", len(expected), matcherErr.Problems)
+	}
+	for j, problem := range matcherErr.Problems {
+		if !strings.HasPrefix(problem, expected[j]) {
+			t.Fatalf("Expected '%s' but got '%s'", expected[j], problem)
+		}
+	}
+	if !strings.Contains(err.Error(), "invalid matchers in 'a request for a user'") {
+		t.Fatalf("Expected the error to name the interaction but got '%s'", err.Error())
+	}
+
+	i.Request.Path = Term("/users/1", `/users/\d+`)
+	i.Request.Headers = MapMatcher{"Accept": String("application/json")}
+	i.Response.Body = EachLike(map[string]interface{}{"ip": IPv6Address()}, 1)
+	if err = i.Validate(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func TestInteraction_logsInvalidMatchers(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	(&Interaction{}).
+		UponReceiving("a request for a user").
+		WithRequest(Request{Method: "GET", Path: Term("/users/abc", `/users/\d+`)}).
+		WillRespondWith(Response{Status: 200, Body: map[string]interface{}{"created": Timestamp()}})
+
+	if !strings.Contains(out.String(), "[WARN] invalid matcher in 'a request for a user', which will fail verification: $.request.path: ") {
+		t.Fatalf("Expected the invalid path to be logged but got '%s'", out.String())
+	}
+	if strings.Contains(out.String(), "$.response.body.created") {
+		t.Fatalf("Expected warnings to be logged when verified but got '%s'", out.String())
+	}
+}
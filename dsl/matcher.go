@@ -6,6 +6,8 @@ import (
 	"log"
 	"reflect"
 	"regexp"
+	"regexp/syntax"
+	"sort"
 	"strings"
 	"time"
 )
@@ -102,9 +104,9 @@ func IPAddress() Matcher {
 // IPv4Address matches valid IPv4 addresses.
 var IPv4Address = IPAddress
 
-// IPv6Address defines a matcher that accepts IP addresses.
+// IPv6Address defines a matcher that accepts IPv6 addresses.
 func IPv6Address() Matcher {
-	return Regex("::ffff:192.0.2.128", ipAddress)
+	return Regex("::ffff:192.0.2.128", ipv6Address)
 }
 
 // Decimal defines a matcher that accepts any decimal value.
@@ -138,6 +140,165 @@ func UUID() Matcher {
 // Regex is a more appropriately named alias for the "Term" matcher
 var Regex = Term
 
+// MatcherError lists the problems found in the Matchers of an interaction or
+// message, each prefixed with the JSON path of the Matcher.
+type MatcherError struct {
+	// Description of the interaction or message.
+	Description string
+
+	// Problems found, e.g. "$.response.body.id: EachLike must have a minimum
+	// of at least 1, got 0".
+	Problems []string
+
+	// Warnings about Matchers that could not be checked, such as a Term
+	// whose regex uses a lookahead, which Go can't compile.
+	Warnings []string
+}
+
+func (e *MatcherError) Error() string {
+	message := fmt.Sprintf("invalid matchers in '%s':\n\t%s", e.Description, strings.Join(e.Problems, "\n\t"))
+	if len(e.Warnings) > 0 {
+		message += "\nwarnings:\n\t" + strings.Join(e.Warnings, "\n\t")
+	}
+	return message
+}
+
+// warningPrefix marks a problem that is a warning rather than an error,
+// e.g. "$.body.created: warning: unable to check ...".
+const warningPrefix = "warning: "
+
+// newMatcherError returns a *MatcherError for the problems found in the
+// Matchers of an interaction or message, or nil if there are only warnings.
+// Warnings are logged.
+func newMatcherError(description string, problems []string) error {
+	e := &MatcherError{Description: description}
+	for _, problem := range problems {
+		if strings.Contains(problem, ": "+warningPrefix) {
+			log.Printf("[WARN] unchecked matcher in '%s': %s", description, problem)
+			e.Warnings = append(e.Warnings, problem)
+		} else {
+			e.Problems = append(e.Problems, problem)
+		}
+	}
+
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+// logInvalidMatchers logs the problems found in the Matchers of part of an
+// interaction or message as it is built, ahead of it failing verification.
+// Warnings are logged when verified.
+func logInvalidMatchers(description string, problems []string) {
+	for _, problem := range problems {
+		if !strings.Contains(problem, ": "+warningPrefix) {
+			log.Printf("[WARN] invalid matcher in '%s', which will fail verification: %s", description, problem)
+		}
+	}
+}
+
+// UncheckedRegexError is returned by CheckTerm for a regex using constructs
+// Go can't compile, such as lookaheads and backreferences, so that the
+// example could not be checked. The regex may still be valid for the mock
+// service and verifier, which use other regex engines.
+type UncheckedRegexError struct {
+	// Regex that could not be compiled.
+	Regex string
+
+	// Err is the error compiling the regex.
+	Err error
+}
+
+func (e *UncheckedRegexError) Error() string {
+	return fmt.Sprintf("unable to check the example against the regex %q, which Go can't compile: %v", e.Regex, e.Err)
+}
+
+// backreference matches a backreference in a regex, e.g. `\3`.
+var backreference = regexp.MustCompile(`^\\[1-9]`)
+
+// CheckTerm checks that the example of a Term matches its regex. Regexes
+// using constructs Go can't compile, such as lookaheads, return an
+// *UncheckedRegexError, which should be treated as a warning.
+func CheckTerm(example string, regex string) error {
+	re, err := compileRegex(regex)
+	if err != nil {
+		if e, ok := err.(*syntax.Error); ok && (e.Code == syntax.ErrInvalidPerlOp || (e.Code == syntax.ErrInvalidEscape && backreference.MatchString(e.Expr))) {
+			return &UncheckedRegexError{Regex: regex, Err: err}
+		}
+		return fmt.Errorf("invalid regex %q: %v", regex, err)
+	}
+	if !re.MatchString(example) {
+		return fmt.Errorf("example %q does not match the regex %q", example, regex)
+	}
+	return nil
+}
+
+// validateMatchers checks the Matchers within a value of the DSL, returning
+// the problems found, in order of path.
+func validateMatchers(path []string, value interface{}) []string {
+	generic, err := decodeGeneric(value)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", formatPath(path), err)}
+	}
+
+	var problems []string
+	validateGeneric(path, generic, &problems)
+	sort.Strings(problems)
+	return problems
+}
+
+// validateGeneric walks a decoded JSON value, checking the Ruby style
+// matchers written by the DSL.
+func validateGeneric(path []string, value interface{}, problems *[]string) {
+	problem := func(format string, args ...interface{}) {
+		*problems = append(*problems, formatPath(path)+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch v["json_class"] {
+		case "Pact::SomethingLike":
+			validateGeneric(path, v["contents"], problems)
+			return
+		case "Pact::ArrayLike":
+			if n, ok := number(v["min"]); !ok || n < 1 {
+				problem("EachLike must have a minimum of at least 1, got %v", v["min"])
+			}
+			validateGeneric(child(path, "[*]"), v["contents"], problems)
+			return
+		case "Pact::Term":
+			data, _ := v["data"].(map[string]interface{})
+			matcher, _ := data["matcher"].(map[string]interface{})
+			example, isString := data["generate"].(string)
+			regex, _ := matcher["s"].(string)
+			switch {
+			case regex == "":
+				problem("Term must have a regex")
+			case !isString:
+				problem("Term example must be a string, got %v", data["generate"])
+			default:
+				if err := CheckTerm(example, regex); err != nil {
+					if _, unchecked := err.(*UncheckedRegexError); unchecked {
+						problem("%s%v", warningPrefix, err)
+					} else {
+						problem("%v", err)
+					}
+				}
+			}
+			return
+		}
+
+		for k, value := range v {
+			validateGeneric(child(path, k), value, problems)
+		}
+	case []interface{}:
+		for i, value := range v {
+			validateGeneric(child(path, fmt.Sprintf("[%d]", i)), value, problems)
+		}
+	}
+}
+
 // StringMatcher allows a string or Matcher to be provided in
 // when matching with the DSL
 // We use the strategy outlined at http://www.jerf.org/iri/post/2917
@@ -194,6 +355,28 @@ func (m Matcher) GetValue() interface{} {
 // to also contain complex matchers
 type MapMatcher map[string]StringMatcher
 
+// QueryValues is a query parameter with more than one value, e.g.
+// "?tag=a&tag=b", in order. Each value is a String or a Matcher such as
+// Term. To match any number of values, use EachLike instead, e.g.
+//
+//	Query: dsl.MapMatcher{
+//		"tag":  dsl.QueryValues{dsl.String("a"), dsl.Term("b", `^[a-z]+$`)},
+//		"sort": dsl.EachLike(dsl.Term("name", `^(name|date)$`), 1),
+//	}
+type QueryValues []StringMatcher
+
+func (q QueryValues) isMatcher() {}
+
+// GetValue returns the raw generated values for the matcher
+// without any of the matching detail context
+func (q QueryValues) GetValue() interface{} {
+	values := make([]interface{}, len(q))
+	for i, v := range q {
+		values[i] = v.GetValue()
+	}
+	return values
+}
+
 // Takes an object and converts it to a JSON representation
 func objectToString(obj interface{}) string {
 	switch content := obj.(type) {
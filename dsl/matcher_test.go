@@ -7,6 +7,7 @@ import (
 	"log"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -920,3 +921,97 @@ func Test_pluckParams(t *testing.T) {
 		})
 	}
 }
+
+func TestMatcher_CheckTerm(t *testing.T) {
+	if err := CheckTerm("127.0.0.1", ipAddress); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := CheckTerm("fe80::1", ipAddress); err == nil {
+		t.Fatalf("Expected an IPv6 address not to match the IPv4 regex")
+	}
+	if err := CheckTerm("fe80::1", ipv6Address); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := CheckTerm("abc", "[a-z"); err == nil || !strings.Contains(err.Error(), "invalid regex") {
+		t.Fatalf("Expected an error for an invalid regex but got %v", err)
+	}
+
+	// Lookaheads and backreferences can't be compiled in Go, so are not
+	// checked
+	for _, regex := range []string{`^\d+(?!\d)`, `^(-?)\d+\1$`} {
+		err := CheckTerm("anything", regex)
+		if _, ok := err.(*UncheckedRegexError); !ok {
+			t.Fatalf("Expected %s to be unchecked but got %v", regex, err)
+		}
+	}
+}
+
+func TestMatcher_ValidateBuiltIn(t *testing.T) {
+	matchers := map[string]Matcher{
+		"HexValue":    HexValue(),
+		"IPAddress":   IPAddress(),
+		"IPv6Address": IPv6Address(),
+		"Timestamp":   Timestamp(),
+		"Date":        Date(),
+		"Time":        Time(),
+		"UUID":        UUID(),
+	}
+
+	for name, matcher := range matchers {
+		if err := newMatcherError(name, validateMatchers([]string{"body"}, matcher)); err != nil {
+			t.Fatalf("Expected %s to be valid but got %v", name, err)
+		}
+	}
+
+	for _, matcher := range []Matcher{Timestamp(), Date()} {
+		problems := validateMatchers([]string{"body"}, matcher)
+		if len(problems) != 1 || !strings.HasPrefix(problems[0], "$.body: warning: unable to check the example") {
+			t.Fatalf("Expected a warning that the regex can't be checked but got %v", problems)
+		}
+	}
+}
+
+func TestMatcher_newMatcherError(t *testing.T) {
+	problems := []string{
+		`$.response.body.created: warning: unable to check the example against the regex`,
+		`$.response.body.id: example "abc" does not match the regex "^\\d+$"`,
+	}
+
+	err := newMatcherError("a request for a user", problems)
+	e, ok := err.(*MatcherError)
+	if !ok {
+		t.Fatalf("Expected a *MatcherError but got %v", err)
+	}
+	if len(e.Problems) != 1 || len(e.Warnings) != 1 || !strings.Contains(e.Error(), "warnings:\n\t$.response.body.created") {
+		t.Fatalf("Expected the problem and warning to be separated but got %v", e)
+	}
+
+	if err = newMatcherError("a request for a user", problems[:1]); err != nil {
+		t.Fatalf("Expected warnings alone not to be an error but got %v", err)
+	}
+}
+
+func TestMatcher_validateMatchers(t *testing.T) {
+	body := map[string]interface{}{
+		"id":    Term("abc", `^\d+$`),
+		"ok":    Like(Term("1", `^\d+$`)),
+		"items": EachLike(map[string]interface{}{"date": Term("2000-01-01", "[")}, 0),
+		"tags":  []interface{}{EachLike("a", 1), Like(1)},
+	}
+
+	problems := validateMatchers([]string{"response", "body"}, body)
+	expected := []string{
+		`$.response.body.id: example "abc" does not match the regex "^\\d+$"`,
+		`$.response.body.items: EachLike must have a minimum of at least 1, got 0`,
+		`$.response.body.items[*].date: invalid regex "["`,
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems but got %v", len(expected), problems)
+	}
+	for i, problem := range problems {
+		if !strings.HasPrefix(problem, expected[i]) {
+			t.Fatalf("Expected '%s' but got '%s'", expected[i], problem)
+		}
+	}
+}
@@ -0,0 +1,916 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mismatch describes a difference between an expected and actual request or
+// response.
+type Mismatch struct {
+	// Path to the mismatched value, e.g. "$.body.items[0].id" or "$.headers.Accept".
+	Path string `json:"path"`
+
+	// Message describing the mismatch.
+	Message string `json:"message"`
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Path, m.Message)
+}
+
+// MatchingRule is a single matcher from the matching rules of a Pact file,
+// e.g. {"match": "regex", "regex": "\\d+"}.
+type MatchingRule struct {
+	Match  string `json:"match"`
+	Regex  string `json:"regex,omitempty"`
+	Min    *int   `json:"min,omitempty"`
+	Max    *int   `json:"max,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// ruleList is the matchers that apply to a path, combined with AND or OR.
+type ruleList struct {
+	path     []string
+	matchers []MatchingRule
+	combine  string
+}
+
+// cascades reports whether the rules also apply to the children of the path,
+// as type based matching does.
+func (r ruleList) cascades() bool {
+	for _, m := range r.matchers {
+		switch m.Match {
+		case "type", "min", "max", "values":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// has reports whether any of the matchers are of the given type.
+func (r ruleList) has(match string) bool {
+	for _, m := range r.matchers {
+		if m.Match == match {
+			return true
+		}
+	}
+	return false
+}
+
+// bounds returns the minimum and maximum array lengths of the matchers.
+func (r ruleList) bounds() (*int, *int) {
+	var min, max *int
+	for _, m := range r.matchers {
+		if m.Min != nil {
+			min = m.Min
+		}
+		if m.Max != nil {
+			max = m.Max
+		}
+	}
+	return min, max
+}
+
+// matchingRules are the parsed matching rules of a request or response.
+type matchingRules []ruleList
+
+// parseMatchingRules parses the matching rules of a v2 Pact file, keyed by
+// path (e.g. "$.body.id"), or a v3 Pact file, keyed by category.
+func parseMatchingRules(raw map[string]interface{}) matchingRules {
+	var rules matchingRules
+
+	for key, value := range raw {
+		if strings.HasPrefix(key, "$") {
+			if list, ok := parseRuleList(value); ok {
+				list.path = normalisePath(parseRulePath(key))
+				rules = append(rules, list)
+			}
+			continue
+		}
+
+		category, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "path":
+			if list, ok := parseRuleList(category); ok {
+				list.path = []string{"path"}
+				rules = append(rules, list)
+			}
+		case "body":
+			for path, v := range category {
+				if list, ok := parseRuleList(v); ok {
+					list.path = append([]string{"body"}, parseRulePath(path)...)
+					rules = append(rules, list)
+				}
+			}
+		case "header", "headers", "query":
+			prefix := map[string]string{"header": "headers", "headers": "headers", "query": "query"}[key]
+			for name, v := range category {
+				if list, ok := parseRuleList(v); ok {
+					list.path = normalisePath([]string{prefix, name})
+					rules = append(rules, list)
+				}
+			}
+		}
+	}
+
+	// Stable order for equally weighted rules
+	sort.Slice(rules, func(i, j int) bool {
+		return strings.Join(rules[i].path, ".") < strings.Join(rules[j].path, ".")
+	})
+
+	return rules
+}
+
+// parseRuleList parses the matchers for a single path.
+func parseRuleList(value interface{}) (ruleList, bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return ruleList{}, false
+	}
+
+	list := ruleList{combine: "AND"}
+	if combine, ok := m["combine"].(string); ok {
+		list.combine = strings.ToUpper(combine)
+	}
+
+	var raw []interface{}
+	if matchers, ok := m["matchers"].([]interface{}); ok {
+		raw = matchers
+	} else {
+		raw = []interface{}{m}
+	}
+
+	for _, r := range raw {
+		data, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var rule MatchingRule
+		if err = json.Unmarshal(data, &rule); err != nil {
+			log.Println("[WARN] ignoring invalid matching rule:", string(data))
+			continue
+		}
+		if rule.Match == "" {
+			switch {
+			case rule.Regex != "":
+				rule.Match = "regex"
+			case rule.Min != nil || rule.Max != nil:
+				rule.Match = "type"
+			}
+		}
+		list.matchers = append(list.matchers, rule)
+	}
+
+	return list, len(list.matchers) > 0
+}
+
+// parseRulePath splits a matching rule path such as "$.body.items[*]['a b']"
+// into its segments, e.g. ["body", "items", "[*]", "a b"].
+func parseRulePath(path string) []string {
+	var segments []string
+	path = strings.TrimPrefix(path, "$")
+
+	for len(path) > 0 {
+		switch {
+		case strings.HasPrefix(path, "['") || strings.HasPrefix(path, `["`):
+			quote := path[1:2]
+			end := strings.Index(path[2:], quote+"]")
+			if end < 0 {
+				segments = append(segments, path[2:])
+				return segments
+			}
+			segments = append(segments, path[2:2+end])
+			path = path[2+end+2:]
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end < 0 {
+				segments = append(segments, path)
+				return segments
+			}
+			segments = append(segments, path[:end+1])
+			path = path[end+1:]
+		case strings.HasPrefix(path, "."):
+			path = path[1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+
+	return segments
+}
+
+// normalisePath lower cases header names, which are case insensitive.
+func normalisePath(path []string) []string {
+	if len(path) == 2 && path[0] == "headers" {
+		return []string{"headers", strings.ToLower(path[1])}
+	}
+	return path
+}
+
+// formatPath formats path segments as a JSON path, e.g. "$.body.items[0]".
+func formatPath(path []string) string {
+	var b bytes.Buffer
+	b.WriteString("$")
+	for _, segment := range path {
+		switch {
+		case strings.HasPrefix(segment, "["):
+			b.WriteString(segment)
+		case strings.ContainsAny(segment, ". []'"):
+			b.WriteString("['" + segment + "']")
+		default:
+			b.WriteString("." + segment)
+		}
+	}
+	return b.String()
+}
+
+// weight scores how well the rule path matches a prefix of the path. Exact
+// segments score 2 and wildcards 1, and 0 means no match.
+func weight(rulePath []string, path []string) int {
+	if len(rulePath) > len(path) {
+		return 0
+	}
+
+	total := 0
+	for i, segment := range rulePath {
+		switch {
+		case segment == path[i]:
+			total += 2
+		case segment == "*" && !strings.HasPrefix(path[i], "["):
+			total++
+		case segment == "[*]" && strings.HasPrefix(path[i], "["):
+			total++
+		default:
+			return 0
+		}
+	}
+	return total
+}
+
+// resolve finds the most specific rules for a path. Rules for a parent path
+// only apply if they cascade to children.
+func (rules matchingRules) resolve(path []string) *ruleList {
+	var best *ruleList
+	bestWeight := 0
+
+	for i := range rules {
+		r := &rules[i]
+		w := weight(r.path, path)
+		if w == 0 || (len(r.path) < len(path) && !r.cascades()) {
+			continue
+		}
+		if w > bestWeight || (w == bestWeight && best != nil && len(r.path) > len(best.path)) {
+			best, bestWeight = r, w
+		}
+	}
+
+	return best
+}
+
+// within reports whether there are rules for children of the path, e.g.
+// "$.query.tag[0]" for "$.query.tag".
+func (rules matchingRules) within(path []string) bool {
+	for _, r := range rules {
+		if len(r.path) > len(path) && weight(r.path[:len(path)], path) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FindMatchingRules returns the matchers that apply to the value at a path
+// in a request or response (e.g. "$.body.items[0].id" or "$.headers.Accept"),
+// given the matching rules of the request or response, and the path of the
+// rule they were found at. Rules for a parent path are returned if they
+// cascade to the value, as type based matchers do.
+func FindMatchingRules(rules map[string]interface{}, path string) ([]MatchingRule, string) {
+	list := parseMatchingRules(rules).resolve(normalisePath(parseRulePath(path)))
+	if list == nil {
+		return nil, ""
+	}
+	return list.matchers, formatPath(list.path)
+}
+
+// comparison compares expected and actual values using matching rules.
+type comparison struct {
+	rules               matchingRules
+	allowUnexpectedKeys bool
+	mismatches          []Mismatch
+}
+
+// mismatch records a difference at the path.
+func (c *comparison) mismatch(path []string, format string, args ...interface{}) {
+	c.mismatches = append(c.mismatches, Mismatch{
+		Path:    formatPath(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// child returns the path of a child.
+func child(path []string, segment string) []string {
+	return append(append([]string{}, path...), segment)
+}
+
+// compare recursively compares the expected value with the actual value.
+func (c *comparison) compare(path []string, expected interface{}, actual interface{}) {
+	rules := c.rules.resolve(path)
+
+	if rules != nil && rules.has("equality") {
+		if !reflect.DeepEqual(normaliseNumbers(expected), normaliseNumbers(actual)) {
+			c.mismatch(path, "expected %s but received %s", describe(expected), describe(actual))
+		}
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			c.mismatch(path, "expected an object but received %s", describe(actual))
+			return
+		}
+
+		if rules != nil && rules.has("values") {
+			keys := sortedKeys(e)
+			if len(keys) == 0 {
+				return
+			}
+			for _, k := range sortedKeys(a) {
+				c.compare(child(path, k), e[keys[0]], a[k])
+			}
+			return
+		}
+
+		for _, k := range sortedKeys(e) {
+			v, present := a[k]
+			if !present {
+				c.mismatch(child(path, k), "expected %s but the key was missing", describe(e[k]))
+				continue
+			}
+			c.compare(child(path, k), e[k], v)
+		}
+
+		if !c.allowUnexpectedKeys {
+			for _, k := range sortedKeys(a) {
+				if _, ok := e[k]; !ok {
+					c.mismatch(child(path, k), "unexpected key with value %s", describe(a[k]))
+				}
+			}
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			c.mismatch(path, "expected an array but received %s", describe(actual))
+			return
+		}
+
+		// Length bounds only apply to the array the rule is for, not to
+		// arrays nested inside it
+		min, max := (*int)(nil), (*int)(nil)
+		if rules != nil && len(rules.path) == len(path) {
+			min, max = rules.bounds()
+		}
+
+		if min == nil && max == nil {
+			if len(e) != len(a) {
+				c.mismatch(path, "expected an array of length %d but received length %d", len(e), len(a))
+			}
+			for i := 0; i < len(e) && i < len(a); i++ {
+				c.compare(child(path, fmt.Sprintf("[%d]", i)), e[i], a[i])
+			}
+			return
+		}
+
+		if min != nil && len(a) < *min {
+			c.mismatch(path, "expected an array with at least %d elements but received %d", *min, len(a))
+		}
+		if max != nil && len(a) > *max {
+			c.mismatch(path, "expected an array with at most %d elements but received %d", *max, len(a))
+		}
+		if len(e) == 0 {
+			return
+		}
+		for i, v := range a {
+			example := e[0]
+			if i < len(e) {
+				example = e[i]
+			}
+			c.compare(child(path, fmt.Sprintf("[%d]", i)), example, v)
+		}
+	default:
+		if rules == nil {
+			if !reflect.DeepEqual(normaliseNumbers(expected), normaliseNumbers(actual)) {
+				c.mismatch(path, "expected %s but received %s", describe(expected), describe(actual))
+			}
+			return
+		}
+
+		c.applyRules(path, rules, expected, actual)
+	}
+}
+
+// applyRules checks a primitive value against the matchers of a path.
+func (c *comparison) applyRules(path []string, rules *ruleList, expected interface{}, actual interface{}) {
+	var failures []string
+	for _, m := range rules.matchers {
+		if err := m.check(expected, actual); err != nil {
+			failures = append(failures, err.Error())
+		} else if rules.combine == "OR" {
+			return
+		}
+	}
+
+	if len(failures) > 0 {
+		if rules.combine == "OR" {
+			c.mismatch(path, "%s", strings.Join(failures, ", or "))
+		} else {
+			c.mismatch(path, "%s", strings.Join(failures, ", and "))
+		}
+	}
+}
+
+// check checks an actual primitive value against a single matcher.
+func (m MatchingRule) check(expected interface{}, actual interface{}) error {
+	switch m.Match {
+	case "type", "min", "max", "values":
+		if jsonType(expected) != jsonType(actual) {
+			return fmt.Errorf("expected a %s (like %s) but received %s", jsonType(expected), describe(expected), describe(actual))
+		}
+	case "regex":
+		re, err := compileRegex(m.Regex)
+		if err != nil {
+			log.Printf("[WARN] unable to check value against regular expression %q: %v", m.Regex, err)
+			return nil
+		}
+		value, ok := primitiveString(actual)
+		if !ok || !re.MatchString(value) {
+			return fmt.Errorf("expected a value matching /%s/ but received %s", m.Regex, describe(actual))
+		}
+	case "include":
+		value, ok := primitiveString(actual)
+		if !ok || !strings.Contains(value, m.Value) {
+			return fmt.Errorf("expected a value including %q but received %s", m.Value, describe(actual))
+		}
+	case "integer":
+		n, ok := number(actual)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("expected an integer but received %s", describe(actual))
+		}
+	case "decimal":
+		n, ok := number(actual)
+		if !ok || n == math.Trunc(n) && !hasDecimalPoint(actual) {
+			return fmt.Errorf("expected a decimal number but received %s", describe(actual))
+		}
+	case "number":
+		if _, ok := number(actual); !ok {
+			return fmt.Errorf("expected a number but received %s", describe(actual))
+		}
+	case "boolean":
+		if _, ok := actual.(bool); !ok {
+			return fmt.Errorf("expected a boolean but received %s", describe(actual))
+		}
+	case "null":
+		if actual != nil {
+			return fmt.Errorf("expected null but received %s", describe(actual))
+		}
+	case "date", "time", "timestamp", "datetime":
+		value, ok := actual.(string)
+		if !ok {
+			return fmt.Errorf("expected a %s but received %s", m.Match, describe(actual))
+		}
+		if m.Format != "" {
+			if _, err := time.Parse(javaDateLayout(m.Format), value); err != nil {
+				return fmt.Errorf("expected a %s in the format %q but received %s", m.Match, m.Format, describe(actual))
+			}
+		}
+	case "contentType":
+		value, ok := actual.(string)
+		example, _ := expected.(string)
+		decoded, err := base64.StdEncoding.DecodeString(example)
+		if err != nil {
+			decoded = []byte(example)
+		}
+		if !ok || !matchesContentType([]byte(value), m.Value, decoded) {
+			return fmt.Errorf("expected content of type %s but received %s", m.Value, detectContentType([]byte(fmt.Sprint(actual))))
+		}
+	case "equality":
+		if !reflect.DeepEqual(normaliseNumbers(expected), normaliseNumbers(actual)) {
+			return fmt.Errorf("expected %s but received %s", describe(expected), describe(actual))
+		}
+	default:
+		log.Printf("[DEBUG] ignoring unsupported matcher %q", m.Match)
+	}
+
+	return nil
+}
+
+// compileRegex compiles a regular expression from a Pact file. Ruby's \Z
+// anchor is translated to its Go equivalent; constructs Go does not support,
+// such as lookaheads, return an error.
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile(strings.Replace(expr, `\Z`, `\n?\z`, -1))
+}
+
+// jsonType names the JSON type of a value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64, json.Number:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+// describe formats a value for a mismatch message.
+func describe(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(data) > 80 {
+		return string(data[:77]) + "..."
+	}
+	return string(data)
+}
+
+// number converts a JSON number to a float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// hasDecimalPoint reports whether a JSON number was written with a decimal point.
+func hasDecimalPoint(v interface{}) bool {
+	if n, ok := v.(json.Number); ok {
+		return strings.ContainsAny(string(n), ".eE")
+	}
+	return false
+}
+
+// normaliseNumbers converts the numbers in a value to float64, so values
+// decoded in different ways can be compared.
+func normaliseNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = normaliseNumbers(val)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, val := range t {
+			a[i] = normaliseNumbers(val)
+		}
+		return a
+	}
+	if n, ok := number(v); ok {
+		return n
+	}
+	return v
+}
+
+// primitiveString formats a string, number or boolean as a string.
+func primitiveString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	if n, ok := number(v); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// javaDateLayout converts a Java date format, as used in Pact files, into
+// a Go time layout.
+func javaDateLayout(format string) string {
+	replacements := []struct{ java, golang string }{
+		{"yyyy", "2006"}, {"yy", "06"}, {"MMMM", "January"}, {"MMM", "Jan"},
+		{"MM", "01"}, {"M", "1"}, {"dd", "02"}, {"d", "2"}, {"EEEE", "Monday"},
+		{"EEE", "Mon"}, {"HH", "15"}, {"hh", "03"}, {"h", "3"}, {"mm", "04"},
+		{"ss", "05"}, {"SSS", "000"}, {"a", "PM"}, {"XXX", "Z07:00"},
+		{"XX", "Z0700"}, {"X", "Z07"}, {"Z", "-0700"}, {"z", "MST"},
+	}
+
+	var b bytes.Buffer
+	for i := 0; i < len(format); {
+		if format[i] == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				b.WriteString(format[i+1:])
+				break
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		matched := false
+		for _, r := range replacements {
+			if strings.HasPrefix(format[i:], r.java) {
+				b.WriteString(r.golang)
+				i += len(r.java)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(format[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// matchRequest compares an actual HTTP request, and its body, with the
+// request of an interaction, returning any mismatches.
+func matchRequest(expected PactRequest, actual *http.Request, body []byte) []Mismatch {
+	c := &comparison{rules: parseMatchingRules(expected.MatchingRules)}
+
+	if !strings.EqualFold(expected.Method, actual.Method) {
+		c.mismatch([]string{"method"}, "expected %s but received %s", strings.ToUpper(expected.Method), actual.Method)
+	}
+
+	c.compare([]string{"path"}, expected.Path, actual.URL.Path)
+	c.compareQuery(expected.Query, actual.URL.Query())
+	c.compareHeaders(expected.Headers, actual.Header)
+	c.compareBody(expected.Body, expected.Headers, actual.Header.Get("Content-Type"), body)
+
+	return c.mismatches
+}
+
+// compareQuery compares query parameters. Unexpected parameters are mismatches.
+func (c *comparison) compareQuery(expected PactQuery, actual map[string][]string) {
+	c.compareParameters([]string{"query"}, "query parameter", expected, actual)
+}
+
+// compareParameters compares the parameters of a query or form body, the
+// kind of which is used in mismatches. Unexpected parameters are mismatches.
+//
+// The values of a parameter are compared in order, like an array, so rules
+// may apply to each value (e.g. "$.query.tag[1]"), or to all of them with
+// bounds on their number, as EachLike does. A rule for the parameter without
+// bounds applies to every value.
+func (c *comparison) compareParameters(prefix []string, kind string, expected map[string][]string, actual map[string][]string) {
+	for _, k := range sortedStringKeys(expected) {
+		path := child(prefix, k)
+		values, ok := actual[k]
+		if !ok {
+			c.mismatch(path, "expected %s %s=%s but it was missing", kind, k, strings.Join(expected[k], ","))
+			continue
+		}
+
+		rules := c.rules.resolve(path)
+		if rules != nil && len(rules.path) == len(path) && len(expected[k]) > 0 {
+			if min, max := rules.bounds(); min == nil && max == nil {
+				for _, v := range values {
+					c.applyRules(path, rules, expected[k][0], v)
+				}
+				continue
+			}
+		}
+
+		if rules != nil || c.rules.within(path) {
+			c.compare(path, stringsToInterfaces(expected[k]), stringsToInterfaces(values))
+			continue
+		}
+
+		if strings.Join(expected[k], ",") != strings.Join(values, ",") {
+			c.mismatch(path, "expected %s but received %s", describe(expected[k]), describe(values))
+		}
+	}
+
+	for k, v := range actual {
+		if _, ok := expected[k]; !ok {
+			c.mismatch(child(prefix, k), "unexpected %s with value %s", kind, describe(v))
+		}
+	}
+}
+
+// compareHeaders compares the expected headers with the actual headers,
+// ignoring case in the names. Additional headers are allowed. Repeated
+// headers are compared as a single header of comma separated values.
+func (c *comparison) compareHeaders(expected map[string]string, actual http.Header) {
+	for _, name := range sortedHeaderNames(expected) {
+		path := normalisePath([]string{"headers", name})
+		values, ok := headerValues(actual, name)
+		if !ok {
+			c.mismatch(path, "expected header %s: %s but it was missing", name, expected[name])
+			continue
+		}
+		value := strings.Join(values, ", ")
+
+		if rules := c.rules.resolve(path); rules != nil {
+			c.applyRules(path, rules, expected[name], value)
+			continue
+		}
+
+		if normaliseHeaderValue(expected[name]) != normaliseHeaderValue(value) {
+			c.mismatch(path, "expected %q but received %q", expected[name], value)
+		}
+	}
+}
+
+// normaliseHeaderValue removes the optional whitespace around the commas
+// and semicolons separating header values and parameters.
+func normaliseHeaderValue(value string) string {
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' })
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ";")
+}
+
+// compareBody compares the body of a request. An interaction without a body
+// matches any body. JSON, XML, form and multipart bodies, and streams, are
+// compared structurally, and binary bodies by their base64 example or content
+// type.
+func (c *comparison) compareBody(expected interface{}, expectedHeaders map[string]string, contentType string, body []byte) {
+	if expected == nil {
+		return
+	}
+	path := []string{"body"}
+
+	if isJSONContentType(contentType) || (contentType == "" && !isString(expected)) {
+		var actual interface{}
+		decoder := json.NewDecoder(strings.NewReader(string(body)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&actual); err != nil {
+			c.mismatch(path, "expected a JSON body but it could not be parsed: %v", err)
+			return
+		}
+		c.compare(path, expected, actual)
+		return
+	}
+
+	if expectedForm, ok := expected.(string); ok && isFormContentType(contentType) {
+		e, err := url.ParseQuery(expectedForm)
+		if err == nil {
+			actual, err := url.ParseQuery(string(body))
+			if err != nil {
+				c.mismatch(path, "expected a form body but it could not be parsed: %v", err)
+				return
+			}
+			c.compareParameters(path, "form field", e, actual)
+			return
+		}
+	}
+
+	if expectedMultipart, ok := expected.(string); ok && strings.HasPrefix(contentType, "multipart/form-data") {
+		e, err := parseMultipart([]byte(expectedMultipart), "")
+		if err == nil {
+			_, params, _ := mime.ParseMediaType(contentType)
+			actual, err := parseMultipart(body, params["boundary"])
+			if err != nil {
+				c.mismatch(path, "expected a multipart body but it could not be parsed: %v", err)
+				return
+			}
+			c.compareMultipart(path, e, actual)
+			return
+		}
+	}
+
+	if expectedStream, ok := expected.(string); ok && isStreamContentType(contentType) {
+		if e, err := parseStream(contentType, []byte(expectedStream)); err == nil && len(e) > 0 {
+			actual, err := parseStream(contentType, body)
+			if err != nil {
+				c.mismatch(path, "expected a stream but it could not be read: %v", err)
+				return
+			}
+			c.compareStream(path, e, actual)
+			return
+		}
+	}
+
+	if expectedBinary, ok := expected.(string); ok && isBinaryContentType(contentType) {
+		if rules := c.rules.resolve(path); rules == nil || len(rules.path) != len(path) {
+			if example, err := base64.StdEncoding.DecodeString(expectedBinary); err == nil {
+				if !bytes.Equal(example, body) {
+					c.mismatch(path, "expected the %d bytes of the example but received %d different bytes", len(example), len(body))
+				}
+				return
+			}
+		}
+	}
+
+	if expectedXML, ok := expected.(string); ok && (isXMLContentType(contentType) || (contentType == "" && strings.HasPrefix(strings.TrimSpace(expectedXML), "<"))) {
+		if e, err := parseXML(expectedXML); err == nil {
+			actual, err := parseXML(string(body))
+			if err != nil {
+				c.mismatch(path, "expected an XML body but it could not be parsed: %v", err)
+				return
+			}
+			c.compare(path, e, actual)
+			return
+		}
+		log.Println("[DEBUG] expected body is not valid XML, comparing it as text")
+	}
+
+	c.compare(path, expected, string(body))
+}
+
+// isJSONContentType reports whether the content type is a JSON media type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isString reports whether the value is a string.
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+// stringsToInterfaces converts the values of a parameter to an array.
+func stringsToInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// sortedStringKeys returns the keys of a query in order.
+func sortedStringKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedHeaderNames returns the names of the headers in order.
+func sortedHeaderNames(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package dsl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeRules(t *testing.T, rules string) map[string]interface{} {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(rules), &raw); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return raw
+}
+
+func TestMatching_parseRulePath(t *testing.T) {
+	cases := map[string]string{
+		"$.body.items[*].id":  "body,items,[*],id",
+		"$.body['a b'][0]":    "body,a b,[0]",
+		"$.headers.Accept":    "headers,Accept",
+		"$.body.*":            "body,*",
+		"$":                   "",
+		"$.query.name[0]":     "query,name,[0]",
+		`$.body["x.y"].value`: "body,x.y,value",
+	}
+
+	for path, expected := range cases {
+		if segments := strings.Join(parseRulePath(path), ","); segments != expected {
+			t.Fatalf("Expected %s to be parsed as '%s' but got '%s'", path, expected, segments)
+		}
+	}
+}
+
+func TestMatching_compareBody(t *testing.T) {
+	expected := map[string]interface{}{
+		"id":    json.Number("1"),
+		"name":  "billy",
+		"email": "billy@example.com",
+		"items": []interface{}{map[string]interface{}{"sku": "abc"}},
+	}
+	rules := decodeRules(t, `{
+		"$.body.id": {"match": "type"},
+		"$.body.email": {"match": "regex", "regex": "^[a-z]+@example\\.com$"},
+		"$.body.items": {"min": 1}
+	}`)
+
+	cases := []struct {
+		body       string
+		mismatches int
+	}{
+		{`{"id": 2, "name": "billy", "email": "sally@example.com", "items": [{"sku": "x"}, {"sku": "y"}]}`, 0},
+		{`{"id": "2", "name": "billy", "email": "sally@example.com", "items": [{"sku": "x"}]}`, 1},
+		{`{"id": 2, "name": "sally", "email": "sally@example.org", "items": []}`, 3},
+		{`{"id": 2, "name": "billy", "email": "sally@example.com", "items": [{"sku": 1}], "extra": true}`, 2},
+	}
+
+	for _, c := range cases {
+		comparison := &comparison{rules: parseMatchingRules(rules)}
+		comparison.compareBody(expected, nil, "application/json", []byte(c.body))
+		if len(comparison.mismatches) != c.mismatches {
+			t.Fatalf("Expected %d mismatches for %s but got %v", c.mismatches, c.body, comparison.mismatches)
+		}
+	}
+}
+
+func TestMatching_valuesRule(t *testing.T) {
+	expected := map[string]interface{}{
+		"b": map[string]interface{}{"id": "x"},
+		"a": map[string]interface{}{"id": json.Number("1")},
+	}
+	actual := map[string]interface{}{
+		"z": map[string]interface{}{"id": "1"},
+		"y": map[string]interface{}{"id": json.Number("2")},
+		"x": map[string]interface{}{"id": true},
+	}
+	rules := decodeRules(t, `{"$.body": {"match": "values"}, "$.body.*.id": {"match": "type"}}`)
+
+	for i := 0; i < 10; i++ {
+		c := &comparison{rules: parseMatchingRules(rules)}
+		c.compare([]string{"body"}, expected, actual)
+
+		var paths []string
+		for _, m := range c.mismatches {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != "$.body.x.id,$.body.z.id" {
+			t.Fatalf("Expected the values to be compared with the example at 'a' but got %v", c.mismatches)
+		}
+	}
+}
+
+func TestMatching_matchRequest(t *testing.T) {
+	expected := PactRequest{
+		Method:  "GET",
+		Path:    "/users/1",
+		Query:   PactQuery{"page": []string{"1"}},
+		Headers: map[string]string{"Accept": "application/json"},
+		MatchingRules: decodeRules(t, `{
+			"path": {"matchers": [{"match": "regex", "regex": "^/users/[0-9]+$"}]},
+			"query": {"page": {"matchers": [{"match": "regex", "regex": "^[0-9]+$"}]}}
+		}`),
+	}
+
+	req := httptest.NewRequest("GET", "/users/42?page=3", nil)
+	req.Header.Set("accept", "application/json")
+	if mismatches := matchRequest(expected, req, nil); len(mismatches) != 0 {
+		t.Fatalf("Expected the request to match but got %v", mismatches)
+	}
+
+	req = httptest.NewRequest("POST", "/users/abc?page=3&size=10", nil)
+	mismatches := matchRequest(expected, req, nil)
+
+	var paths []string
+	for _, m := range mismatches {
+		paths = append(paths, m.Path)
+	}
+	if strings.Join(paths, ",") != "$.method,$.path,$.query.size,$.headers.accept" {
+		t.Fatalf("Unexpected mismatches: %v", mismatches)
+	}
+}
+
+func TestMatching_multiValuedQuery(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request for posts",
+		Request: Request{
+			Method: "GET",
+			Path:   String("/posts"),
+			Query: MapMatcher{
+				"tag":  QueryValues{String("go"), Term("pact", `^[a-z]+$`)},
+				"sort": EachLike(Term("date", `^(name|date)$`), 1),
+				"page": Term("1", `^\d+$`),
+			},
+		},
+		Response: Response{Status: 200},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := pact.Interactions[0].Request
+	if expected.Query.String() != "page=1&sort=date&tag=go&tag=pact" {
+		t.Fatalf("Unexpected query: %s", expected.Query.String())
+	}
+
+	cases := []struct {
+		query string
+		paths string
+	}{
+		{"tag=go&tag=testing&sort=name&sort=date&page=2", ""},
+		{"tag=go&tag=pact&sort=date&page=2&page=3", ""},
+		{"tag=pact&tag=go&sort=size&page=x", "$.query.page,$.query.sort[0],$.query.tag[0]"},
+		{"tag=go&page=1", "$.query.sort,$.query.tag"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/posts?"+c.query, nil)
+
+		var paths []string
+		for _, m := range matchRequest(expected, req, nil) {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != c.paths {
+			t.Fatalf("Expected mismatches at '%s' for %s but got %v", c.paths, c.query, paths)
+		}
+	}
+}
+
+func TestMatching_compareHeaders(t *testing.T) {
+	c := &comparison{}
+	c.compareHeaders(map[string]string{"Content-Type": "application/json;charset=utf-8"}, http.Header{
+		"Content-Type": []string{"application/json; charset=utf-8"},
+	})
+	if len(c.mismatches) != 0 {
+		t.Fatalf("Expected header values to be compared ignoring whitespace but got %v", c.mismatches)
+	}
+}
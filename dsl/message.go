@@ -24,7 +24,8 @@ type MessageHandlers map[string]MessageHandler
 type MessageConsumer func(Message) error
 
 // Message is a representation of a single, unidirectional message
-// e.g. MQ, pub/sub, Websocket, Lambda
+// e.g. MQ, pub/sub, Lambda. See WebSocketInteraction for bidirectional
+// WebSocket conversations.
 // Message is the main implementation of the Pact Message interface.
 type Message struct {
 	// Message Body
@@ -75,6 +76,7 @@ func (p *Message) ExpectsToReceive(description string) *Message {
 // to go with the content
 func (p *Message) WithMetadata(metadata MapMatcher) *Message {
 	p.Metadata = metadata
+	logInvalidMatchers(p.Description, validateMatchers([]string{"metadata"}, metadata))
 	return p
 }
 
@@ -83,10 +85,23 @@ func (p *Message) WithMetadata(metadata MapMatcher) *Message {
 // Mandatory.
 func (p *Message) WithContent(content interface{}) *Message {
 	p.Content = content
+	logInvalidMatchers(p.Description, validateMatchers([]string{"contents"}, content))
 
 	return p
 }
 
+// Validate checks the Matchers of the content and metadata: that Term
+// examples match their regex, and that EachLike requires at least one
+// element. It returns a *MatcherError listing every problem found, with
+// Terms that can't be checked logged as warnings, as for
+// Interaction.Validate.
+func (p *Message) Validate() error {
+	problems := validateMatchers([]string{"contents"}, p.Content)
+	problems = append(problems, validateMatchers([]string{"metadata"}, p.Metadata)...)
+
+	return newMatcherError(p.Description, problems)
+}
+
 // AsType specifies that the content sent through to the
 // consumer handler should be sent as the given type
 func (p *Message) AsType(t interface{}) *Message {
@@ -18,3 +18,27 @@ func TestMessage_DSL(t *testing.T) {
 		}).
 		AsType(t)
 }
+
+func TestMessage_Validate(t *testing.T) {
+	m := &Message{}
+	m.ExpectsToReceive("a user").
+		WithMetadata(MapMatcher{
+			"content-type": Term("text/plain", "application/json"),
+		}).
+		WithContent(map[string]interface{}{
+			"id": Term("1", `\d+`),
+		})
+
+	err := m.Validate()
+	if err == nil {
+		t.Fatalf("Expected the message to be invalid")
+	}
+	if problems := err.(*MatcherError).Problems; len(problems) != 1 || problems[0] != `$.metadata.content-type: example "text/plain" does not match the regex "application/json"` {
+		t.Fatalf("Expected the metadata to be invalid but got %v", problems)
+	}
+
+	m.WithMetadata(MapMatcher{"content-type": String("application/json")})
+	if err = m.Validate(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// mockServiceProxy runs in front of the mock service, matching the requests
+// of interactions with structured request bodies, such as XMLElement,
+// FormBody and MultipartBody, with their Matchers. The mock service is only
+// sent their examples, so it could only match a body equal to the example,
+// and a multipart body only with the same boundary.
+//
+// A request that matches such an interaction is forwarded with the example
+// body, and its Content-Type, so the mock service records the match. Other
+// requests, and the requests of the administration API, are forwarded as
+// they are. The mock service returns a binary body as its base64 example,
+// which the proxy decodes, and a stream in one piece, which the proxy writes
+// an event at a time, as the StubServer does.
+type mockServiceProxy struct {
+	// MockServiceURL is the base URL of the mock service.
+	MockServiceURL string
+
+	// Host and Port the proxy listens on.
+	Host string
+	Port int
+
+	mu           sync.Mutex
+	interactions []structuredInteraction
+	proxy        *httputil.ReverseProxy
+	listener     net.Listener
+	server       *http.Server
+}
+
+// structuredInteraction is an interaction with a structured request body, as
+// written to a Pact file.
+type structuredInteraction struct {
+	PactInteraction
+	body structuredBody
+}
+
+// Start starts the proxy on the Host and Port.
+func (p *mockServiceProxy) Start() error {
+	target, err := url.Parse(p.MockServiceURL)
+	if err != nil {
+		return err
+	}
+	p.proxy = httputil.NewSingleHostReverseProxy(target)
+	p.proxy.ModifyResponse = modifyResponse
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", p.Host, p.Port))
+	if err != nil {
+		return err
+	}
+	p.listener = listener
+	p.server = &http.Server{Handler: p}
+
+	log.Printf("[DEBUG] mock service proxy listening on %s:%d for %s", p.Host, p.Port, p.MockServiceURL)
+	go p.server.Serve(listener)
+	return nil
+}
+
+// Stop stops the proxy.
+func (p *mockServiceProxy) Stop() error {
+	if p.server == nil {
+		return nil
+	}
+	log.Println("[DEBUG] stopping mock service proxy")
+	err := p.server.Close()
+	p.listener.Close()
+	p.server = nil
+	return err
+}
+
+// Load replaces the interactions the proxy matches with those of the DSL
+// interactions that have a structured request body.
+func (p *mockServiceProxy) Load(interactions []*Interaction) error {
+	var structured []structuredInteraction
+	for _, i := range interactions {
+		body, ok := i.Request.Body.(structuredBody)
+		if !ok {
+			continue
+		}
+		pact := &PactFile{}
+		if err := pact.AddInteraction(*i); err != nil {
+			return err
+		}
+		structured = append(structured, structuredInteraction{PactInteraction: pact.Interactions[0], body: body})
+	}
+
+	p.mu.Lock()
+	p.interactions = structured
+	p.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP forwards the request to the mock service, with the example body
+// of the first structured interaction it matches.
+func (p *mockServiceProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Pact-Mock-Service") != "" {
+		p.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeStubError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	p.mu.Lock()
+	interactions := p.interactions
+	p.mu.Unlock()
+
+	for _, interaction := range interactions {
+		mismatches := matchStructuredRequest(interaction.Request, r, body)
+		if len(mismatches) > 0 {
+			log.Printf("[DEBUG] mock service proxy: %s %s did not match '%s': %v", r.Method, r.URL, interaction.Description, mismatches)
+			continue
+		}
+
+		log.Printf("[DEBUG] mock service proxy matched %s %s to '%s'", r.Method, r.URL, interaction.Description)
+		contentType, ok := headerExample(interaction.Request.Headers, "Content-Type")
+		if !ok {
+			contentType = interaction.body.ContentType()
+		}
+		body = []byte(interaction.body.String())
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		break
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	p.proxy.ServeHTTP(flushWriter{w}, r)
+}
+
+// flushWriter flushes each write of a response, so that each event of a
+// stream is sent as it is written.
+type flushWriter struct {
+	http.ResponseWriter
+}
+
+// Write writes and flushes the data.
+func (w flushWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// modifyResponse rewrites a response of the mock service with a binary or
+// streaming body.
+func modifyResponse(res *http.Response) error {
+	if isStreamContentType(res.Header.Get("Content-Type")) {
+		return streamResponse(res)
+	}
+	return decodeBinaryResponse(res)
+}
+
+// streamResponse replaces the body of a response with a stream of its
+// events, read one at a time.
+func streamResponse(res *http.Response) error {
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	res.Body = ioutil.NopCloser(&eventReader{events: streamEvents(res.Header.Get("Content-Type"), body)})
+	res.ContentLength = -1
+	res.Header.Del("Content-Length")
+	return nil
+}
+
+// decodeBinaryResponse replaces a base64 body of a response with a binary
+// Content-Type with the bytes it encodes.
+func decodeBinaryResponse(res *http.Response) error {
+	if !isBinaryContentType(res.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(string(body)); err == nil {
+		body = decoded
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// matchStructuredRequest matches a request to an interaction, as the
+// StubServer does. The boundary of a multipart body may differ from the
+// example's, so its Content-Type is compared by media type alone.
+func matchStructuredRequest(expected PactRequest, actual *http.Request, body []byte) []Mismatch {
+	contentType, ok := headerExample(expected.Headers, "Content-Type")
+	if !ok || !strings.HasPrefix(strings.ToLower(contentType), "multipart/") {
+		return matchRequest(expected, actual, body)
+	}
+
+	headers := make(map[string]string, len(expected.Headers))
+	for name, value := range expected.Headers {
+		if !strings.EqualFold(name, "Content-Type") {
+			headers[name] = value
+		}
+	}
+	expected.Headers = headers
+
+	mismatches := matchRequest(expected, actual, body)
+	expectedType, _, _ := mime.ParseMediaType(contentType)
+	actualType, _, _ := mime.ParseMediaType(actual.Header.Get("Content-Type"))
+	if expectedType != actualType {
+		mismatches = append(mismatches, Mismatch{
+			Path:    "$.headers.Content-Type",
+			Message: fmt.Sprintf("expected %s but received %q", expectedType, actual.Header.Get("Content-Type")),
+		})
+	}
+	return mismatches
+}
+
+// headerExample returns the example of a header, ignoring the case of its
+// name.
+func headerExample(headers map[string]string, name string) (string, bool) {
+	for n, value := range headers {
+		if strings.EqualFold(n, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,150 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/utils"
+)
+
+func TestMockServiceProxy(t *testing.T) {
+	var received, receivedType string
+	mockService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received, receivedType = string(body), r.Header.Get("Content-Type")
+	}))
+	defer mockService.Close()
+
+	port, _ := utils.GetFreePort()
+	proxy := &mockServiceProxy{MockServiceURL: mockService.URL, Host: "localhost", Port: port}
+	if err := proxy.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer proxy.Stop()
+
+	photo := []byte("\x89PNG\r\n\x1a\n")
+	upload := Multipart().
+		Field("description", Like("My holiday")).
+		File("photo", Like("photo.png"), Term("image/png", "^image/"), photo).
+		FileSize("photo", 1, 1024)
+	form := Form(MapMatcher{"username": Like("billy")})
+	err := proxy.Load([]*Interaction{
+		(&Interaction{}).
+			UponReceiving("an upload").
+			WithRequest(Request{Method: "POST", Path: String("/photos"), Headers: MapMatcher{"Content-Type": String(upload.ContentType())}, Body: upload}),
+		(&Interaction{}).
+			UponReceiving("a login").
+			WithRequest(Request{Method: "POST", Path: String("/login"), Body: form}),
+		(&Interaction{}).
+			UponReceiving("a JSON request").
+			WithRequest(Request{Method: "POST", Path: String("/json"), Body: map[string]interface{}{"a": Like(1)}}),
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	w.WriteField("description", "Skiing")
+	part, _ := w.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {`form-data; name="photo"; filename="skiing.png"`},
+		"Content-Type":        {"image/png"},
+	})
+	part.Write(append(photo, 1, 2, 3))
+	w.Close()
+
+	cases := []struct {
+		path         string
+		contentType  string
+		body         string
+		expected     string
+		expectedType string
+	}{
+		{"/photos", w.FormDataContentType(), b.String(), upload.String(), upload.ContentType()},
+		{"/photos", "multipart/form-data; boundary=other", "--other--", "--other--", "multipart/form-data; boundary=other"},
+		{"/login", "application/x-www-form-urlencoded", "username=sally", form.String(), form.ContentType()},
+		{"/login", "application/x-www-form-urlencoded", "username=sally&password=x", "username=sally&password=x", "application/x-www-form-urlencoded"},
+		{"/json", "application/json", `{"a": 2}`, `{"a": 2}`, "application/json"},
+	}
+
+	for _, c := range cases {
+		res, err := http.Post(fmt.Sprintf("http://localhost:%d%s", port, c.path), c.contentType, strings.NewReader(c.body))
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		res.Body.Close()
+
+		if received != c.expected || receivedType != c.expectedType {
+			t.Fatalf("Expected %s to forward %q (%s) but got %q (%s)", c.path, c.expected, c.expectedType, received, receivedType)
+		}
+	}
+}
+
+func TestMockServiceProxy_binaryResponse(t *testing.T) {
+	mockService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/photo" {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		w.Write([]byte(base64.StdEncoding.EncodeToString(png)))
+	}))
+	defer mockService.Close()
+
+	port, _ := utils.GetFreePort()
+	proxy := &mockServiceProxy{MockServiceURL: mockService.URL, Host: "localhost", Port: port}
+	if err := proxy.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer proxy.Stop()
+
+	cases := map[string][]byte{
+		"/photo": png,
+		"/text":  []byte(base64.StdEncoding.EncodeToString(png)),
+	}
+	for path, expected := range cases {
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if !bytes.Equal(body, expected) || res.ContentLength != int64(len(expected)) {
+			t.Fatalf("Expected %s to return %d bytes but got %d", path, len(expected), len(body))
+		}
+	}
+}
+
+func TestMockServiceProxy_streamResponse(t *testing.T) {
+	mockService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", EventStreamContentType)
+		w.Write([]byte(priceStream().String()))
+	}))
+	defer mockService.Close()
+
+	port, _ := utils.GetFreePort()
+	proxy := &mockServiceProxy{MockServiceURL: mockService.URL, Host: "localhost", Port: port}
+	if err := proxy.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer proxy.Stop()
+
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d/prices", port))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if res.ContentLength != -1 {
+		t.Fatalf("Expected the stream to be sent in chunks but got a Content-Length of %d", res.ContentLength)
+	}
+	if err = priceStream().Verify(res.Body, time.Second); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
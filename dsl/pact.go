@@ -15,7 +15,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode"
 
 	"github.com/hashicorp/logutils"
 	"github.com/pact-foundation/pact-go/install"
@@ -65,8 +67,10 @@ type Pact struct {
 	// See https://github.com/pact-foundation/pact-ruby/blob/master/documentation/configuration.md#pactfile_write_mode
 	PactFileWriteMode string
 
-	// Specify which version of the Pact Specification should be used (1 or 2).
-	// Defaults to 2.
+	// Specify which version of the Pact Specification should be used (1, 2
+	// or 3). Defaults to 2. With 3, WritePact rewrites the Pact file written
+	// by the mock service with query parameters in the map form, e.g.
+	// {"tag": ["a", "b"]}, rather than as a query string.
 	SpecificationVersion int
 
 	// Host is the address of the Mock and Verification Service runs on
@@ -90,6 +94,13 @@ type Pact struct {
 
 	// Check if CLI tools are up to date
 	toolValidityCheck bool
+
+	// Proxy in front of the mock service, matching structured bodies.
+	proxy *mockServiceProxy
+
+	// Verified interactions with structured bodies, whose matching rules
+	// are added to the Pact file once the mock service has written it.
+	structured []*Interaction
 }
 
 // AddMessage creates a new asynchronous consumer expectation
@@ -157,13 +168,7 @@ func (p *Pact) Setup(startMockServer bool) *Pact {
 	}
 
 	// Need to predefine due to scoping
-	var port int
-	var perr error
-	if p.AllowedMockServerPorts != "" {
-		port, perr = utils.FindPortInRange(p.AllowedMockServerPorts)
-	} else {
-		port, perr = utils.GetFreePort()
-	}
+	port, perr := p.findPort()
 	if perr != nil {
 		log.Println("[ERROR] unable to find free port, mockserver will fail to start")
 	}
@@ -185,12 +190,68 @@ func (p *Pact) Setup(startMockServer bool) *Pact {
 			p.PactFileWriteMode,
 		}
 
-		p.Server = p.pactClient.StartServer(args, port)
+		// Interactions with structured bodies need the mock service to run
+		// behind a proxy on the port, which matches them with their Matchers
+		mockServicePort := port
+		if hasStructuredBody(p.Interactions) {
+			var err error
+			if mockServicePort, err = utils.GetFreePort(); err != nil {
+				log.Println("[ERROR] unable to find free port for the mock service, its proxy will not be started")
+				mockServicePort = port
+			}
+		}
+		p.Server = p.pactClient.StartServer(args, mockServicePort)
+
+		if mockServicePort != port {
+			if err := p.startProxy(port); err != nil {
+				log.Printf("[ERROR] unable to start the mock service proxy on port %d, the mock service is on port %d: %v", port, mockServicePort, err)
+			}
+		}
 	}
 
 	return p
 }
 
+// findPort finds a port for the mock server, in the AllowedMockServerPorts
+// if given.
+func (p *Pact) findPort() (int, error) {
+	if p.AllowedMockServerPorts != "" {
+		return utils.FindPortInRange(p.AllowedMockServerPorts)
+	}
+	return utils.GetFreePort()
+}
+
+// startProxy starts the mock service proxy on the port, in front of the
+// running mock service, which the Server then refers to.
+func (p *Pact) startProxy(port int) error {
+	proxy := &mockServiceProxy{
+		MockServiceURL: fmt.Sprintf("http://%s:%d", p.Host, p.Server.Port),
+		Host:           p.Host,
+		Port:           port,
+	}
+	if err := proxy.Start(); err != nil {
+		return err
+	}
+
+	p.proxy = proxy
+	p.Server.Port = port
+	return nil
+}
+
+// hasStructuredBody reports whether any of the interactions has a structured
+// request or response body, such as an XMLElement or FormBody.
+func hasStructuredBody(interactions []*Interaction) bool {
+	for _, i := range interactions {
+		if _, ok := i.Request.Body.(structuredBody); ok {
+			return true
+		}
+		if _, ok := i.Response.Body.(structuredBody); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // Configure logging
 func (p *Pact) setupLogging() {
 	if p.logFilter == nil {
@@ -211,6 +272,10 @@ func (p *Pact) setupLogging() {
 // of each test suite.
 func (p *Pact) Teardown() *Pact {
 	log.Println("[DEBUG] teardown")
+	if p.proxy != nil {
+		p.proxy.Stop()
+		p.proxy = nil
+	}
 	if p.Server != nil {
 		server, err := p.pactClient.StopServer(p.Server)
 
@@ -223,7 +288,8 @@ func (p *Pact) Teardown() *Pact {
 }
 
 // Verify runs the current test case against a Mock Service.
-// Will cleanup interactions between tests within a suite.
+// Will cleanup interactions between tests within a suite. The Matchers of
+// each interaction are validated first, returning every problem found.
 func (p *Pact) Verify(integrationTest func() error) error {
 	p.Setup(true)
 	log.Println("[DEBUG] pact verify")
@@ -233,12 +299,42 @@ func (p *Pact) Verify(integrationTest func() error) error {
 		return errors.New("there are no interactions to be verified")
 	}
 
+	var invalid []string
+	for _, interaction := range p.Interactions {
+		if err := interaction.Validate(); err != nil {
+			invalid = append(invalid, err.Error())
+		}
+	}
+	if len(invalid) > 0 {
+		return errors.New(strings.Join(invalid, "\n"))
+	}
+
+	// The mock service was started without its proxy, as the interactions
+	// of the first test had no structured bodies, so the proxy is started on
+	// a new port
+	if p.proxy == nil && hasStructuredBody(p.Interactions) {
+		port, err := p.findPort()
+		if err == nil {
+			err = p.startProxy(port)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to start the mock service proxy, which matches structured bodies: %v", err)
+		}
+		log.Printf("[INFO] started the mock service proxy for structured bodies, the mock server is now on port %d", port)
+	}
+
 	mockServer := &MockService{
 		BaseURL:  fmt.Sprintf("http://%s:%d", p.Host, p.Server.Port),
 		Consumer: p.Consumer,
 		Provider: p.Provider,
 	}
 
+	if p.proxy != nil {
+		if err := p.proxy.Load(p.Interactions); err != nil {
+			return err
+		}
+	}
+
 	for _, interaction := range p.Interactions {
 		err := mockServer.AddInteraction(interaction)
 		if err != nil {
@@ -258,8 +354,17 @@ func (p *Pact) Verify(integrationTest func() error) error {
 		return err
 	}
 
+	for _, interaction := range p.Interactions {
+		if hasStructuredBody([]*Interaction{interaction}) {
+			p.structured = append(p.structured, interaction)
+		}
+	}
+
 	// Clear out interations
 	p.Interactions = make([]*Interaction, 0)
+	if p.proxy != nil {
+		p.proxy.Load(nil)
+	}
 
 	return mockServer.DeleteInteractions()
 }
@@ -281,11 +386,46 @@ func (p *Pact) WritePact() error {
 		return err
 	}
 
-	return nil
+	return p.rewritePact()
+}
+
+// rewritePact rewrites the Pact file written by the mock service, adding the
+// matching rules of the structured bodies verified so far, such as
+// XMLElement and FormBody, which it is only sent the examples of. A Pact
+// file of v3 of the specification is rewritten with its queries in the map
+// form, e.g. {"tag": ["a", "b"]}.
+func (p *Pact) rewritePact() error {
+	if len(p.structured) == 0 && p.SpecificationVersion < 3 {
+		return nil
+	}
+
+	file := filepath.Join(p.PactDir, pactFileName(p.Consumer, p.Provider))
+	pact, err := LoadPactFile(file)
+	if err != nil {
+		return err
+	}
+	pact.addBodyRules(p.structured)
+	return pact.Write(file)
+}
+
+// pactFileName is the name of the Pact file the mock service writes, e.g.
+// "my_consumer-my_provider.json".
+func pactFileName(consumer string, provider string) string {
+	name := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return '_'
+			}
+			return r
+		}, strings.ToLower(s))
+	}
+	return fmt.Sprintf("%s-%s.json", name(consumer), name(provider))
 }
 
 // VerifyProviderRaw reads the provided pact files and runs verification against
 // a running Provider API, providing raw response from the Verification process.
+// Interactions with a streaming response, of Server-Sent Events or NDJSON,
+// are verified by reading the stream, within the StreamTimeout.
 func (p *Pact) VerifyProviderRaw(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
 	p.Setup(false)
 
@@ -300,7 +440,147 @@ func (p *Pact) VerifyProviderRaw(request types.VerifyRequest) (types.ProviderVer
 
 	log.Println("[DEBUG] pact provider verification")
 
-	return p.pactClient.VerifyProvider(request)
+	streams := findStreamPacts(request)
+	if len(request.PendingPactURLs) == 0 && !request.PublishVerificationResults && len(streams) == 0 {
+		return p.pactClient.VerifyProvider(request)
+	}
+
+	return p.verifyEachPact(request, streams)
+}
+
+// verifyEachPact verifies each pact separately, so that pending pacts do not
+// fail the verification and so that each result can be published to the
+// Pact Broker. The streams are the Pact files with streaming responses, by
+// URL.
+func (p *Pact) verifyEachPact(request types.VerifyRequest, streams map[string]*PactFile) (types.ProviderVerifierResponse, error) {
+	var response types.ProviderVerifierResponse
+	var verificationErr error
+
+	pending := make(map[string]bool)
+	for _, pactURL := range request.PendingPactURLs {
+		pending[pactURL] = true
+	}
+
+	pactURLs := append(append([]string{}, request.PactURLs...), request.PendingPactURLs...)
+	if len(pactURLs) == 0 {
+		return p.pactClient.VerifyProvider(request)
+	}
+
+	broker := &Broker{
+		Username: request.BrokerUsername,
+		Password: request.BrokerPassword,
+	}
+
+	for _, pactURL := range pactURLs {
+		log.Println("[DEBUG] pact provider verification - verifying pact:", pactURL, "pending:", pending[pactURL])
+		res, err := p.verifyPact(request, pactURL, streams[pactURL])
+
+		// The verifier could not run at all, there are no results to report
+		if err != nil && len(res.Examples) == 0 {
+			return response, err
+		}
+
+		if request.PublishVerificationResults {
+			if err := p.publishVerificationResult(broker, pactURL, request, res); err != nil {
+				return response, err
+			}
+		}
+
+		if pending[pactURL] {
+			if err != nil {
+				log.Println("[WARN] pending pact failed verification, this will not fail the build:", pactURL)
+			}
+			for i := range res.Examples {
+				res.Examples[i].Pending = true
+			}
+			res.Summary.PendingCount += res.Summary.FailureCount
+			res.Summary.FailureCount = 0
+		} else if err != nil && verificationErr == nil {
+			verificationErr = err
+		}
+
+		response.Version = res.Version
+		response.Examples = append(response.Examples, res.Examples...)
+		response.Summary.Duration += res.Summary.Duration
+		response.Summary.ExampleCount += res.Summary.ExampleCount
+		response.Summary.FailureCount += res.Summary.FailureCount
+		response.Summary.PendingCount += res.Summary.PendingCount
+		response.Summary.ErrorsOutsideOfExamplesCount += res.Summary.ErrorsOutsideOfExamplesCount
+	}
+
+	response.SummaryLine = fmt.Sprintf("%d examples, %d failures, %d pending", response.Summary.ExampleCount, response.Summary.FailureCount, response.Summary.PendingCount)
+
+	return response, verificationErr
+}
+
+// verifyPact verifies a single pact. If it has interactions with streaming
+// responses, they are verified by reading each stream, and the others by
+// the verifier, with a copy of the Pact file without them.
+func (p *Pact) verifyPact(request types.VerifyRequest, pactURL string, pact *PactFile) (types.ProviderVerifierResponse, error) {
+	request.PactURLs = []string{pactURL}
+	request.PendingPactURLs = nil
+	if pact == nil {
+		return p.pactClient.VerifyProvider(request)
+	}
+
+	var streams, others []PactInteraction
+	for _, interaction := range pact.Interactions {
+		if isStreamInteraction(interaction) {
+			streams = append(streams, interaction)
+		} else {
+			others = append(others, interaction)
+		}
+	}
+
+	var response types.ProviderVerifierResponse
+	var err error
+	if len(others) > 0 {
+		dir, dirErr := ioutil.TempDir("", "pact-go")
+		if dirErr != nil {
+			return response, dirErr
+		}
+		defer os.RemoveAll(dir)
+
+		withoutStreams := *pact
+		withoutStreams.Interactions = others
+		file := filepath.Join(dir, pactFileName(pact.Consumer.Name, pact.Provider.Name))
+		if err = withoutStreams.Write(file); err != nil {
+			return response, err
+		}
+
+		request.PactURLs = []string{file}
+		response, err = p.pactClient.VerifyProvider(request)
+		if err != nil && len(response.Examples) == 0 {
+			return response, err
+		}
+	}
+
+	log.Println("[DEBUG] pact provider verification - reading the streaming responses of pact:", pactURL)
+	if failures := verifyStreams(&response, request, pact, streams); failures > 0 && err == nil {
+		err = fmt.Errorf("%d streaming responses of pact %s failed verification", failures, pactURL)
+	}
+	return response, err
+}
+
+// publishVerificationResult publishes the result of verifying a single pact
+// to the Pact Broker it was retrieved from.
+func (p *Pact) publishVerificationResult(broker *Broker, pactURL string, request types.VerifyRequest, res types.ProviderVerifierResponse) error {
+	if !strings.HasPrefix(pactURL, "http") {
+		log.Println("[WARN] unable to publish verification results for a local pact file:", pactURL)
+		return nil
+	}
+
+	result := types.NewVerificationResult(res)
+	result.ProviderApplicationVersion = request.ProviderVersion
+	result.ProviderVersionBranch = request.ProviderBranch
+	result.BuildURL = request.BuildURL
+
+	err := broker.PublishVerificationResult(pactURL, result)
+	if err != nil {
+		return fmt.Errorf("unable to publish verification results for pact %s: %v", pactURL, err)
+	}
+
+	return nil
 }
 
 // VerifyProvider accepts an instance of `*testing.T`
@@ -313,6 +593,10 @@ func (p *Pact) VerifyProvider(t *testing.T, request types.VerifyRequest) (types.
 		t.Run(example.Description, func(st *testing.T) {
 			st.Log(example.FullDescription)
 			if example.Status != "passed" {
+				if example.Pending {
+					st.Logf("[WARN] pending pact failed verification, this will not fail the build:\n%s\n%s\n", example.FullDescription, example.Exception.Message)
+					return
+				}
 				t.Errorf("%s\n%s\n", example.FullDescription, example.Exception.Message)
 			}
 		})
@@ -412,6 +696,10 @@ func (p *Pact) VerifyMessageProvider(t *testing.T, request VerifyMessageRequest)
 		t.Run(example.Description, func(st *testing.T) {
 			st.Log(example.FullDescription)
 			if example.Status != "passed" {
+				if example.Pending {
+					st.Logf("[WARN] pending pact failed verification, this will not fail the build:\n%s\n", example.Exception.Message)
+					return
+				}
 				st.Errorf("%s\n", example.Exception.Message)
 				st.Error("Check to ensure that all message expectations have corresponding message handlers")
 			}
@@ -446,10 +734,16 @@ func (p *Pact) VerifyMessageProviderRaw(request VerifyMessageRequest) (types.Pro
 		PactURLs:                   request.PactURLs,
 		BrokerURL:                  request.BrokerURL,
 		Tags:                       request.Tags,
+		ProviderTags:               request.ProviderTags,
+		EnablePending:              request.EnablePending,
+		IncludeWIPPactsSince:       request.IncludeWIPPactsSince,
+		PendingPactURLs:            request.PendingPactURLs,
 		BrokerUsername:             request.BrokerUsername,
 		BrokerPassword:             request.BrokerPassword,
 		PublishVerificationResults: request.PublishVerificationResults,
 		ProviderVersion:            request.ProviderVersion,
+		ProviderBranch:             request.ProviderBranch,
+		BuildURL:                   request.BuildURL,
 	}
 
 	mux.HandleFunc("/", messageHandler(request.MessageHandlers, request.StateHandlers))
@@ -485,6 +779,10 @@ func (p *Pact) VerifyMessageConsumerRaw(message *Message, handler MessageConsume
 	log.Printf("[DEBUG] verify message")
 	p.Setup(false)
 
+	if err := message.Validate(); err != nil {
+		return err
+	}
+
 	// Reify the message back to its "example/generated" form
 	reified, err := p.pactClient.ReifyMessage(&types.PactReificationRequest{
 		Message: message.Content,
@@ -0,0 +1,537 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PactFile is a representation of a Pact file, as written by the mock
+// service and read by the Publisher and StubServer.
+type PactFile struct {
+	// The API Consumer name
+	Consumer PactName `json:"consumer"`
+
+	// The API Provider name
+	Provider PactName `json:"provider"`
+
+	// Interactions between the Consumer and Provider.
+	Interactions []PactInteraction `json:"interactions,omitempty"`
+
+	// Metadata such as the Pact specification version.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// PactName represents the name fields in the PactFile.
+type PactName struct {
+	Name string `json:"name"`
+}
+
+// PactInteraction is an interaction as written to a Pact file, with example
+// values and matching rules in place of the Matchers used by the DSL.
+type PactInteraction struct {
+	// Description of the interaction.
+	Description string `json:"description"`
+
+	// ProviderState is the provider state of a v2 Pact file.
+	ProviderState string `json:"providerState,omitempty"`
+
+	// ProviderStates are the provider states of a v3 Pact file.
+	ProviderStates []PactProviderState `json:"providerStates,omitempty"`
+
+	// Request expected by the Provider.
+	Request PactRequest `json:"request"`
+
+	// Response returned by the Provider.
+	Response PactResponse `json:"response"`
+}
+
+// PactProviderState is a provider state of a v3 Pact file.
+type PactProviderState struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// States returns the names of the provider states of the interaction, for
+// either a v2 or v3 Pact file.
+func (i PactInteraction) States() []string {
+	var states []string
+	if i.ProviderState != "" {
+		states = append(states, i.ProviderState)
+	}
+	for _, state := range i.ProviderStates {
+		states = append(states, state.Name)
+	}
+	return states
+}
+
+// PactRequest is the request of a PactInteraction.
+type PactRequest struct {
+	Method        string                 `json:"method"`
+	Path          string                 `json:"path"`
+	Query         PactQuery              `json:"query,omitempty"`
+	Headers       map[string]string      `json:"headers,omitempty"`
+	Body          interface{}            `json:"body,omitempty"`
+	MatchingRules map[string]interface{} `json:"matchingRules,omitempty"`
+	Generators    map[string]interface{} `json:"generators,omitempty"`
+}
+
+// PactResponse is the response of a PactInteraction.
+type PactResponse struct {
+	Status        int                    `json:"status"`
+	Headers       map[string]string      `json:"headers,omitempty"`
+	Body          interface{}            `json:"body,omitempty"`
+	MatchingRules map[string]interface{} `json:"matchingRules,omitempty"`
+	Generators    map[string]interface{} `json:"generators,omitempty"`
+}
+
+// PactQuery is the query string of a PactRequest. It is read from either
+// the string form of a v2 Pact file, or the map form of a v3 Pact file, and
+// written in the string form.
+type PactQuery map[string][]string
+
+// UnmarshalJSON reads the query from a v2 or v3 Pact file.
+func (q *PactQuery) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	query := PactQuery{}
+	switch t := raw.(type) {
+	case nil:
+	case string:
+		values, err := url.ParseQuery(t)
+		if err != nil {
+			return err
+		}
+		for k, v := range values {
+			query[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range t {
+			switch values := v.(type) {
+			case string:
+				query[k] = []string{values}
+			case []interface{}:
+				for _, value := range values {
+					query[k] = append(query[k], fmt.Sprintf("%v", value))
+				}
+			default:
+				return fmt.Errorf("invalid value for query parameter '%s': %v", k, v)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid query: %s", data)
+	}
+
+	*q = query
+	return nil
+}
+
+// MarshalJSON writes the query in the string form of a v2 Pact file. A
+// PactFile of v3 of the specification writes its queries in the map form.
+func (q PactQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.String())
+}
+
+// String encodes the query, sorted by key.
+func (q PactQuery) String() string {
+	var keys []string
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			params = append(params, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// LoadPactFile reads a Pact file from a local path or http(s) URL.
+func LoadPactFile(file string) (*PactFile, error) {
+	return loadPactFile(file, "", "")
+}
+
+// loadPactFile reads a Pact file, fetching a URL with the credentials of a
+// Pact Broker if given.
+func loadPactFile(file string, username string, password string) (*PactFile, error) {
+	log.Println("[DEBUG] loading pact file:", file)
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(file, "http") {
+		var req *http.Request
+		if req, err = http.NewRequest("GET", file, nil); err != nil {
+			return nil, err
+		}
+		if username != "" && password != "" {
+			req.SetBasicAuth(username, password)
+		}
+		var res *http.Response
+		res, err = http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err == nil && (res.StatusCode < 200 || res.StatusCode >= 300) {
+			err = fmt.Errorf("unable to fetch pact file %s: %s", file, res.Status)
+		}
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pact PactFile
+	if err = json.Unmarshal(data, &pact); err != nil {
+		return nil, fmt.Errorf("unable to parse pact file %s: %v", file, err)
+	}
+
+	if pact.Consumer.Name == "" || pact.Provider.Name == "" {
+		return nil, errors.New("Invalid Pact file - cannot find the Consumer and Provider name")
+	}
+
+	return &pact, nil
+}
+
+// LoadPactFiles reads the Pact files at the given paths, directories, glob
+// patterns or URLs.
+func LoadPactFiles(urls []string) ([]*PactFile, error) {
+	expanded, err := expandPactURLs(urls)
+	if err != nil {
+		return nil, err
+	}
+
+	var pacts []*PactFile
+	for _, file := range expanded {
+		pact, err := LoadPactFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pacts = append(pacts, pact)
+	}
+
+	return pacts, nil
+}
+
+// AddInteraction adds a DSL Interaction to the Pact file, converting its
+// Matchers into example values and v2 matching rules. Structured bodies,
+// such as XML, form and multipart bodies, are written as their example along
+// with their matching rules.
+func (p *PactFile) AddInteraction(i Interaction) error {
+	interaction := PactInteraction{
+		Description:   i.Description,
+		ProviderState: i.State,
+		Request: PactRequest{
+			Method: strings.ToUpper(i.Request.Method),
+		},
+		Response: PactResponse{
+			Status: i.Response.Status,
+		},
+	}
+
+	if i.Request.Path == nil {
+		return errors.New("the request path of an interaction is mandatory")
+	}
+
+	request := map[string]interface{}{}
+	path, err := extractMatchers([]string{"path"}, i.Request.Path, request)
+	if err != nil {
+		return err
+	}
+	interaction.Request.Path = fmt.Sprintf("%v", path)
+	if template, ok := i.Request.Path.(*PathMatcher); ok && template.expression() != "" {
+		interaction.Request.Generators = map[string]interface{}{
+			"path": map[string]interface{}{"type": "ProviderState", "expression": template.expression()},
+		}
+	}
+
+	if len(i.Request.Query) > 0 {
+		interaction.Request.Query = PactQuery{}
+		for name, value := range i.Request.Query {
+			example, err := extractMatchers([]string{"query", name}, value, request)
+			if err != nil {
+				return err
+			}
+			interaction.Request.Query[name] = queryValues(example)
+		}
+	}
+
+	if interaction.Request.Headers, err = extractHeaders(i.Request.Headers, request); err != nil {
+		return err
+	}
+	if i.Request.Body != nil {
+		if interaction.Request.Body, err = extractBody([]string{"body"}, i.Request.Body, request); err != nil {
+			return err
+		}
+	}
+
+	response := map[string]interface{}{}
+	if interaction.Response.Headers, err = extractHeaders(i.Response.Headers, response); err != nil {
+		return err
+	}
+	if i.Response.Body != nil {
+		if interaction.Response.Body, err = extractBody([]string{"body"}, i.Response.Body, response); err != nil {
+			return err
+		}
+	}
+
+	if len(request) > 0 {
+		interaction.Request.MatchingRules = request
+	}
+	if len(response) > 0 {
+		interaction.Response.MatchingRules = response
+	}
+
+	p.Interactions = append(p.Interactions, interaction)
+	return nil
+}
+
+// addBodyRules adds the matching rules of the structured bodies of the DSL
+// interactions, such as XMLElement and FormBody, to the Pact file written by
+// the mock service, which only knows their examples. Interactions are paired
+// by description and provider state.
+func (p *PactFile) addBodyRules(interactions []*Interaction) {
+	v3 := p.SpecificationVersion() >= 3
+	for _, i := range interactions {
+		for n := range p.Interactions {
+			interaction := &p.Interactions[n]
+			if interaction.Description != i.Description || !hasState(interaction.States(), i.State) {
+				continue
+			}
+			interaction.Request.MatchingRules = mergeBodyRules(interaction.Request.MatchingRules, i.Request.Body, v3)
+			interaction.Response.MatchingRules = mergeBodyRules(interaction.Response.MatchingRules, i.Response.Body, v3)
+		}
+	}
+}
+
+// hasState reports whether the provider states of a PactInteraction are the
+// single state of a DSL Interaction, or none if it has no state.
+func hasState(states []string, state string) bool {
+	if state == "" {
+		return len(states) == 0
+	}
+	return len(states) == 1 && states[0] == state
+}
+
+// mergeBodyRules adds the matching rules of a structured body to the rules
+// of a request or response, in the v2 or v3 form.
+func mergeBodyRules(rules map[string]interface{}, body interface{}, v3 bool) map[string]interface{} {
+	b, ok := body.(structuredBody)
+	if !ok {
+		return rules
+	}
+	added := map[string]interface{}{}
+	b.bodyRules([]string{"body"}, added)
+	if len(added) == 0 {
+		return rules
+	}
+
+	if rules == nil {
+		rules = map[string]interface{}{}
+	}
+	if !v3 {
+		for path, rule := range added {
+			rules[path] = rule
+		}
+		return rules
+	}
+
+	category, ok := rules["body"].(map[string]interface{})
+	if !ok {
+		category = map[string]interface{}{}
+		rules["body"] = category
+	}
+	for path, rule := range added {
+		category["$"+strings.TrimPrefix(path, "$.body")] = map[string]interface{}{"matchers": []interface{}{rule}}
+	}
+	return rules
+}
+
+// SpecificationVersion returns the major version of the Pact specification
+// given by the Metadata, or 2 if there is none.
+func (p *PactFile) SpecificationVersion() int {
+	var version interface{}
+	for _, key := range []string{"pactSpecification", "pact-specification"} {
+		if spec, ok := p.Metadata[key].(map[string]interface{}); ok {
+			version = spec["version"]
+			break
+		}
+	}
+
+	var major int
+	if _, err := fmt.Sscanf(fmt.Sprintf("%v", version), "%d", &major); err != nil || major == 0 {
+		return 2
+	}
+	return major
+}
+
+// MarshalJSON writes the Pact file, with queries in the map form of v3 of
+// the specification, e.g. {"tag": ["a", "b"]}, if its Metadata is for v3.
+func (p *PactFile) MarshalJSON() ([]byte, error) {
+	type pactFile PactFile
+	if p.SpecificationVersion() < 3 {
+		return json.Marshal((*pactFile)(p))
+	}
+
+	type v3Request struct {
+		PactRequest
+		Query map[string][]string `json:"query,omitempty"`
+	}
+	type v3Interaction struct {
+		PactInteraction
+		Request v3Request `json:"request"`
+	}
+	interactions := make([]v3Interaction, len(p.Interactions))
+	for i, interaction := range p.Interactions {
+		interactions[i] = v3Interaction{
+			PactInteraction: interaction,
+			Request:         v3Request{PactRequest: interaction.Request, Query: interaction.Request.Query},
+		}
+	}
+
+	return json.Marshal(struct {
+		*pactFile
+		Interactions []v3Interaction `json:"interactions,omitempty"`
+	}{(*pactFile)(p), interactions})
+}
+
+// Write writes the Pact file, as v2 of the specification unless the
+// Metadata says otherwise.
+func (p *PactFile) Write(file string) error {
+	if p.Metadata == nil {
+		p.Metadata = map[string]interface{}{
+			"pactSpecification": map[string]interface{}{"version": "2.0.0"},
+		}
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	log.Println("[DEBUG] writing pact file:", file)
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// queryValues converts the example of a query parameter, which is an array
+// for QueryValues and EachLike, to its values.
+func queryValues(example interface{}) []string {
+	examples, ok := example.([]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%v", example)}
+	}
+
+	values := make([]string, len(examples))
+	for i, v := range examples {
+		values[i] = fmt.Sprintf("%v", v)
+	}
+	return values
+}
+
+// extractHeaders extracts the example values and matching rules of headers.
+func extractHeaders(headers MapMatcher, rules map[string]interface{}) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	examples := make(map[string]string, len(headers))
+	for name, value := range headers {
+		example, err := extractMatchers([]string{"headers", name}, value, rules)
+		if err != nil {
+			return nil, err
+		}
+		examples[name] = fmt.Sprintf("%v", example)
+	}
+	return examples, nil
+}
+
+// extractMatchers replaces the Matchers in a value with their example
+// values, adding their matching rules, keyed by path.
+func extractMatchers(path []string, value interface{}, rules map[string]interface{}) (interface{}, error) {
+	generic, err := decodeGeneric(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractGeneric(path, generic, rules), nil
+}
+
+// decodeGeneric converts a value of the DSL, such as a body containing
+// Matchers, to its decoded JSON form.
+func decodeGeneric(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// extractGeneric walks a decoded JSON value, replacing the Ruby style
+// matchers written by the DSL with their examples.
+func extractGeneric(path []string, value interface{}, rules map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch v["json_class"] {
+		case "Pact::SomethingLike":
+			rules[formatPath(path)] = map[string]interface{}{"match": "type"}
+			return extractGeneric(path, v["contents"], rules)
+		case "Pact::ArrayLike":
+			min := 1
+			if n, ok := number(v["min"]); ok && n > 1 {
+				min = int(n)
+			}
+			rules[formatPath(path)] = map[string]interface{}{"match": "type", "min": min}
+			example := extractGeneric(child(path, "[*]"), v["contents"], rules)
+			examples := make([]interface{}, min)
+			for i := range examples {
+				examples[i] = example
+			}
+			return examples
+		case "Pact::Term":
+			data, _ := v["data"].(map[string]interface{})
+			matcher, _ := data["matcher"].(map[string]interface{})
+			rules[formatPath(path)] = map[string]interface{}{"match": "regex", "regex": matcher["s"]}
+			return data["generate"]
+		}
+
+		for k, value := range v {
+			v[k] = extractGeneric(child(path, k), value, rules)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = extractGeneric(child(path, fmt.Sprintf("[%d]", i)), value, rules)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -1,9 +1,15 @@
 package dsl
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -108,6 +114,118 @@ func TestPact_WritePact(t *testing.T) {
 	}
 }
 
+func TestPact_WritePactBodyRules(t *testing.T) {
+	cases := []struct {
+		version  string
+		expected map[string]interface{}
+	}{
+		{"2.0.0", map[string]interface{}{
+			"$.body.user.@id": map[string]interface{}{"match": "type"},
+		}},
+		{"3.0.0", map[string]interface{}{
+			"body": map[string]interface{}{
+				"$.user.@id": map[string]interface{}{"matchers": []interface{}{map[string]interface{}{"match": "type"}}},
+			},
+		}},
+	}
+
+	for _, c := range cases {
+		ms := setupMockServer(true, t)
+		dir, err := ioutil.TempDir("", "pactgo")
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		pact := &Pact{
+			Server:   &types.MockServer{Port: getPort(ms.URL)},
+			Consumer: "My Consumer",
+			Provider: "My Provider",
+			PactDir:  dir,
+		}
+		pact.
+			AddInteraction().
+			Given("Some state").
+			UponReceiving("Some name for the test").
+			WithRequest(Request{Method: "GET", Path: String("/user")}).
+			WillRespondWith(Response{Status: 200, Body: XML("user").Attribute("id", Like("1"))})
+		if err = pact.Verify(func() error { return nil }); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		// The mock service writes the examples, without the XML rules
+		content := fmt.Sprintf(`{"consumer": {"name": "My Consumer"}, "provider": {"name": "My Provider"},
+			"interactions": [{"description": "Some name for the test", "providerState": "Some state",
+			"request": {"method": "get", "path": "/user"}, "response": {"status": 200, "body": "<user id=\"1\"></user>"}}],
+			"metadata": {"pactSpecification": {"version": "%s"}}}`, c.version)
+		file := filepath.Join(dir, "my_consumer-my_provider.json")
+		if err = ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if err = pact.WritePact(); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		written, err := LoadPactFile(file)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if rules := written.Interactions[0].Response.MatchingRules; !reflect.DeepEqual(rules, c.expected) {
+			t.Fatalf("Expected the rules %v of v%s but got %v", c.expected, c.version, rules)
+		}
+
+		ms.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPact_WritePactV3Query(t *testing.T) {
+	ms := setupMockServer(true, t)
+	defer ms.Close()
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pact := &Pact{
+		Server:               &types.MockServer{Port: getPort(ms.URL)},
+		Consumer:             "My Consumer",
+		Provider:             "My Provider",
+		PactDir:              dir,
+		SpecificationVersion: 3,
+	}
+
+	// The mock service writes the query as a string
+	file := filepath.Join(dir, "my_consumer-my_provider.json")
+	content := `{"consumer": {"name": "My Consumer"}, "provider": {"name": "My Provider"},
+		"interactions": [{"description": "a request for posts", "request": {"method": "get", "path": "/posts", "query": "tag=go&tag=pact"},
+		"response": {"status": 200}}], "metadata": {"pactSpecification": {"version": "3.0.0"}}}`
+	if err = ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err = pact.WritePact(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var written struct {
+		Interactions []struct {
+			Request struct {
+				Query map[string][]string `json:"query"`
+			} `json:"request"`
+		} `json:"interactions"`
+	}
+	if err = json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Expected the query in the map form but got %s: %v", data, err)
+	}
+	if tags := written.Interactions[0].Request.Query["tag"]; !reflect.DeepEqual(tags, []string{"go", "pact"}) {
+		t.Fatalf("Expected the tags [go pact] but got %v", tags)
+	}
+}
+
 func TestPact_WritePactFail(t *testing.T) {
 	ms := setupMockServer(false, t)
 	defer ms.Close()
@@ -154,6 +272,71 @@ func TestPact_VerifyFail(t *testing.T) {
 	}
 }
 
+func TestPact_VerifyStructuredBodyProxy(t *testing.T) {
+	ms := setupMockServer(true, t)
+	defer ms.Close()
+	port := getPort(ms.URL)
+
+	pact := &Pact{Server: &types.MockServer{Port: port}}
+	pact.
+		AddInteraction().
+		UponReceiving("A JSON request").
+		WithRequest(Request{Method: "GET", Path: String("/users")}).
+		WillRespondWith(Response{Status: 200})
+	if err := pact.Verify(func() error { return nil }); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if pact.proxy != nil || pact.Server.Port != port {
+		t.Fatalf("Expected no proxy without structured bodies, but the mock server is on port %d", pact.Server.Port)
+	}
+
+	var proxied string
+	pact.
+		AddInteraction().
+		UponReceiving("A login").
+		WithRequest(Request{Method: "POST", Path: String("/login"), Body: Form(MapMatcher{"username": Like("billy")})}).
+		WillRespondWith(Response{Status: 200})
+	err := pact.Verify(func() error {
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d/users", pact.Server.Port))
+		if err != nil {
+			return err
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		proxied = string(body)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer pact.proxy.Stop()
+
+	if pact.Server.Port == port || proxied != "Hello, client\n" {
+		t.Fatalf("Expected the mock server to be behind the proxy on another port but got port %d and %q", pact.Server.Port, proxied)
+	}
+}
+
+func TestPact_VerifyStructuredBodyProxyFail(t *testing.T) {
+	ms := setupMockServer(true, t)
+	defer ms.Close()
+	port := getPort(ms.URL)
+
+	pact := &Pact{Server: &types.MockServer{Port: port}, AllowedMockServerPorts: strconv.Itoa(port)}
+	pact.
+		AddInteraction().
+		UponReceiving("A login").
+		WithRequest(Request{Method: "POST", Path: String("/login"), Body: Form(MapMatcher{"username": Like("billy")})}).
+		WillRespondWith(Response{Status: 200})
+
+	err := pact.Verify(func() error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "unable to start the mock service proxy") {
+		t.Fatalf("Expected the proxy to fail to start but got %v", err)
+	}
+	if pact.Server.Port != port {
+		t.Fatalf("Expected the mock server to stay on port %d but got %d", port, pact.Server.Port)
+	}
+}
+
 func TestPact_Setup(t *testing.T) {
 	pact := &Pact{LogLevel: "DEBUG"}
 	defer stubPorts()()
@@ -176,6 +359,7 @@ func TestPact_SetupWithMockServerPort(t *testing.T) {
 	pact := &Pact{LogLevel: "DEBUG", AllowedMockServerPorts: "32768", pactClient: c}
 	defer stubPorts()()
 	pact.Setup(true)
+	defer pact.Teardown()
 
 	if pact.Server == nil {
 		t.Fatalf("Expected server to be created")
@@ -190,6 +374,7 @@ func TestPact_SetupWithMockServerPortCSV(t *testing.T) {
 	defer stubPorts()()
 	pact := &Pact{LogLevel: "DEBUG", AllowedMockServerPorts: "32768,32769", pactClient: c}
 	pact.Setup(true)
+	defer pact.Teardown()
 
 	if pact.Server == nil {
 		t.Fatalf("Expected server to be created")
@@ -204,6 +389,7 @@ func TestPact_SetupWithMockServerPortRange(t *testing.T) {
 	defer stubPorts()()
 	pact := &Pact{LogLevel: "DEBUG", AllowedMockServerPorts: "32768-32770", pactClient: c}
 	pact.Setup(true)
+	defer pact.Teardown()
 	if pact.Server == nil {
 		t.Fatalf("Expected server to be created")
 	}
@@ -301,6 +487,39 @@ func TestPact_VerifyProviderBroker(t *testing.T) {
 	}
 }
 
+func TestPact_VerifyProviderPublishLocalPact(t *testing.T) {
+	c, _ := createClient(true)
+	defer stubPorts()()
+
+	pact := &Pact{LogLevel: "DEBUG", pactClient: c}
+	_, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL:            "http://www.foo.com",
+		PactURLs:                   []string{"foo.json"},
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+	})
+
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestPact_VerifyProviderPublishNoVersion(t *testing.T) {
+	c, _ := createClient(true)
+	defer stubPorts()()
+
+	pact := &Pact{LogLevel: "DEBUG", pactClient: c}
+	_, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL:            "http://www.foo.com",
+		PactURLs:                   []string{"foo.json"},
+		PublishVerificationResults: true,
+	})
+
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
 func TestPact_VerifyProviderBrokerNoConsumers(t *testing.T) {
 	s := setupMockBroker(false)
 	defer s.Close()
@@ -317,6 +536,56 @@ func TestPact_VerifyProviderBrokerNoConsumers(t *testing.T) {
 	}
 }
 
+func TestPact_VerifyProviderRawPending(t *testing.T) {
+	c, _ := createClient(true)
+	defer stubPorts()()
+
+	pact := &Pact{LogLevel: "DEBUG", pactClient: c}
+	res, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL: "http://www.foo.com",
+		PactURLs:        []string{"foo.json"},
+		PendingPactURLs: []string{"bar.json"},
+	})
+
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if res.SummaryLine != "0 examples, 0 failures, 0 pending" {
+		t.Fatalf("Expected a combined summary line but got '%s'", res.SummaryLine)
+	}
+}
+
+func TestPact_VerifyProviderRawPendingOnly(t *testing.T) {
+	c, _ := createClient(true)
+	defer stubPorts()()
+
+	pact := &Pact{LogLevel: "DEBUG", pactClient: c}
+	_, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL: "http://www.foo.com",
+		PendingPactURLs: []string{"bar.json"},
+	})
+
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestPact_VerifyProviderRawPendingFail(t *testing.T) {
+	c, _ := createClient(false)
+	defer stubPorts()()
+
+	pact := &Pact{LogLevel: "DEBUG", pactClient: c}
+	_, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL: "http://www.foo.com",
+		PendingPactURLs: []string{"bar.json"},
+	})
+
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
 func TestPact_VerifyProviderRawFail(t *testing.T) {
 	c, _ := createClient(false)
 	defer stubPorts()()
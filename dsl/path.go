@@ -0,0 +1,163 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PathMatcher matches a request path built from a template, such as
+// "/users/{id}/orders/{orderId}", whose parameters are Matchers. Use
+// PathTemplate to create one, e.g.
+//
+//	Path: dsl.PathTemplate("/users/{id}/orders/{orderId}", map[string]dsl.Matcher{
+//		"id":      dsl.Like(10),
+//		"orderId": dsl.UUID(),
+//	})
+//
+// It is sent to the mock service as a Term of the example path (e.g.
+// "/users/10/orders/fc763eba-0905-41c5-a27f-3934ab26786c") and a regex
+// built from the template, in which a Term parameter matches its regex, a
+// Like number parameter any number, and any other parameter any segment.
+type PathMatcher struct {
+	template  string
+	params    map[string]Matcher
+	fromState []string
+}
+
+// pathParameter matches the parameters of a path template, e.g. "{id}".
+var pathParameter = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// PathTemplate creates a PathMatcher. Parameters of the template without a
+// Matcher match any segment, with the name of the parameter as the example.
+func PathTemplate(template string, params map[string]Matcher) *PathMatcher {
+	return &PathMatcher{template: template, params: params}
+}
+
+// FromProviderState marks parameters whose values are given by the provider
+// state, such as the ID of a user it created. When the path is written with
+// PactFile.AddInteraction, a v3 ProviderState generator is added for it, e.g.
+// {"type": "ProviderState", "expression": "/users/${id}"}, so the provider
+// verifier can replace the example with the value from the state.
+func (p *PathMatcher) FromProviderState(names ...string) *PathMatcher {
+	p.fromState = append(p.fromState, names...)
+	return p
+}
+
+func (p *PathMatcher) isMatcher() {}
+
+// GetValue returns the example path.
+func (p *PathMatcher) GetValue() interface{} {
+	example, _ := p.build(nil)
+	return example
+}
+
+// Regex returns the regex matching the path.
+func (p *PathMatcher) Regex() string {
+	_, regex := p.build(nil)
+	return regex
+}
+
+// MarshalJSON writes the path as a Term.
+func (p *PathMatcher) MarshalJSON() ([]byte, error) {
+	example, regex := p.build(nil)
+	return json.Marshal(Term(example, regex))
+}
+
+// expression returns the provider state expression of the path, or "" if
+// no parameters are from the provider state.
+func (p *PathMatcher) expression() string {
+	if len(p.fromState) == 0 {
+		return ""
+	}
+
+	fromState := map[string]bool{}
+	for _, name := range p.fromState {
+		fromState[name] = true
+	}
+	expression, _ := p.build(fromState)
+	return expression
+}
+
+// build returns the example and regex of the path. Parameters in fromState
+// are written as provider state expressions in the example, e.g. "${id}".
+func (p *PathMatcher) build(fromState map[string]bool) (string, string) {
+	var example, regex []string
+	last := 0
+	for _, match := range pathParameter.FindAllStringSubmatchIndex(p.template, -1) {
+		literal := p.template[last:match[0]]
+		name := p.template[match[2]:match[3]]
+		last = match[1]
+
+		paramExample, paramRegex := pathParameterMatcher(name, p.params[name])
+		if fromState[name] {
+			paramExample = "${" + name + "}"
+		}
+		example = append(example, literal, paramExample)
+		regex = append(regex, regexp.QuoteMeta(literal), "(?:"+paramRegex+")")
+	}
+	example = append(example, p.template[last:])
+	regex = append(regex, regexp.QuoteMeta(p.template[last:]))
+
+	return strings.Join(example, ""), "^" + strings.Join(regex, "") + "$"
+}
+
+// pathParameterMatcher returns the example and regex of a parameter.
+func pathParameterMatcher(name string, m Matcher) (string, string) {
+	const segment = `[^/]+`
+
+	switch m["json_class"] {
+	case "Pact::Term":
+		data, _ := m["data"].(map[string]interface{})
+		matcher, _ := data["matcher"].(map[string]interface{})
+		regex, _ := matcher["s"].(string)
+		return fmt.Sprintf("%v", data["generate"]), unanchored(regex)
+	case "Pact::SomethingLike":
+		if _, ok := number(m["contents"]); ok {
+			return fmt.Sprintf("%v", m["contents"]), `-?\d+(?:\.\d+)?`
+		}
+		return fmt.Sprintf("%v", m["contents"]), segment
+	case nil:
+		return name, segment
+	}
+	return fmt.Sprintf("%v", m.GetValue()), segment
+}
+
+// unanchored removes the anchors at the start and end of a regex, so that it
+// can be part of a larger regex.
+func unanchored(regex string) string {
+	for _, anchor := range []string{"^", `\A`} {
+		regex = strings.TrimPrefix(regex, anchor)
+	}
+	for _, anchor := range []string{"$", `\z`, `\Z`} {
+		if strings.HasSuffix(regex, anchor) && !strings.HasSuffix(regex, `\`+anchor) {
+			regex = strings.TrimSuffix(regex, anchor)
+		}
+	}
+	return regex
+}
+
+// validate checks that the parameters of the template and its Matchers
+// agree.
+func (p *PathMatcher) validate(path []string) []string {
+	inTemplate := map[string]bool{}
+	for _, match := range pathParameter.FindAllStringSubmatch(p.template, -1) {
+		inTemplate[match[1]] = true
+	}
+
+	var problems []string
+	for name := range p.params {
+		if !inTemplate[name] {
+			problems = append(problems, fmt.Sprintf("%s: the parameter %q is not in the template %q", formatPath(path), name, p.template))
+		}
+	}
+	for _, name := range p.fromState {
+		if !inTemplate[name] {
+			problems = append(problems, fmt.Sprintf("%s: the provider state parameter %q is not in the template %q", formatPath(path), name, p.template))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
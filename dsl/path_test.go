@@ -0,0 +1,108 @@
+package dsl
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func orderPath() *PathMatcher {
+	return PathTemplate("/users/{id}/orders/{orderId}.json", map[string]Matcher{
+		"id":      Like(10),
+		"orderId": UUID(),
+	})
+}
+
+func TestPath_PathTemplate(t *testing.T) {
+	if orderPath().GetValue() != "/users/10/orders/fc763eba-0905-41c5-a27f-3934ab26786c.json" {
+		t.Fatalf("Unexpected example: %v", orderPath().GetValue())
+	}
+
+	re, err := compileRegex(orderPath().Regex())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for path, matches := range map[string]bool{
+		"/users/10/orders/fc763eba-0905-41c5-a27f-3934ab26786c.json":     true,
+		"/users/-2/orders/00000000-0000-0000-0000-000000000000.json":     true,
+		"/users/abc/orders/fc763eba-0905-41c5-a27f-3934ab26786c.json":    false,
+		"/users/10/orders/1.json":                                        false,
+		"/users/10/orders/fc763eba-0905-41c5-a27f-3934ab26786c.jsonx":    false,
+		"/api/users/10/orders/fc763eba-0905-41c5-a27f-3934ab26786c.json": false,
+	} {
+		if re.MatchString(path) != matches {
+			t.Fatalf("Expected %s matching to be %v", path, matches)
+		}
+	}
+
+	if err = (&Interaction{Request: Request{Path: PathTemplate("/users/{name}", nil)}}).Validate(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func TestPath_AddInteraction(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request for an order",
+		State:       "an order exists",
+		Request:     Request{Method: "GET", Path: orderPath().FromProviderState("id")},
+		Response:    Response{Status: 200},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	request := pact.Interactions[0].Request
+	if request.Path != "/users/10/orders/fc763eba-0905-41c5-a27f-3934ab26786c.json" {
+		t.Fatalf("Unexpected path: %s", request.Path)
+	}
+	generators := map[string]interface{}{
+		"path": map[string]interface{}{"type": "ProviderState", "expression": "/users/${id}/orders/fc763eba-0905-41c5-a27f-3934ab26786c.json"},
+	}
+	if !reflect.DeepEqual(request.Generators, generators) {
+		t.Fatalf("Expected %v but got %v", generators, request.Generators)
+	}
+
+	req := httptest.NewRequest("GET", "/users/42/orders/00000000-0000-0000-0000-000000000000.json", nil)
+	if mismatches := matchRequest(request, req, nil); len(mismatches) != 0 {
+		t.Fatalf("Expected the path to match but got %v", mismatches)
+	}
+	req = httptest.NewRequest("GET", "/users/42/orders/1.json", nil)
+	if mismatches := matchRequest(request, req, nil); len(mismatches) != 1 || mismatches[0].Path != "$.path" {
+		t.Fatalf("Expected the path not to match but got %v", mismatches)
+	}
+
+	data, err := json.Marshal(Request{Path: orderPath()})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(string(data), `"json_class":"Pact::Term"`) {
+		t.Fatalf("Expected the path to be sent as a Term but got %s", data)
+	}
+}
+
+func TestPath_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		UponReceiving("a request for an order").
+		WithRequest(Request{
+			Method: "GET",
+			Path: PathTemplate("/orders/{id}", map[string]Matcher{
+				"id":   Term("abc", `^\d+$`),
+				"name": Like("billy"),
+			}).FromProviderState("userId"),
+		}).
+		WillRespondWith(Response{Status: 200})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 3 || !strings.HasPrefix(problems[0], "$.request.path: example") ||
+		!strings.Contains(problems[1], `parameter "name" is not in the template`) ||
+		!strings.Contains(problems[2], `provider state parameter "userId" is not in the template`) {
+		t.Fatalf("Expected the path to be invalid but got %v", problems)
+	}
+}
@@ -2,33 +2,23 @@ package dsl
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pact-foundation/pact-go/types"
+	"github.com/pact-foundation/pact-go/utils"
 )
 
-// PactFile is a simple representation of a Pact file to be able to
-// parse Consumer/Provider from the file.
-type PactFile struct {
-	// The API Consumer name
-	Consumer PactName `json:"consumer"`
-
-	// The API Provider name
-	Provider PactName `json:"provider"`
-}
-
-// PactName represents the name fields in the PactFile.
-type PactName struct {
-	Name string `json:"name"`
-}
-
 // Publisher is the API to send Pact files to a Pact Broker.
 type Publisher struct {
 	request types.PublishRequest
@@ -47,15 +37,15 @@ func (p *Publisher) validate() error {
 	// Validate that the files exist on the system
 	var err error
 	for _, url := range p.request.PactURLs {
-		// Only check local files
-		if !strings.HasPrefix(url, "http") {
+		// Only check local files, glob patterns are checked when expanded
+		if !strings.HasPrefix(url, "http") && !isGlob(url) {
 			if _, err = os.Stat(url); err != nil {
 				return err
 			}
 		}
 	}
 
-	if p.request.PactBroker == "" {
+	if p.request.PactBroker == "" && !p.request.DryRun {
 		return errors.New("PactBroker is mandatory")
 	}
 
@@ -72,6 +62,12 @@ func (p *Publisher) validate() error {
 
 // call sends a message to the Pact Broker.
 func (p *Publisher) call(method string, url string, content []byte) error {
+	_, err := p.do(method, url, content)
+	return err
+}
+
+// do sends a message to the Pact Broker, returning the response body.
+func (p *Publisher) do(method string, url string, content []byte) ([]byte, error) {
 	if p.client == nil {
 		p.client = &http.Client{}
 	}
@@ -79,10 +75,11 @@ func (p *Publisher) call(method string, url string, content []byte) error {
 	var err error
 	req, err = http.NewRequest(method, url, bytes.NewReader(content))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/hal+json, application/json")
 
 	if p.request.BrokerUsername != "" && p.request.BrokerPassword != "" {
 		req.SetBasicAuth(p.request.BrokerUsername, p.request.BrokerPassword)
@@ -90,16 +87,157 @@ func (p *Publisher) call(method string, url string, content []byte) error {
 
 	res, err := p.client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	responseBody, err := ioutil.ReadAll(res.Body)
 	res.Body.Close()
 	log.Printf("[DEBUG] pact publisher response Body: %s\n", responseBody)
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return errors.New(string(responseBody))
+		return nil, errors.New(string(responseBody))
 	}
-	return err
+	return responseBody, err
+}
+
+// PactSource is a Pact file to be published, as found by FindPacts.
+type PactSource struct {
+	// URL is the local path or remote URL of the Pact file.
+	URL string
+
+	// Consumer is the name of the consumer in the Pact file.
+	Consumer string
+
+	// Provider is the name of the provider in the Pact file.
+	Provider string
+}
+
+// pactSource is a Pact file read ready for publishing.
+type pactSource struct {
+	url  string
+	file *PactFile
+	data []byte
+}
+
+// FindPacts returns the Pact files the request would publish, after
+// expanding directories and glob patterns, removing duplicates and
+// filtering by consumer and provider.
+func (p *Publisher) FindPacts(request types.PublishRequest) ([]PactSource, error) {
+	p.request = request
+	pacts, err := p.findPacts()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]PactSource, len(pacts))
+	for i, pact := range pacts {
+		sources[i] = PactSource{
+			URL:      pact.url,
+			Consumer: pact.file.Consumer.Name,
+			Provider: pact.file.Provider.Name,
+		}
+	}
+	return sources, nil
+}
+
+// findPacts reads each of the Pact files in the request.
+func (p *Publisher) findPacts() ([]pactSource, error) {
+	urls, err := expandPactURLs(p.request.PactURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	var pacts []pactSource
+	for _, url := range urls {
+		file, data, err := p.readPactFile(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matchesName(file.Consumer.Name, p.request.Consumers) || !matchesName(file.Provider.Name, p.request.Providers) {
+			log.Println("[DEBUG] pact publisher: skipping filtered Pact file", url)
+			continue
+		}
+
+		pacts = append(pacts, pactSource{url: url, file: file, data: data})
+	}
+
+	if len(pacts) == 0 {
+		return nil, errors.New("no Pact files found to publish")
+	}
+
+	return pacts, nil
+}
+
+// expandPactURLs expands directories and glob patterns into the Pact files
+// they contain, removing any duplicates.
+func expandPactURLs(urls []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+
+	add := func(url string) {
+		key := url
+		if !strings.HasPrefix(url, "http") {
+			if abs, err := filepath.Abs(url); err == nil {
+				key = abs
+			}
+		}
+		if !seen[key] {
+			seen[key] = true
+			expanded = append(expanded, url)
+		}
+	}
+
+	for _, url := range urls {
+		if strings.HasPrefix(url, "http") {
+			add(url)
+			continue
+		}
+
+		pattern := url
+		if !isGlob(url) {
+			info, err := os.Stat(url)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(url)
+				continue
+			}
+			pattern = filepath.Join(url, "*.json")
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no Pact files found matching %s", url)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			add(match)
+		}
+	}
+
+	return expanded, nil
+}
+
+// isGlob reports whether the path is a glob pattern.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// matchesName reports whether the name is in the filter, or the filter is empty.
+func matchesName(name string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, n := range filter {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
 
 // readPactFile reads Pact files from local or remote sources.
@@ -156,25 +294,171 @@ func (p *Publisher) readRemotePactFile(file string) (*PactFile, []byte, error) {
 	return f, data, err
 }
 
-// Publish sends the Pacts to a broker, optionally tagging them
+// publishContractsRequest is the body sent to the publish contracts API of
+// a Pact Broker.
+type publishContractsRequest struct {
+	PacticipantName          string            `json:"pacticipantName"`
+	PacticipantVersionNumber string            `json:"pacticipantVersionNumber"`
+	Branch                   string            `json:"branch,omitempty"`
+	Tags                     []string          `json:"tags,omitempty"`
+	BuildURL                 string            `json:"buildUrl,omitempty"`
+	Contracts                []publishContract `json:"contracts"`
+}
+
+// publishContract is a single Pact file in a publishContractsRequest.
+type publishContract struct {
+	ConsumerName  string `json:"consumerName"`
+	ProviderName  string `json:"providerName"`
+	Specification string `json:"specification"`
+	ContentType   string `json:"contentType"`
+	Content       string `json:"content"`
+}
+
+// publishContractsResponse is a subset of the response from the publish
+// contracts API of a Pact Broker.
+type publishContractsResponse struct {
+	Notices []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"notices"`
+}
+
+// Publish sends the Pacts to a broker, optionally tagging them.
+//
+// Where the broker supports it, all of the Pacts for a consumer are published
+// in a single request to the publish contracts API, along with the Branch,
+// Tags and BuildURL. Older brokers are sent each Pact, tag and branch separately.
 func (p *Publisher) Publish(request types.PublishRequest) error {
 	log.Println("[DEBUG] pact publisher: publish pact")
+
+	if request.AutoDetectVersionProperties {
+		props := utils.DetectVersionProperties()
+		log.Printf("[DEBUG] pact publisher: detected version properties %+v\n", props)
+		if request.ConsumerVersion == "" {
+			request.ConsumerVersion = props.Commit
+		}
+		if request.Branch == "" {
+			request.Branch = props.Branch
+		}
+		if request.BuildURL == "" {
+			request.BuildURL = props.BuildURL
+		}
+	}
 	p.request = request
 
-	for _, url := range request.PactURLs {
-		file, data, err := p.readPactFile(url)
-		if err != nil {
-			return err
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	pacts, err := p.findPacts()
+	if err != nil {
+		return err
+	}
+
+	if request.DryRun {
+		for _, pact := range pacts {
+			log.Printf("[INFO] pact publisher: dry run, would publish %s (%s -> %s) as version %s\n", pact.url, pact.file.Consumer.Name, pact.file.Provider.Name, request.ConsumerVersion)
 		}
+		return nil
+	}
+
+	if endpoint := p.publishContractsEndpoint(); endpoint != "" {
+		return p.publishContracts(endpoint, pacts)
+	}
+
+	for _, pact := range pacts {
+		file, data := pact.file, pact.data
 
-		endpoint := fmt.Sprintf("%s/pacts/provider/%s/consumer/%s/version/%s", request.PactBroker, file.Provider.Name, file.Consumer.Name, request.ConsumerVersion)
+		endpoint := fmt.Sprintf("%s/pacts/provider/%s/consumer/%s/version/%s", request.PactBroker, url.PathEscape(file.Provider.Name), url.PathEscape(file.Consumer.Name), url.PathEscape(request.ConsumerVersion))
 		log.Println("[DEBUG] pact publisher: putting Pact on endpoint:", endpoint)
 		err = p.call("PUT", endpoint, data)
 		if err != nil {
 			return err
 		}
 
-		p.tagRequest(file.Consumer.Name, request)
+		if err = p.tagRequest(file.Consumer.Name, request); err != nil {
+			return err
+		}
+
+		if err = p.branchRequest(file.Consumer.Name, request); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishContractsEndpoint finds the publish contracts API from the index of
+// the broker. Returns an empty string if the broker does not support it.
+func (p *Publisher) publishContractsEndpoint() string {
+	body, err := p.do("GET", strings.TrimSuffix(p.request.PactBroker, "/")+"/", nil)
+	if err != nil {
+		log.Println("[DEBUG] pact publisher: unable to read broker index, falling back to publishing each pact:", err)
+		return ""
+	}
+
+	var index struct {
+		Links struct {
+			PublishContracts PactLink `json:"pb:publish-contracts"`
+		} `json:"_links"`
+	}
+	if err = json.Unmarshal(body, &index); err != nil {
+		log.Println("[DEBUG] pact publisher: unable to read broker index, falling back to publishing each pact:", err)
+		return ""
+	}
+
+	return index.Links.PublishContracts.Href
+}
+
+// publishContracts publishes the Pacts of each consumer in a single request.
+func (p *Publisher) publishContracts(endpoint string, pacts []pactSource) error {
+	request := p.request
+	var consumers []string
+	contracts := make(map[string]*publishContractsRequest)
+
+	for _, pact := range pacts {
+		file, data := pact.file, pact.data
+
+		body, ok := contracts[file.Consumer.Name]
+		if !ok {
+			body = &publishContractsRequest{
+				PacticipantName:          file.Consumer.Name,
+				PacticipantVersionNumber: request.ConsumerVersion,
+				Branch:                   request.Branch,
+				Tags:                     request.Tags,
+				BuildURL:                 request.BuildURL,
+			}
+			contracts[file.Consumer.Name] = body
+			consumers = append(consumers, file.Consumer.Name)
+		}
+
+		body.Contracts = append(body.Contracts, publishContract{
+			ConsumerName:  file.Consumer.Name,
+			ProviderName:  file.Provider.Name,
+			Specification: "pact",
+			ContentType:   "application/json",
+			Content:       base64.StdEncoding.EncodeToString(data),
+		})
+	}
+
+	for _, consumer := range consumers {
+		content, err := json.Marshal(contracts[consumer])
+		if err != nil {
+			return err
+		}
+
+		log.Println("[DEBUG] pact publisher: publishing contracts for consumer:", consumer)
+		res, err := p.do("POST", endpoint, content)
+		if err != nil {
+			return err
+		}
+
+		var doc publishContractsResponse
+		if err = json.Unmarshal(res, &doc); err == nil {
+			for _, notice := range doc.Notices {
+				log.Printf("[INFO] pact publisher: %s\n", notice.Text)
+			}
+		}
 	}
 
 	return nil
@@ -184,7 +468,7 @@ func (p *Publisher) Publish(request types.PublishRequest) error {
 func (p *Publisher) tagRequest(consumerName string, request types.PublishRequest) error {
 	log.Println("[DEBUG] pact publisher: tagging pacts...")
 	for _, tag := range request.Tags {
-		endpoint := fmt.Sprintf("%s/pacticipants/%s/versions/%s/tags/%s", request.PactBroker, consumerName, request.ConsumerVersion, tag)
+		endpoint := fmt.Sprintf("%s/pacticipants/%s/versions/%s/tags/%s", request.PactBroker, url.PathEscape(consumerName), url.PathEscape(request.ConsumerVersion), url.PathEscape(tag))
 		log.Println("[DEBUG] pact publisher: tagging Pact:", endpoint)
 		err := p.call("PUT", endpoint, []byte{})
 		if err != nil {
@@ -195,6 +479,32 @@ func (p *Publisher) tagRequest(consumerName string, request types.PublishRequest
 	return nil
 }
 
+// branchRequest adds the consumer version to its branch, and records the
+// build URL against the version.
+func (p *Publisher) branchRequest(consumerName string, request types.PublishRequest) error {
+	if request.Branch != "" {
+		endpoint := fmt.Sprintf("%s/pacticipants/%s/branches/%s/versions/%s", request.PactBroker, url.PathEscape(consumerName), url.PathEscape(request.Branch), url.PathEscape(request.ConsumerVersion))
+		log.Println("[DEBUG] pact publisher: adding version to branch:", endpoint)
+		if err := p.call("PUT", endpoint, []byte("{}")); err != nil {
+			return err
+		}
+	}
+
+	if request.BuildURL != "" {
+		endpoint := fmt.Sprintf("%s/pacticipants/%s/versions/%s", request.PactBroker, url.PathEscape(consumerName), url.PathEscape(request.ConsumerVersion))
+		content, err := json.Marshal(map[string]string{"buildUrl": request.BuildURL})
+		if err != nil {
+			return err
+		}
+		log.Println("[DEBUG] pact publisher: setting build URL of version:", endpoint)
+		if err := p.call("PUT", endpoint, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SetClient allows dsl users to configure the http.Client used when publishing Pacts
 func (p *Publisher) SetClient(client *http.Client) {
 	p.client = client
@@ -2,6 +2,7 @@ package dsl
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -522,3 +523,255 @@ func TestPublish_SetClient(t *testing.T) {
 		t.Fatalf("SetClient Failed To Set Client On Publisher")
 	}
 }
+
+func TestPublish_PublishContracts(t *testing.T) {
+	p := &Publisher{}
+	f := createSimplePact(true)
+	g := createSimplePact(true)
+
+	var body publishContractsRequest
+	calls := 0
+	mux := http.NewServeMux()
+	broker := httptest.NewServer(mux)
+	defer broker.Close()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"_links":{"pb:publish-contracts":{"href":"%s/contracts/publish"}}}`, broker.URL)
+	})
+	mux.HandleFunc("/contracts/publish", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Method != "POST" {
+			t.Fatalf("Expected POST but got %s", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		w.Write([]byte(`{"notices":[{"type":"success","text":"Created Some Consumer version 1.0.0"}]}`))
+	})
+
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{f.Name(), g.Name()},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		Branch:          "main",
+		BuildURL:        "http://ci/builds/1",
+		Tags:            []string{"prod"},
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("Expected contracts to be published in 1 request but got %d", calls)
+	}
+	if body.PacticipantName != "Some Consumer" || body.PacticipantVersionNumber != "1.0.0" {
+		t.Fatalf("Unexpected pacticipant: %+v", body)
+	}
+	if body.Branch != "main" || body.BuildURL != "http://ci/builds/1" || len(body.Tags) != 1 {
+		t.Fatalf("Unexpected version properties: %+v", body)
+	}
+	if len(body.Contracts) != 2 {
+		t.Fatalf("Expected 2 contracts but got %d", len(body.Contracts))
+	}
+	content, _ := base64.StdEncoding.DecodeString(body.Contracts[0].Content)
+	if !strings.Contains(string(content), "Some Provider") || body.Contracts[0].ProviderName != "Some Provider" {
+		t.Fatalf("Unexpected contract: %+v", body.Contracts[0])
+	}
+}
+
+func TestPublish_PublishWithBranch(t *testing.T) {
+	p := &Publisher{}
+	f := createSimplePact(true)
+
+	var paths []string
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/" {
+			w.Write([]byte(`{"_links":{}}`))
+		}
+	}))
+	defer broker.Close()
+
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{f.Name()},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		Branch:          "main",
+		BuildURL:        "http://ci/builds/1",
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := []string{
+		"GET /",
+		"PUT /pacts/provider/Some Provider/consumer/Some Consumer/version/1.0.0",
+		"PUT /pacticipants/Some Consumer/branches/main/versions/1.0.0",
+		"PUT /pacticipants/Some Consumer/versions/1.0.0",
+	}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected requests %v but got %v", expected, paths)
+	}
+}
+
+func TestPublish_PublishEscaping(t *testing.T) {
+	p := &Publisher{}
+	f := createSimplePact(true)
+
+	var paths []string
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			paths = append(paths, r.URL.EscapedPath())
+		}
+	}))
+	defer broker.Close()
+
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{f.Name()},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0+build/1",
+		Tags:            []string{"feat/x"},
+		Branch:          "feature/x",
+		BuildURL:        "http://ci/builds/1",
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := []string{
+		"/pacts/provider/Some%20Provider/consumer/Some%20Consumer/version/1.0.0+build%2F1",
+		"/pacticipants/Some%20Consumer/versions/1.0.0+build%2F1/tags/feat%2Fx",
+		"/pacticipants/Some%20Consumer/branches/feature%2Fx/versions/1.0.0+build%2F1",
+		"/pacticipants/Some%20Consumer/versions/1.0.0+build%2F1",
+	}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected requests %v but got %v", expected, paths)
+	}
+}
+
+func TestPublish_PublishTagFail(t *testing.T) {
+	p := &Publisher{}
+	f := createSimplePact(true)
+
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/tags/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("unable to tag"))
+		}
+	}))
+	defer broker.Close()
+
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{f.Name()},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		Tags:            []string{"prod"},
+	})
+
+	if err == nil || err.Error() != "unable to tag" {
+		t.Fatalf("Expected tag error but got: %v", err)
+	}
+}
+
+func createPactDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	pacts := map[string]string{
+		"billy-bobby.json":   `{"consumer":{"name":"billy"},"provider":{"name":"bobby"}}`,
+		"billy-sally.json":   `{"consumer":{"name":"billy"},"provider":{"name":"sally"}}`,
+		"jessica-bobby.json": `{"consumer":{"name":"jessica"},"provider":{"name":"bobby"}}`,
+		"notes.txt":          "not a pact",
+	}
+	for name, content := range pacts {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestPublish_FindPacts(t *testing.T) {
+	dir := createPactDir(t)
+	defer os.RemoveAll(dir)
+
+	p := &Publisher{}
+	pacts, err := p.FindPacts(types.PublishRequest{
+		PactURLs: []string{dir, filepath.Join(dir, "billy-*.json"), filepath.Join(dir, "billy-bobby.json")},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var found []string
+	for _, pact := range pacts {
+		found = append(found, pact.Consumer+"->"+pact.Provider)
+	}
+	if strings.Join(found, ",") != "billy->bobby,billy->sally,jessica->bobby" {
+		t.Fatalf("Expected each pact once, but got %v", found)
+	}
+
+	pacts, err = p.FindPacts(types.PublishRequest{
+		PactURLs:  []string{dir},
+		Consumers: []string{"billy"},
+		Providers: []string{"bobby"},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(pacts) != 1 || pacts[0].URL != filepath.Join(dir, "billy-bobby.json") {
+		t.Fatalf("Expected only the billy-bobby pact, but got %v", pacts)
+	}
+}
+
+func TestPublish_FindPactsFail(t *testing.T) {
+	dir := createPactDir(t)
+	defer os.RemoveAll(dir)
+
+	p := &Publisher{}
+	_, err := p.FindPacts(types.PublishRequest{
+		PactURLs: []string{filepath.Join(dir, "sally-*.json")},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	_, err = p.FindPacts(types.PublishRequest{
+		PactURLs:  []string{dir},
+		Consumers: []string{"sally"},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestPublish_PublishDryRun(t *testing.T) {
+	dir := createPactDir(t)
+	defer os.RemoveAll(dir)
+
+	calls := 0
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer broker.Close()
+
+	p := &Publisher{}
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		DryRun:          true,
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("Expected no calls to the broker but got %d", calls)
+	}
+}
@@ -0,0 +1,368 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pact-foundation/pact-go/utils"
+)
+
+// Recorder is a reverse proxy in front of a real Provider, which records the
+// requests and responses passing through it as draft Interactions. It is
+// intended to bootstrap contracts for existing services: the draft should be
+// reviewed, and given meaningful descriptions and provider states, before it
+// is used.
+type Recorder struct {
+	// Target is the base URL of the Provider, e.g. "http://localhost:8000".
+	Target string
+
+	// Consumer name.
+	Consumer string
+
+	// Provider name.
+	Provider string
+
+	// Host to listen on, defaults to "localhost".
+	Host string
+
+	// Port to listen on. If 0, a free port is chosen.
+	Port int
+
+	// SpecificationVersion of the draft Pact file (2 or 3). Defaults to 2.
+	SpecificationVersion int
+
+	interactions []*Interaction
+	recorded     map[string]bool
+	mu           sync.Mutex
+	proxy        *httputil.ReverseProxy
+	server       *http.Server
+}
+
+// recordedExchange is a request and response captured by the proxy.
+type recordedExchange struct {
+	request      *http.Request
+	requestBody  []byte
+	response     *http.Response
+	responseBody []byte
+}
+
+// Start starts the proxy in the background.
+func (r *Recorder) Start() error {
+	if r.Consumer == "" || r.Provider == "" {
+		return errors.New("Consumer and Provider are mandatory")
+	}
+
+	if err := r.init(); err != nil {
+		return err
+	}
+
+	if r.Host == "" {
+		r.Host = "localhost"
+	}
+
+	if r.Port == 0 {
+		port, err := utils.GetFreePort()
+		if err != nil {
+			return err
+		}
+		r.Port = port
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", r.Host, r.Port))
+	if err != nil {
+		return err
+	}
+	r.server = &http.Server{Handler: r}
+
+	log.Printf("[INFO] recording requests to %s on %s", r.Target, r.URL())
+	go r.server.Serve(listener)
+
+	return nil
+}
+
+// Stop stops the proxy. The recorded Interactions remain available.
+func (r *Recorder) Stop() error {
+	if r.server == nil {
+		return nil
+	}
+	log.Println("[DEBUG] stopping recorder")
+	err := r.server.Close()
+	r.server = nil
+	return err
+}
+
+// URL is the base URL of the running proxy.
+func (r *Recorder) URL() string {
+	return fmt.Sprintf("http://%s:%d", r.Host, r.Port)
+}
+
+// init creates the reverse proxy to the Target.
+func (r *Recorder) init() error {
+	if r.proxy != nil {
+		return nil
+	}
+
+	if r.Target == "" {
+		return errors.New("Target is mandatory")
+	}
+
+	target, err := url.Parse(r.Target)
+	if err != nil {
+		return err
+	}
+
+	r.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := r.proxy.Director
+	r.proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+
+		// Record uncompressed bodies
+		req.Header.Del("Accept-Encoding")
+	}
+	r.recorded = make(map[string]bool)
+
+	return nil
+}
+
+// ServeHTTP proxies the request to the Target, recording the exchange.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := r.init(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	requestBody, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	original := *req.URL
+	header := make(http.Header, len(req.Header))
+	for name, values := range req.Header {
+		header[name] = values
+	}
+
+	proxy := *r.proxy
+	proxy.ModifyResponse = func(res *http.Response) error {
+		responseBody, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+		r.record(recordedExchange{
+			request:      &http.Request{Method: req.Method, URL: &original, Header: header},
+			requestBody:  requestBody,
+			response:     res,
+			responseBody: responseBody,
+		})
+		return nil
+	}
+
+	proxy.ServeHTTP(w, req)
+}
+
+// record adds the exchange as an Interaction, unless an identical request
+// with the same response status has already been recorded.
+func (r *Recorder) record(exchange recordedExchange) {
+	key := fmt.Sprintf("%s %s?%s %s %d", exchange.request.Method, exchange.request.URL.Path,
+		exchange.request.URL.Query().Encode(), exchange.requestBody, exchange.response.StatusCode)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recorded[key] {
+		log.Printf("[DEBUG] recorder skipping duplicate %s %s", exchange.request.Method, exchange.request.URL)
+		return
+	}
+	r.recorded[key] = true
+
+	log.Printf("[INFO] recorded %s %s (%d)", exchange.request.Method, exchange.request.URL, exchange.response.StatusCode)
+	interaction := exchangeInteraction(exchange)
+
+	// Descriptions must be unique within a Pact file
+	description := interaction.Description
+	for n := 2; r.hasDescription(interaction.Description); n++ {
+		interaction.Description = fmt.Sprintf("%s (%d)", description, n)
+	}
+
+	r.interactions = append(r.interactions, interaction)
+}
+
+// hasDescription reports whether an Interaction with the description has
+// been recorded.
+func (r *Recorder) hasDescription(description string) bool {
+	for _, i := range r.interactions {
+		if i.Description == description {
+			return true
+		}
+	}
+	return false
+}
+
+// Interactions returns the Interactions recorded so far.
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*Interaction{}, r.interactions...)
+}
+
+// Draft returns a Pact file containing the recorded Interactions.
+func (r *Recorder) Draft() (*PactFile, error) {
+	pact := &PactFile{
+		Consumer: PactName{Name: r.Consumer},
+		Provider: PactName{Name: r.Provider},
+	}
+	if r.SpecificationVersion > 0 {
+		pact.Metadata = map[string]interface{}{
+			"pactSpecification": map[string]interface{}{"version": fmt.Sprintf("%d.0.0", r.SpecificationVersion)},
+		}
+	}
+
+	for _, i := range r.Interactions() {
+		if err := pact.AddInteraction(*i); err != nil {
+			return nil, err
+		}
+	}
+
+	return pact, nil
+}
+
+// WriteDraft writes the recorded Interactions to a draft Pact file.
+func (r *Recorder) WriteDraft(file string) error {
+	pact, err := r.Draft()
+	if err != nil {
+		return err
+	}
+	return pact.Write(file)
+}
+
+// exchangeInteraction creates an Interaction from a recorded exchange,
+// inferring matchers for JSON bodies. Descriptions are generated from the
+// request, and there is no provider state.
+func exchangeInteraction(exchange recordedExchange) *Interaction {
+	req := exchange.request
+	res := exchange.response
+
+	request := Request{
+		Method: req.Method,
+		Path:   String(req.URL.Path),
+	}
+	if query := req.URL.Query(); len(query) > 0 {
+		request.Query = MapMatcher{}
+		for _, name := range sortedStringKeys(query) {
+			if len(query[name]) == 1 {
+				request.Query[name] = String(query[name][0])
+				continue
+			}
+			values := QueryValues{}
+			for _, value := range query[name] {
+				values = append(values, String(value))
+			}
+			request.Query[name] = values
+		}
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" && len(exchange.requestBody) > 0 {
+		request.Headers = MapMatcher{"Content-Type": String(contentType)}
+	}
+	request.Body = recordedBody(req.Header.Get("Content-Type"), exchange.requestBody)
+
+	response := Response{Status: res.StatusCode}
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		response.Headers = MapMatcher{"Content-Type": String(contentType)}
+	}
+	response.Body = recordedBody(res.Header.Get("Content-Type"), exchange.responseBody)
+
+	description := fmt.Sprintf("a %s request to %s", req.Method, req.URL.Path)
+	if len(req.URL.RawQuery) > 0 {
+		description = fmt.Sprintf("%s?%s", description, req.URL.RawQuery)
+	}
+
+	i := &Interaction{}
+	return i.UponReceiving(description).
+		WithRequest(request).
+		WillRespondWith(response)
+}
+
+// recordedBody returns a JSON body with inferred matchers, a binary body
+// matched by its content type, or a string body.
+func recordedBody(contentType string, body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	if isJSONContentType(contentType) {
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err == nil {
+			return inferMatchers(value)
+		}
+		log.Println("[WARN] recorded body is not valid JSON, recording it as a string")
+	}
+
+	if isBinaryContentType(contentType) {
+		if matchesContentType(body, contentType, nil) {
+			return ContentType(mediaType(contentType)).Example(body)
+		}
+		return Binary(body)
+	}
+
+	return string(body)
+}
+
+var uuidRegex = regexp.MustCompile(`^` + uuid + `$`)
+
+// inferMatchers replaces the values in a decoded JSON body with Matchers:
+// arrays are matched with EachLike using their first element, strings in a
+// common format by regular expression, and other values by type.
+func inferMatchers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		inferred := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			inferred[k] = inferMatchers(child)
+		}
+		return inferred
+	case []interface{}:
+		if len(v) == 0 {
+			return v
+		}
+		return EachLike(inferMatchers(v[0]), 1)
+	case string:
+		switch {
+		case uuidRegex.MatchString(v):
+			return Regex(v, uuid)
+		case isLayout(time.RFC3339, v):
+			return Regex(v, timestamp)
+		case isLayout("2006-01-02", v):
+			return Regex(v, date)
+		}
+		return Like(v)
+	case nil:
+		return nil
+	default:
+		return Like(v)
+	}
+}
+
+// isLayout reports whether the value is a time in the layout.
+func isLayout(layout string, value string) bool {
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
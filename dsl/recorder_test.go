@@ -0,0 +1,211 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func createRecordedProvider() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/1":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id": 1, "uuid": "fc763eba-0905-41c5-a27f-3934ab26786c", "created": "2018-01-01", "roles": ["admin", "user"]}`)
+		case "/users":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, "created %s", body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRecorder_Record(t *testing.T) {
+	provider := createRecordedProvider()
+	defer provider.Close()
+
+	recorder := &Recorder{Target: provider.URL, Consumer: "billy", Provider: "bobby"}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(proxy.URL + "/users/1")
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if !strings.Contains(string(body), `"id": 1`) {
+			t.Fatalf("Expected the provider's response to be proxied but got %s", body)
+		}
+	}
+
+	res, err := http.Post(proxy.URL+"/users", "application/json", strings.NewReader(`{"name": "billy"}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	res.Body.Close()
+
+	interactions := recorder.Interactions()
+	if len(interactions) != 2 {
+		t.Fatalf("Expected duplicate requests to be recorded once, but got %d interactions", len(interactions))
+	}
+
+	draft, err := recorder.Draft()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	get := draft.Interactions[0]
+	if get.Description != "a GET request to /users/1" || get.Response.Status != 200 {
+		t.Fatalf("Unexpected interaction: %+v", get)
+	}
+
+	expectedRules := map[string]string{
+		"$.body.id":       "type",
+		"$.body.uuid":     "regex",
+		"$.body.created":  "regex",
+		"$.body.roles":    "type",
+		"$.body.roles[*]": "type",
+	}
+	for path, match := range expectedRules {
+		rule, _ := get.Response.MatchingRules[path].(map[string]interface{})
+		if rule["match"] != match {
+			t.Fatalf("Expected a %s rule for %s but got %v", match, path, get.Response.MatchingRules)
+		}
+	}
+
+	post := draft.Interactions[1]
+	if post.Request.Headers["Content-Type"] != "application/json" || post.Response.Body != `created {"name": "billy"}` {
+		t.Fatalf("Unexpected interaction: %+v", post)
+	}
+	if _, ok := post.Request.MatchingRules["$.body.name"]; !ok {
+		t.Fatalf("Expected matchers to be inferred for the request body, but got %v", post.Request.MatchingRules)
+	}
+}
+
+func TestRecorder_WriteDraft(t *testing.T) {
+	provider := createRecordedProvider()
+	defer provider.Close()
+
+	recorder := &Recorder{Target: provider.URL, Consumer: "billy", Provider: "bobby"}
+	if err := recorder.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer recorder.Stop()
+
+	for _, path := range []string{"/missing", "/missing?page=2"} {
+		res, err := http.Get(recorder.URL() + path)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		res.Body.Close()
+	}
+
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "draft", "billy-bobby.json")
+	if err = recorder.WriteDraft(file); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	pact, err := LoadPactFile(file)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(pact.Interactions) != 2 || pact.Interactions[1].Request.Query.String() != "page=2" {
+		t.Fatalf("Unexpected draft: %+v", pact.Interactions)
+	}
+}
+
+func TestRecorder_StartFail(t *testing.T) {
+	recorder := &Recorder{Target: "http://localhost:8000"}
+	if err := recorder.Start(); err == nil {
+		t.Fatalf("Expected an error without a Consumer and Provider")
+	}
+}
+
+func TestPactFile_AddInteraction(t *testing.T) {
+	pact := &PactFile{}
+	err := pact.AddInteraction(Interaction{
+		Description: "a request for users",
+		State:       "users exist",
+		Request: Request{
+			Method:  "get",
+			Path:    Term("/users/1", "/users/[0-9]+"),
+			Query:   MapMatcher{"page": String("1")},
+			Headers: MapMatcher{"Accept": String("application/json")},
+		},
+		Response: Response{
+			Status: 200,
+			Body: map[string]interface{}{
+				"users": EachLike(map[string]interface{}{"id": Like(1)}, 2),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	data, _ := json.Marshal(pact.Interactions[0])
+	expected := `{"description":"a request for users","providerState":"users exist",` +
+		`"request":{"method":"GET","path":"/users/1","query":"page=1","headers":{"Accept":"application/json"},` +
+		`"matchingRules":{"$.path":{"match":"regex","regex":"/users/[0-9]+"}}},` +
+		`"response":{"status":200,"body":{"users":[{"id":1},{"id":1}]},` +
+		`"matchingRules":{"$.body.users":{"match":"type","min":2},"$.body.users[*].id":{"match":"type"}}}}`
+	if string(data) != expected {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, data)
+	}
+}
+
+func TestPactFile_MarshalJSON(t *testing.T) {
+	pact := &PactFile{
+		Consumer:     PactName{Name: "consumer"},
+		Provider:     PactName{Name: "provider"},
+		Interactions: []PactInteraction{{Request: PactRequest{Method: "GET", Path: "/posts", Query: PactQuery{"tag": []string{"a", "b"}}}}},
+	}
+
+	cases := []struct {
+		version string
+		query   string
+	}{
+		{"", `"query":"tag=a\u0026tag=b"`},
+		{"2.0.0", `"query":"tag=a\u0026tag=b"`},
+		{"3.0.0", `"query":{"tag":["a","b"]}`},
+	}
+
+	for _, c := range cases {
+		pact.Metadata = nil
+		if c.version != "" {
+			pact.Metadata = map[string]interface{}{"pactSpecification": map[string]interface{}{"version": c.version}}
+		}
+		data, err := json.Marshal(pact)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if !strings.Contains(string(data), c.query) || !strings.Contains(string(data), `"consumer":{"name":"consumer"}`) {
+			t.Fatalf("Expected %s for version '%s' but got %s", c.query, c.version, data)
+		}
+
+		var read PactFile
+		if err = json.Unmarshal(data, &read); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if read.Interactions[0].Request.Query.String() != "tag=a&tag=b" {
+			t.Fatalf("Expected the query to be read back but got %v", read.Interactions[0].Request.Query)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package dsl
 
 // Request is the default implementation of the Request interface.
 type Request struct {
-	Method  string        `json:"method"`
-	Path    StringMatcher `json:"path"`
-	Query   MapMatcher    `json:"query,omitempty"`
-	Headers MapMatcher    `json:"headers,omitempty"`
-	Body    interface{}   `json:"body,omitempty"`
+	Method string        `json:"method"`
+	Path   StringMatcher `json:"path"`
+	Query  MapMatcher    `json:"query,omitempty"`
+
+	// Headers expected in the request. Names are case insensitive, and other
+	// headers are allowed. See MediaType and HeaderValues for matching
+	// Content-Type and multi-valued headers.
+	Headers MapMatcher `json:"headers,omitempty"`
+
+	Body interface{} `json:"body,omitempty"`
 }
@@ -2,7 +2,11 @@ package dsl
 
 // Response is the default implementation of the Response interface.
 type Response struct {
-	Status  int         `json:"status"`
-	Headers MapMatcher  `json:"headers,omitempty"`
-	Body    interface{} `json:"body,omitempty"`
+	Status int `json:"status"`
+
+	// Headers of the response. Names are case insensitive, and the Provider
+	// may return other headers.
+	Headers MapMatcher `json:"headers,omitempty"`
+
+	Body interface{} `json:"body,omitempty"`
 }
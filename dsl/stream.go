@@ -0,0 +1,628 @@
+package dsl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// StreamBody is a streaming response body: a sequence of Server-Sent Events
+// (text/event-stream) or lines of newline delimited JSON
+// (application/x-ndjson). Use EventStream or NDJSON to create one, e.g.
+//
+//	body := dsl.EventStream().
+//		NamedEvent("price", map[string]interface{}{"symbol": dsl.Like("ACME"), "price": dsl.Like(1.5)}).
+//		MinEvents(3).
+//		KeepOpen()
+//
+// The data of each event may contain Matchers. Events after the last one
+// given must match it, and MinEvents sets how many must be sent. Unless
+// KeepOpen is used, the stream must end after its events.
+//
+// A StreamBody is sent to the mock service, and written to a PactFile, as
+// its example stream, and Pact.WritePact writes the Matchers of its events
+// as matching rules. Pact.Verify and the StubServer write each event of the
+// stream as it is sent. VerifyProvider reads the stream from the Provider,
+// checking each event as it arrives, and Verify checks a stream read by
+// hand.
+type StreamBody struct {
+	contentType string
+	events      []streamEvent
+	min         int
+	open        bool
+}
+
+// streamEvent is an event of a StreamBody, with an optional name for
+// Server-Sent Events.
+type streamEvent struct {
+	name string
+	data interface{}
+}
+
+// Content types of streaming bodies.
+const (
+	EventStreamContentType = "text/event-stream"
+	NDJSONContentType      = "application/x-ndjson"
+)
+
+// EventStream creates a stream of Server-Sent Events.
+func EventStream() *StreamBody {
+	return &StreamBody{contentType: EventStreamContentType}
+}
+
+// NDJSON creates a stream of newline delimited JSON values.
+func NDJSON() *StreamBody {
+	return &StreamBody{contentType: NDJSONContentType}
+}
+
+// Event adds an event, or a line of NDJSON, whose data is a string or JSON
+// value, either of which may contain Matchers.
+func (s *StreamBody) Event(data interface{}) *StreamBody {
+	s.events = append(s.events, streamEvent{data: data})
+	return s
+}
+
+// NamedEvent adds a Server-Sent Event with an event name, e.g. "price".
+func (s *StreamBody) NamedEvent(name string, data interface{}) *StreamBody {
+	s.events = append(s.events, streamEvent{name: name, data: data})
+	return s
+}
+
+// MinEvents sets the minimum number of events the stream must contain,
+// which is otherwise the number of events added. Any events after those
+// added must match the last one.
+func (s *StreamBody) MinEvents(min int) *StreamBody {
+	s.min = min
+	return s
+}
+
+// KeepOpen allows the stream to continue after its events, e.g. for a feed
+// of updates. Otherwise the stream must end after its events.
+func (s *StreamBody) KeepOpen() *StreamBody {
+	s.open = true
+	return s
+}
+
+// ContentType returns the Content-Type header of the body.
+func (s *StreamBody) ContentType() string {
+	return s.contentType
+}
+
+// count returns the number of events in the example stream: the minimum,
+// or the number of events added if greater.
+func (s *StreamBody) count() int {
+	if s.min > len(s.events) && len(s.events) > 0 {
+		return s.min
+	}
+	return len(s.events)
+}
+
+// event returns the event at index i of the example stream, repeating the
+// last event up to the minimum.
+func (s *StreamBody) event(i int) streamEvent {
+	if i >= len(s.events) {
+		return s.events[len(s.events)-1]
+	}
+	return s.events[i]
+}
+
+// String returns the example stream.
+func (s *StreamBody) String() string {
+	var b bytes.Buffer
+	for i := 0; i < s.count(); i++ {
+		event := s.event(i)
+		data, err := extractMatchers(nil, event.data, map[string]interface{}{})
+		if err != nil {
+			continue
+		}
+		b.WriteString(formatStreamEvent(s.contentType, event.name, data))
+	}
+	return b.String()
+}
+
+// MarshalJSON writes the example stream as a JSON string.
+func (s *StreamBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// bodyRules adds the matching rules of the events at path, e.g.
+// "$.body[0].data.price" for an event, with those of the last event also
+// applying to any events after it, e.g. "$.body[*].data.price".
+func (s *StreamBody) bodyRules(path []string, rules map[string]interface{}) {
+	for i, event := range s.events {
+		extractMatchers(s.dataPath(child(path, fmt.Sprintf("[%d]", i))), event.data, rules)
+		if i == len(s.events)-1 {
+			extractMatchers(s.dataPath(child(path, "[*]")), event.data, rules)
+		}
+	}
+}
+
+// dataPath returns the path of the data of the event at path: the data of a
+// Server-Sent Event, or the event itself for NDJSON.
+func (s *StreamBody) dataPath(path []string) []string {
+	if s.contentType == EventStreamContentType {
+		return child(path, "data")
+	}
+	return path
+}
+
+// validateBody checks the events and their Matchers.
+func (s *StreamBody) validateBody(path []string) []string {
+	var problems []string
+	if len(s.events) == 0 {
+		problems = append(problems, fmt.Sprintf("%s: a stream must have at least one event", formatPath(path)))
+	}
+	for i, event := range s.events {
+		eventPath := child(path, fmt.Sprintf("[%d]", i))
+		if event.name != "" && s.contentType != EventStreamContentType {
+			problems = append(problems, fmt.Sprintf("%s: only Server-Sent Events can have a name", formatPath(eventPath)))
+		}
+		problems = append(problems, validateMatchers(s.dataPath(eventPath), event.data)...)
+	}
+	return problems
+}
+
+// Verify reads a stream from the Provider, such as the body of a response,
+// and checks each event as it arrives. It fails if the events don't match,
+// or if the stream doesn't have enough events, or end when expected, within
+// the timeout. If the stream is an io.Closer, it is closed once verified or
+// on a timeout.
+func (s *StreamBody) Verify(stream io.Reader, timeout time.Duration) error {
+	if len(s.events) == 0 {
+		return errors.New("a stream must have at least one event")
+	}
+
+	rules := map[string]interface{}{}
+	s.bodyRules([]string{"body"}, rules)
+	examples, _ := parseStream(s.contentType, []byte(s.String()))
+
+	return verifyStream(s.contentType, examples, rules, s.open, stream, timeout)
+}
+
+// verifyStream checks each event of a stream as it arrives, with the
+// example events and the matching rules of a body. Unless open, the stream
+// must end after the events. If the stream is an io.Closer, it is closed
+// once verified or on a timeout.
+func verifyStream(contentType string, examples []interface{}, rules map[string]interface{}, open bool, stream io.Reader, timeout time.Duration) error {
+	if closer, ok := stream.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	events := make(chan interface{})
+	stop := make(chan struct{})
+	defer close(stop)
+	done := make(chan error, 1)
+	go func() {
+		done <- readStreamEvents(contentType, stream, func(event interface{}) bool {
+			select {
+			case events <- event:
+				return true
+			case <-stop:
+				return false
+			}
+		})
+	}()
+
+	c := &comparison{rules: parseMatchingRules(rules)}
+	expected := len(examples)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	received := 0
+	for {
+		select {
+		case event := <-events:
+			c.compareEvent([]string{"body"}, examples, received, event)
+			received++
+			if open && received >= expected {
+				return streamMismatches(c.mismatches)
+			}
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("unable to read the stream: %v", err)
+			}
+			if received < expected {
+				c.mismatch([]string{"body"}, "expected at least %d events but the stream ended after %d", expected, received)
+			}
+			return streamMismatches(c.mismatches)
+		case <-timer.C:
+			if received < expected {
+				return fmt.Errorf("timed out after %v waiting for events: expected at least %d but received %d", timeout, expected, received)
+			}
+			return fmt.Errorf("timed out after %v waiting for the stream to end after %d events", timeout, received)
+		}
+	}
+}
+
+// compareStream compares the events of streams. There must be at least as
+// many events as the example, and any more must match its last event.
+func (c *comparison) compareStream(path []string, expected []interface{}, actual []interface{}) {
+	if len(actual) < len(expected) {
+		c.mismatch(path, "expected at least %d events but received %d", len(expected), len(actual))
+	}
+	for i, event := range actual {
+		c.compareEvent(path, expected, i, event)
+	}
+}
+
+// compareEvent compares the event at index i of a stream with the example
+// at that index, or the last example.
+func (c *comparison) compareEvent(path []string, expected []interface{}, i int, event interface{}) {
+	example := expected[len(expected)-1]
+	if i < len(expected) {
+		example = expected[i]
+	}
+	c.compare(child(path, fmt.Sprintf("[%d]", i)), example, event)
+}
+
+// streamMismatches returns an error listing the mismatches, if any.
+func streamMismatches(mismatches []Mismatch) error {
+	if len(mismatches) == 0 {
+		return nil
+	}
+	lines := make([]string, len(mismatches))
+	for i, m := range mismatches {
+		lines[i] = m.String()
+	}
+	return fmt.Errorf("the stream did not match:\n\t%s", strings.Join(lines, "\n\t"))
+}
+
+// formatStreamEvent formats an event of a stream.
+func formatStreamEvent(contentType string, name string, data interface{}) string {
+	text, ok := data.(string)
+	if !ok {
+		encoded, _ := json.Marshal(data)
+		text = string(encoded)
+	}
+
+	if contentType != EventStreamContentType {
+		return text + "\n"
+	}
+
+	var b bytes.Buffer
+	if name != "" {
+		b.WriteString("event: " + name + "\n")
+	}
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// readStreamEvents reads the events of a stream, emitting each as it is
+// read, until emit returns false. Events are in the form compared with the
+// examples: {"event": name, "data": data} for a Server-Sent Event, or the
+// JSON value of a line of NDJSON.
+func readStreamEvents(contentType string, stream io.Reader, emit func(interface{}) bool) error {
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	if contentType != EventStreamContentType {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			if !emit(streamData(line)) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	name, data, hasData := "", []string{}, false
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			if hasData {
+				if name == "" {
+					name = "message"
+				}
+				if !emit(map[string]interface{}{"event": name, "data": streamData(strings.Join(data, "\n"))}) {
+					return nil
+				}
+			}
+			name, data, hasData = "", []string{}, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		}
+	}
+	return scanner.Err()
+}
+
+// streamData decodes the data of an event as JSON, or else returns it as a
+// string.
+func streamData(text string) interface{} {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return text
+	}
+	return value
+}
+
+// parseStream reads all of the events of a stream.
+func parseStream(contentType string, body []byte) ([]interface{}, error) {
+	var events []interface{}
+	err := readStreamEvents(contentType, bytes.NewReader(body), func(event interface{}) bool {
+		events = append(events, event)
+		return true
+	})
+	return events, err
+}
+
+// isStreamContentType reports whether the content type is a stream of
+// Server-Sent Events or NDJSON.
+func isStreamContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == EventStreamContentType || mediaType == NDJSONContentType)
+}
+
+// writeStream writes a stream one event at a time, flushing each so that
+// the client receives it as it is written.
+func writeStream(w http.ResponseWriter, contentType string, body []byte) {
+	flusher, _ := w.(http.Flusher)
+	for _, event := range streamEvents(contentType, body) {
+		w.Write(event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// streamEvents splits a stream into the text of its events.
+func streamEvents(contentType string, body []byte) [][]byte {
+	separator := []byte("\n")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == EventStreamContentType {
+		separator = []byte("\n\n")
+	}
+
+	var events [][]byte
+	for _, event := range bytes.SplitAfter(body, separator) {
+		if len(event) > 0 {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// eventReader reads the events of a stream, one event at a time.
+type eventReader struct {
+	events [][]byte
+}
+
+// Read reads the rest of the next event.
+func (r *eventReader) Read(p []byte) (int, error) {
+	if len(r.events) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.events[0])
+	if r.events[0] = r.events[0][n:]; len(r.events[0]) == 0 {
+		r.events = r.events[1:]
+	}
+	return n, nil
+}
+
+// defaultStreamTimeout is how long provider verification waits for the
+// events of a streaming response, unless VerifyRequest.StreamTimeout is set.
+const defaultStreamTimeout = 10 * time.Second
+
+// findStreamPacts loads the Pact files to verify, returning those with
+// interactions that have a streaming response by their URL. A Pact file
+// that can't be loaded is left to the verifier.
+func findStreamPacts(request types.VerifyRequest) map[string]*PactFile {
+	pacts := map[string]*PactFile{}
+	for _, pactURL := range append(append([]string{}, request.PactURLs...), request.PendingPactURLs...) {
+		pact, err := loadPactFile(pactURL, request.BrokerUsername, request.BrokerPassword)
+		if err != nil {
+			log.Println("[DEBUG] unable to load pact file to find streaming responses:", err)
+			continue
+		}
+		for _, interaction := range pact.Interactions {
+			if isStreamInteraction(interaction) {
+				pacts[pactURL] = pact
+				break
+			}
+		}
+	}
+	return pacts
+}
+
+// isStreamInteraction reports whether the interaction has a streaming
+// response.
+func isStreamInteraction(interaction PactInteraction) bool {
+	contentType, _ := headerExample(interaction.Response.Headers, "Content-Type")
+	return isStreamContentType(contentType)
+}
+
+// verifyStreams verifies the interactions with streaming responses against
+// the Provider, adding an example to the response for each. It returns the
+// number of failures.
+func verifyStreams(response *types.ProviderVerifierResponse, request types.VerifyRequest, pact *PactFile, interactions []PactInteraction) int {
+	// The examples are anonymous structs, so the slice is grown by reflection
+	first := len(response.Examples)
+	examples := reflect.ValueOf(&response.Examples).Elem()
+	examples.Set(reflect.AppendSlice(examples, reflect.MakeSlice(examples.Type(), len(interactions), len(interactions))))
+
+	failures := 0
+	for i, interaction := range interactions {
+		description := interaction.Description
+		if states := interaction.States(); len(states) > 0 {
+			description = fmt.Sprintf("Given %s %s", strings.Join(states, " and "), description)
+		}
+
+		example := &response.Examples[first+i]
+		example.Description = interaction.Description
+		example.FullDescription = fmt.Sprintf("Verifying a pact between %s and %s %s with %s %s returns a stream matching the example",
+			pact.Consumer.Name, pact.Provider.Name, description, interaction.Request.Method, interaction.Request.Path)
+		example.Status = "passed"
+
+		if err := verifyStreamInteraction(request, pact.Consumer.Name, interaction); err != nil {
+			example.Status = "failed"
+			example.Exception.Class = "StreamMismatch"
+			example.Exception.Message = err.Error()
+			response.Summary.FailureCount++
+			failures++
+		}
+		response.Summary.ExampleCount++
+	}
+
+	return failures
+}
+
+// verifyStreamInteraction sets up the provider states of the interaction,
+// sends its request to the Provider, and reads the stream it responds with,
+// checking each event as it arrives. As a Pact file does not record whether
+// the stream is kept open, it is not read past the events of the example.
+func verifyStreamInteraction(request types.VerifyRequest, consumer string, interaction PactInteraction) error {
+	timeout := request.StreamTimeout
+	if timeout == 0 {
+		timeout = defaultStreamTimeout
+	}
+
+	contentType, _ := headerExample(interaction.Response.Headers, "Content-Type")
+	body, ok := interaction.Response.Body.(string)
+	if !ok {
+		return errors.New("the example stream is not a string")
+	}
+	examples, err := parseStream(contentType, []byte(body))
+	if err != nil || len(examples) == 0 {
+		return fmt.Errorf("unable to read the example stream: %v", err)
+	}
+
+	for _, state := range interaction.States() {
+		if err = setupProviderState(request, consumer, state); err != nil {
+			return err
+		}
+	}
+
+	req, err := providerRequest(request, interaction.Request)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	status := interaction.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if res.StatusCode != status {
+		return fmt.Errorf("expected status %d but received %d", status, res.StatusCode)
+	}
+	if !isStreamContentType(res.Header.Get("Content-Type")) {
+		return fmt.Errorf("expected a stream of %s but received %q", mediaType(contentType), res.Header.Get("Content-Type"))
+	}
+
+	return verifyStream(contentType, examples, interaction.Response.MatchingRules, true, res.Body, timeout)
+}
+
+// setupProviderState posts a provider state to the ProviderStatesSetupURL,
+// as the verifier does, if it is set.
+func setupProviderState(request types.VerifyRequest, consumer string, state string) error {
+	if request.ProviderStatesSetupURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"consumer": consumer,
+		"state":    state,
+		"states":   []string{state},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", request.ProviderStatesSetupURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setCustomProviderHeaders(req, request.CustomProviderHeaders)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to set up the provider state %q: %v", state, err)
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unable to set up the provider state %q: %s", state, res.Status)
+	}
+	return nil
+}
+
+// providerRequest creates the request of an interaction to the Provider.
+func providerRequest(request types.VerifyRequest, expected PactRequest) (*http.Request, error) {
+	u := strings.TrimSuffix(request.ProviderBaseURL, "/") + expected.Path
+	if len(expected.Query) > 0 {
+		u += "?" + url.Values(expected.Query).Encode()
+	}
+
+	var body io.Reader
+	if expected.Body != nil {
+		contentType, _ := headerExample(expected.Headers, "Content-Type")
+		if text, ok := expected.Body.(string); ok && !isJSONContentType(contentType) {
+			body = strings.NewReader(text)
+		} else {
+			content, err := json.Marshal(expected.Body)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(content)
+		}
+	}
+
+	req, err := http.NewRequest(expected.Method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range expected.Headers {
+		req.Header.Set(name, value)
+	}
+	setCustomProviderHeaders(req, request.CustomProviderHeaders)
+	return req, nil
+}
+
+// setCustomProviderHeaders sets the CustomProviderHeaders of a
+// VerifyRequest, e.g. "Authorization: Basic cGFjdDpwYWN0", on a request.
+func setCustomProviderHeaders(req *http.Request, headers []string) {
+	for _, header := range headers {
+		if i := strings.Index(header, ":"); i > 0 {
+			req.Header.Set(strings.TrimSpace(header[:i]), strings.TrimSpace(header[i+1:]))
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package dsl
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func priceStream() *StreamBody {
+	return EventStream().
+		NamedEvent("open", "market open").
+		NamedEvent("price", map[string]interface{}{"symbol": Like("ACME"), "price": Like(1.5)}).
+		MinEvents(3)
+}
+
+func TestStream_String(t *testing.T) {
+	expected := "event: open\ndata: market open\n\n" +
+		"event: price\ndata: {\"price\":1.5,\"symbol\":\"ACME\"}\n\n" +
+		"event: price\ndata: {\"price\":1.5,\"symbol\":\"ACME\"}\n\n"
+	if priceStream().String() != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, priceStream().String())
+	}
+
+	lines := NDJSON().Event(map[string]interface{}{"id": Like(1)}).Event(map[string]interface{}{"id": 2}).String()
+	if lines != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Fatalf("Unexpected NDJSON: %s", lines)
+	}
+}
+
+func TestStream_Verify(t *testing.T) {
+	cases := []struct {
+		stream string
+		err    string
+	}{
+		{"event: open\ndata: market open\n\n" +
+			": comment\nevent: price\ndata: {\"symbol\":\"XYZ\",\"price\":2}\n\n" +
+			"event: price\nid: 3\ndata: {\"symbol\":\"ABC\",\ndata: \"price\":3.25}\n\n" +
+			"event: price\ndata: {\"symbol\":\"DEF\",\"price\":4}\n\n", ""},
+		{"event: open\ndata: market open\n\nevent: price\ndata: {\"symbol\":\"XYZ\",\"price\":2}\n\n",
+			"$.body: expected at least 3 events but the stream ended after 2"},
+		{"event: open\ndata: market closed\n\nevent: price\ndata: {\"symbol\":1,\"price\":2}\n\ndata: {}\n\n",
+			"$.body[0].data: expected \"market open\"|$.body[1].data.symbol|$.body[2].event"},
+	}
+
+	for _, c := range cases {
+		err := priceStream().Verify(strings.NewReader(c.stream), time.Second)
+		if c.err == "" {
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("Expected an error for %q", c.stream)
+		}
+		for _, part := range strings.Split(c.err, "|") {
+			if !strings.Contains(err.Error(), part) {
+				t.Fatalf("Expected the error to contain %q but got %v", part, err)
+			}
+		}
+	}
+}
+
+func TestStream_VerifyTimeout(t *testing.T) {
+	r, w := io.Pipe()
+	go func() {
+		w.Write([]byte("{\"id\":1}\n"))
+		w.Write([]byte("{\"id\":2}\n"))
+	}()
+
+	// An open stream is verified once it has enough events
+	if err := NDJSON().Event(map[string]interface{}{"id": Like(1)}).MinEvents(2).KeepOpen().Verify(r, time.Second); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	r, w = io.Pipe()
+	go w.Write([]byte("{\"id\":1}\n"))
+	err := NDJSON().Event(map[string]interface{}{"id": Like(1)}).Verify(r, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "waiting for the stream to end after 1 events") {
+		t.Fatalf("Expected a timeout waiting for the stream to end but got %v", err)
+	}
+
+	r, _ = io.Pipe()
+	err = NDJSON().Event(map[string]interface{}{"id": Like(1)}).KeepOpen().Verify(r, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "expected at least 1 but received 0") {
+		t.Fatalf("Expected a timeout waiting for events but got %v", err)
+	}
+}
+
+func TestStream_StubServer(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request for prices",
+		Request:     Request{Method: "GET", Path: String("/prices")},
+		Response: Response{
+			Status:  200,
+			Headers: MapMatcher{"Content-Type": String(EventStreamContentType)},
+			Body:    priceStream(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	response := pact.Interactions[0].Response
+	if _, ok := response.MatchingRules["$.body[*].data.price"]; !ok {
+		t.Fatalf("Expected matching rules for the events but got %v", response.MatchingRules)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeStubResponse(w, response)
+	}))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err = priceStream().Verify(res.Body, time.Second); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	c := &comparison{rules: parseMatchingRules(response.MatchingRules)}
+	c.compareBody(response.Body, response.Headers, EventStreamContentType, []byte("event: open\ndata: market open\n\n"))
+	if len(c.mismatches) != 1 || c.mismatches[0].Path != "$.body" {
+		t.Fatalf("Expected too few events to be a mismatch but got %v", c.mismatches)
+	}
+}
+
+func TestStream_VerifyProvider(t *testing.T) {
+	pact := &PactFile{Consumer: PactName{Name: "billy"}, Provider: PactName{Name: "bobby"}}
+	err := pact.AddInteraction(Interaction{
+		State:       "ACME is trading",
+		Description: "a request for prices",
+		Request:     Request{Method: "GET", Path: String("/prices")},
+		Response: Response{
+			Status:  200,
+			Headers: MapMatcher{"Content-Type": String(EventStreamContentType)},
+			Body:    priceStream(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	err = pact.AddInteraction(Interaction{
+		Description: "a request for a price",
+		Request:     Request{Method: "GET", Path: String("/prices/ACME")},
+		Response:    Response{Status: 200, Body: map[string]interface{}{"price": Like(1.5)}},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "billy-bobby.json")
+	if err = pact.Write(file); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	cases := []struct {
+		price  string
+		status string
+	}{
+		{"1.75", "passed"},
+		{`"1.75"`, "failed"},
+	}
+
+	for _, c := range cases {
+		var state string
+		provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/setup" {
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				state, _ = body["state"].(string)
+				return
+			}
+			w.Header().Set("Content-Type", EventStreamContentType)
+			writeStream(w, EventStreamContentType, []byte("event: open\ndata: market open\n\n"))
+			for i := 0; i < 3; i++ {
+				writeStream(w, EventStreamContentType, []byte("event: price\ndata: {\"symbol\": \"ACME\", \"price\": "+c.price+"}\n\n"))
+			}
+			<-r.Context().Done()
+		}))
+
+		client, _ := createClient(true)
+		res, err := (&Pact{pactClient: client}).VerifyProviderRaw(types.VerifyRequest{
+			ProviderBaseURL:        provider.URL,
+			ProviderStatesSetupURL: provider.URL + "/setup",
+			PactURLs:               []string{file},
+			StreamTimeout:          time.Second,
+		})
+		provider.Close()
+
+		if state != "ACME is trading" {
+			t.Fatalf("Expected the provider state to be set up but got %q", state)
+		}
+		if len(res.Examples) != 1 || res.Examples[0].Status != c.status || (err == nil) != (c.status == "passed") {
+			t.Fatalf("Expected the stream with the price %s to have %s but got %+v: %v", c.price, c.status, res.Examples, err)
+		}
+	}
+}
+
+func TestStream_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		UponReceiving("a request for prices").
+		WithRequest(Request{Method: "GET", Path: String("/prices")}).
+		WillRespondWith(Response{Status: 200, Body: NDJSON().NamedEvent("price", map[string]interface{}{"id": Term("abc", `^\d+$`)})})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 2 || !strings.Contains(problems[0], "only Server-Sent Events can have a name") || !strings.HasPrefix(problems[1], "$.response.body[0].id: ") {
+		t.Fatalf("Expected the event name and matcher to be invalid but got %v", problems)
+	}
+}
@@ -0,0 +1,275 @@
+package dsl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/pact-foundation/pact-go/utils"
+)
+
+// StubServer serves the example responses of the interactions in one or
+// more Pact files, so that a Consumer can be developed or tested without the
+// real Provider.
+type StubServer struct {
+	// PactURLs are the local files, directories, globs or http URLs of the
+	// Pact files to serve.
+	PactURLs []string
+
+	// Host to listen on, defaults to "localhost".
+	Host string
+
+	// Port to listen on. If 0, a free port is chosen.
+	Port int
+
+	// ProviderStates restricts the interactions served to those with one of
+	// these provider states, or with no provider state. All interactions are
+	// served if empty.
+	ProviderStates []string
+
+	// Strict returns a 500 rather than a 404 for requests that do not match
+	// an interaction.
+	Strict bool
+
+	// CORS adds Cross-Origin Resource Sharing headers to responses, and
+	// responds to preflight requests.
+	CORS bool
+
+	interactions []PactInteraction
+	listener     net.Listener
+	server       *http.Server
+}
+
+// Start loads the Pact files and starts serving them in the background.
+func (s *StubServer) Start() error {
+	if err := s.Load(); err != nil {
+		return err
+	}
+
+	if s.Host == "" {
+		s.Host = "localhost"
+	}
+
+	if s.Port == 0 {
+		port, err := utils.GetFreePort()
+		if err != nil {
+			return err
+		}
+		s.Port = port
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.Host, s.Port))
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: s}
+
+	log.Printf("[INFO] stub server serving %d interactions on %s", len(s.interactions), s.URL())
+	go s.server.Serve(listener)
+
+	return nil
+}
+
+// Stop stops the server.
+func (s *StubServer) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	log.Println("[DEBUG] stopping stub server")
+	err := s.server.Close()
+	s.server = nil
+	return err
+}
+
+// URL is the base URL of the running server.
+func (s *StubServer) URL() string {
+	return fmt.Sprintf("http://%s:%d", s.Host, s.Port)
+}
+
+// Load reads the interactions from the Pact files, filtered by provider
+// state. It is called by Start, and need only be called directly when
+// using the StubServer as an http.Handler.
+func (s *StubServer) Load() error {
+	if len(s.PactURLs) == 0 {
+		return errors.New("PactURLs is mandatory")
+	}
+
+	pacts, err := LoadPactFiles(s.PactURLs)
+	if err != nil {
+		return err
+	}
+
+	s.interactions = nil
+	for _, pact := range pacts {
+		for _, interaction := range pact.Interactions {
+			if s.inProviderStates(interaction) {
+				s.interactions = append(s.interactions, interaction)
+			}
+		}
+	}
+
+	if len(s.interactions) == 0 {
+		return errors.New("no interactions found to serve")
+	}
+
+	return nil
+}
+
+// inProviderStates reports whether the interaction should be served for the
+// configured provider states.
+func (s *StubServer) inProviderStates(interaction PactInteraction) bool {
+	states := interaction.States()
+	if len(s.ProviderStates) == 0 || len(states) == 0 {
+		return true
+	}
+	for _, state := range states {
+		for _, wanted := range s.ProviderStates {
+			if state == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ServeHTTP responds with the response of the first interaction matching
+// the request.
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.CORS {
+		s.writeCORSHeaders(w, r)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeStubError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var closest *PactInteraction
+	var closestMismatches []Mismatch
+	for i := range s.interactions {
+		interaction := &s.interactions[i]
+		mismatches := matchRequest(interaction.Request, r, body)
+		if len(mismatches) == 0 {
+			log.Printf("[DEBUG] stub server matched %s %s to '%s'", r.Method, r.URL, interaction.Description)
+			writeStubResponse(w, interaction.Response)
+			return
+		}
+		if closest == nil || len(mismatches) < len(closestMismatches) {
+			closest, closestMismatches = interaction, mismatches
+		}
+	}
+
+	log.Printf("[INFO] stub server found no interaction matching %s %s", r.Method, r.URL)
+	status := http.StatusNotFound
+	if s.Strict {
+		status = http.StatusInternalServerError
+	}
+
+	message := fmt.Sprintf("No interaction found for %s %s", r.Method, r.URL)
+	if closest != nil {
+		message = fmt.Sprintf("%s, the closest was '%s'", message, closest.Description)
+	}
+	writeStubError(w, status, message, closestMismatches)
+}
+
+// writeCORSHeaders allows requests from any origin.
+func (s *StubServer) writeCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = "*"
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS")
+	if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+	}
+}
+
+// writeStubResponse writes the example response, with generators applied.
+// Binary bodies are decoded from base64, and streams written an event at a
+// time.
+func writeStubResponse(w http.ResponseWriter, response PactResponse) {
+	if response.Status == 0 {
+		response.Status = http.StatusOK
+	}
+
+	headers := applyHeaderGenerators(response.Headers, response.Generators)
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+
+	if response.Body == nil {
+		w.WriteHeader(response.Status)
+		return
+	}
+
+	var body []byte
+	if text, ok := response.Body.(string); ok && !isJSONContentType(w.Header().Get("Content-Type")) {
+		body = []byte(text)
+		if isBinaryContentType(w.Header().Get("Content-Type")) {
+			if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+				body = decoded
+			}
+		}
+	} else {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		var err error
+		body, err = json.Marshal(applyBodyGenerators(copyJSON(response.Body), response.Generators))
+		if err != nil {
+			writeStubError(w, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
+
+	w.WriteHeader(response.Status)
+	if isStreamContentType(w.Header().Get("Content-Type")) {
+		writeStream(w, w.Header().Get("Content-Type"), body)
+		return
+	}
+	w.Write(body)
+}
+
+// copyJSON deep copies a decoded JSON value, so that generators do not
+// modify the examples.
+func copyJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for k, child := range value {
+			copied[k] = copyJSON(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, child := range value {
+			copied[i] = copyJSON(child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// writeStubError writes an error, and any mismatches, as JSON.
+func writeStubError(w http.ResponseWriter, status int, message string, mismatches []Mismatch) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      message,
+		"mismatches": mismatches,
+	})
+}
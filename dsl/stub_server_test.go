@@ -0,0 +1,187 @@
+package dsl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var stubPact = `{
+  "consumer": {"name": "billy"},
+  "provider": {"name": "bobby"},
+  "interactions": [
+    {
+      "description": "a request for user 1",
+      "providerState": "user 1 exists",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "body": {"id": 1, "name": "billy", "token": "abc"},
+        "generators": {"body": {"$.token": {"type": "Uuid"}}}
+      }
+    },
+    {
+      "description": "a request for a missing user",
+      "providerState": "user 1 does not exist",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 404}
+    },
+    {
+      "description": "a request to create a user",
+      "request": {
+        "method": "POST",
+        "path": "/users",
+        "headers": {"Content-Type": "application/json"},
+        "body": {"name": "billy"},
+        "matchingRules": {"$.body.name": {"match": "type"}}
+      },
+      "response": {"status": 201, "headers": {"Content-Type": "text/plain"}, "body": "created"}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "2.0.0"}}
+}`
+
+func createStubServer(t *testing.T, states ...string) (*StubServer, func()) {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	file := filepath.Join(dir, "billy-bobby.json")
+	if err = ioutil.WriteFile(file, []byte(stubPact), 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	s := &StubServer{PactURLs: []string{file}, ProviderStates: states}
+	if err = s.Load(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestStubServer_ServeHTTP(t *testing.T) {
+	s, cleanup := createStubServer(t, "user 1 exists")
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 but got %d: %s", w.Code, w.Body.String())
+	}
+
+	var user map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if user["name"] != "billy" {
+		t.Fatalf("Expected the example response but got %v", user)
+	}
+	if token, _ := user["token"].(string); len(token) != 36 || token == "abc" {
+		t.Fatalf("Expected a generated UUID token but got %v", user["token"])
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name": "sally"}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Body.String() != "created" {
+		t.Fatalf("Expected 201 'created' but got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestStubServer_ProviderStates(t *testing.T) {
+	s, cleanup := createStubServer(t, "user 1 does not exist")
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected the interaction for the provider state to be served but got %d", w.Code)
+	}
+	if len(s.interactions) != 2 {
+		t.Fatalf("Expected interactions without a provider state to be served, but got %d interactions", len(s.interactions))
+	}
+}
+
+func TestStubServer_Unmatched(t *testing.T) {
+	s, cleanup := createStubServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 but got %d", w.Code)
+	}
+
+	var res struct {
+		Error      string
+		Mismatches []Mismatch
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(res.Error, "a request to create a user") || len(res.Mismatches) != 1 || res.Mismatches[0].Path != "$.body.name" {
+		t.Fatalf("Expected the closest interaction's mismatches but got %+v", res)
+	}
+
+	s.Strict = true
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/1", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500 in strict mode but got %d", w.Code)
+	}
+}
+
+func TestStubServer_CORS(t *testing.T) {
+	s, cleanup := createStubServer(t)
+	defer cleanup()
+	s.CORS = true
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("OPTIONS", "/users", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	s.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for a preflight request but got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "http://localhost:3000" || w.Header().Get("Access-Control-Allow-Headers") != "Content-Type" {
+		t.Fatalf("Unexpected CORS headers: %v", w.Header())
+	}
+}
+
+func TestStubServer_StartStop(t *testing.T) {
+	s, cleanup := createStubServer(t)
+	defer cleanup()
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer s.Stop()
+
+	res, err := http.Post(s.URL()+"/users", "application/json", strings.NewReader(`{"name": "sally"}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 but got %d", res.StatusCode)
+	}
+}
+
+func TestStubServer_LoadFail(t *testing.T) {
+	s := &StubServer{}
+	if err := s.Load(); err == nil {
+		t.Fatalf("Expected an error without PactURLs")
+	}
+}
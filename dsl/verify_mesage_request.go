@@ -2,6 +2,7 @@ package dsl
 
 import (
 	"fmt"
+	"time"
 )
 
 // VerifyMessageRequest contains the verification logic
@@ -16,18 +17,45 @@ type VerifyMessageRequest struct {
 	// Tags to find in Broker for matrix-based testing
 	Tags []string
 
+	// ProviderTags are the tags of the Provider version being verified. When
+	// EnablePending is set, the Pact Broker uses them to work out which pacts
+	// are pending for this Provider.
+	ProviderTags []string
+
+	// EnablePending allows pending pacts to be verified without failing the
+	// build. The pending status of each pact is retrieved from the Pact Broker.
+	EnablePending bool
+
+	// IncludeWIPPactsSince includes any "work in progress" pacts published
+	// to the Pact Broker after the given time. WIP pacts are always pending.
+	IncludeWIPPactsSince *time.Time
+
+	// PendingPactURLs are Local/HTTP paths to pending Pact files. They are
+	// verified in addition to PactURLs, but their failures are reported as
+	// warnings.
+	PendingPactURLs []string
+
 	// Username when authenticating to a Pact Broker.
 	BrokerUsername string
 
 	// Password when authenticating to a Pact Broker.
 	BrokerPassword string
 
-	// PublishVerificationResults to the Pact Broker.
+	// PublishVerificationResults to the Pact Broker. Results are published
+	// for each Pact retrieved from a Pact Broker.
 	PublishVerificationResults bool
 
 	// ProviderVersion is the semantical version of the Provider API.
 	ProviderVersion string
 
+	// ProviderBranch is the repository branch of the Provider version. Sent
+	// with the verification results when PublishVerificationResults is set.
+	ProviderBranch string
+
+	// BuildURL links to the CI build performing the verification. Sent with
+	// the verification results when PublishVerificationResults is set.
+	BuildURL string
+
 	// MessageHandlers contains a mapped list of message handlers for a provider
 	// that will be rable to produce the correct message format for a given
 	// consumer interaction
@@ -69,9 +97,5 @@ func (v *VerifyMessageRequest) Validate() error {
 		v.Args = append(v.Args, "--provider_app_version", v.ProviderVersion)
 	}
 
-	if v.PublishVerificationResults {
-		v.Args = append(v.Args, "--publish_verification_results", "true")
-	}
-
 	return nil
 }
@@ -0,0 +1,168 @@
+package dsl
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// webhookResponse is the HAL response for a Webhook.
+type webhookResponse struct {
+	types.Webhook
+	Links struct {
+		Self PactLink `json:"self"`
+	} `json:"_links"`
+}
+
+// webhook returns the Webhook, with the UUID taken from its self link.
+func (w webhookResponse) webhook() types.Webhook {
+	webhook := w.Webhook
+	if w.Links.Self.Href != "" {
+		webhook.UUID = path.Base(w.Links.Self.Href)
+	}
+	return webhook
+}
+
+// webhooksResponse is a subset of the HAL response from the webhooks API of a
+// Pact Broker.
+type webhooksResponse struct {
+	Links struct {
+		Webhooks []PactLink `json:"pb:webhooks"`
+	} `json:"_links"`
+}
+
+// ListWebhooks lists all of the Webhooks configured in the Pact Broker.
+func (b *Broker) ListWebhooks() ([]types.Webhook, error) {
+	log.Println("[DEBUG] broker - list webhooks")
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	var res webhooksResponse
+	if err := b.call("GET", fmt.Sprintf("%s/webhooks", b.URL), nil, &res); err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]types.Webhook, 0, len(res.Links.Webhooks))
+	for _, link := range res.Links.Webhooks {
+		var webhook webhookResponse
+		if err := b.call("GET", link.Href, nil, &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook.webhook())
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook fetches a Webhook by UUID.
+func (b *Broker) GetWebhook(uuid string) (types.Webhook, error) {
+	log.Println("[DEBUG] broker - get webhook:", uuid)
+
+	if err := b.validate(); err != nil {
+		return types.Webhook{}, err
+	}
+
+	var res webhookResponse
+	err := b.call("GET", b.webhookURL(uuid), nil, &res)
+
+	return res.webhook(), err
+}
+
+// CreateWebhook creates a Webhook in the Pact Broker, returning it with the
+// UUID assigned by the Broker. If the Webhook has a UUID, it is created with
+// that UUID, or updated if it already exists, so that Webhook definitions can
+// be applied repeatedly.
+func (b *Broker) CreateWebhook(webhook types.Webhook) (types.Webhook, error) {
+	log.Println("[DEBUG] broker - create webhook:", webhook.Description)
+
+	if err := b.validate(); err != nil {
+		return types.Webhook{}, err
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return types.Webhook{}, err
+	}
+
+	method, endpoint := "POST", fmt.Sprintf("%s/webhooks", b.URL)
+	if webhook.UUID != "" {
+		method, endpoint = "PUT", b.webhookURL(webhook.UUID)
+	}
+
+	return b.sendWebhook(method, endpoint, webhook)
+}
+
+// UpdateWebhook replaces the Webhook with the same UUID.
+func (b *Broker) UpdateWebhook(webhook types.Webhook) (types.Webhook, error) {
+	log.Println("[DEBUG] broker - update webhook:", webhook.UUID)
+
+	if err := b.validate(); err != nil {
+		return types.Webhook{}, err
+	}
+
+	if webhook.UUID == "" {
+		return types.Webhook{}, fmt.Errorf("Webhook UUID is mandatory")
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return types.Webhook{}, err
+	}
+
+	return b.sendWebhook("PUT", b.webhookURL(webhook.UUID), webhook)
+}
+
+// DeleteWebhook deletes a Webhook by UUID.
+func (b *Broker) DeleteWebhook(uuid string) error {
+	log.Println("[DEBUG] broker - delete webhook:", uuid)
+
+	if err := b.validate(); err != nil {
+		return err
+	}
+
+	return b.call("DELETE", b.webhookURL(uuid), nil, nil)
+}
+
+// ExecuteWebhook test executes a Webhook, using the latest matching Pact for
+// any template parameters, and returns the outcome.
+func (b *Broker) ExecuteWebhook(uuid string) (types.WebhookExecution, error) {
+	log.Println("[DEBUG] broker - execute webhook:", uuid)
+	var res types.WebhookExecution
+
+	if err := b.validate(); err != nil {
+		return res, err
+	}
+
+	err := b.call("POST", fmt.Sprintf("%s/execute", b.webhookURL(uuid)), map[string]string{}, &res)
+	if err == nil && res.Response != nil {
+		res.Success = res.Response.Status >= 200 && res.Response.Status < 300
+	}
+
+	return res, err
+}
+
+// sendWebhook sends the Webhook to the Broker, without its UUID which is
+// part of the URL.
+func (b *Broker) sendWebhook(method string, endpoint string, webhook types.Webhook) (types.Webhook, error) {
+	uuid := webhook.UUID
+	webhook.UUID = ""
+
+	var res webhookResponse
+	if err := b.call(method, endpoint, webhook, &res); err != nil {
+		return types.Webhook{}, err
+	}
+
+	created := res.webhook()
+	if created.UUID == "" {
+		created.UUID = uuid
+	}
+	return created, nil
+}
+
+// webhookURL is the URL of the Webhook with the given UUID.
+func (b *Broker) webhookURL(uuid string) string {
+	return fmt.Sprintf("%s/webhooks/%s", b.URL, url.PathEscape(uuid))
+}
@@ -0,0 +1,185 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// Pretend to be a Broker for the webhooks API, storing webhooks in memory.
+func setupMockWebhookBroker() *httptest.Server {
+	webhooks := map[string]map[string]interface{}{}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	respond := func(w http.ResponseWriter, uuid string) {
+		webhook := webhooks[uuid]
+		webhook["_links"] = map[string]interface{}{"self": map[string]string{"href": fmt.Sprintf("%s/webhooks/%s", server.URL, uuid)}}
+		json.NewEncoder(w).Encode(webhook)
+	}
+
+	mux.HandleFunc("/webhooks", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Content-Type", "application/hal+json")
+		if req.Method == "POST" {
+			var webhook map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&webhook)
+			uuid := fmt.Sprintf("generated-%d", len(webhooks)+1)
+			webhooks[uuid] = webhook
+			w.WriteHeader(201)
+			respond(w, uuid)
+			return
+		}
+
+		var links []map[string]string
+		for uuid := range webhooks {
+			links = append(links, map[string]string{"href": fmt.Sprintf("%s/webhooks/%s", server.URL, uuid)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"_links": map[string]interface{}{"pb:webhooks": links}})
+	})
+
+	mux.HandleFunc("/webhooks/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Content-Type", "application/hal+json")
+		uuid := strings.TrimPrefix(req.URL.Path, "/webhooks/")
+
+		if strings.HasSuffix(uuid, "/execute") {
+			fmt.Fprint(w, `{"logs":"POST https://ci.example.com/build\n202 Accepted","response":{"status":202,"body":"queued"}}`)
+			return
+		}
+
+		switch req.Method {
+		case "PUT":
+			var webhook map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&webhook)
+			if _, ok := webhook["uuid"]; ok {
+				w.WriteHeader(400)
+				fmt.Fprint(w, "unexpected uuid in body")
+				return
+			}
+			webhooks[uuid] = webhook
+			respond(w, uuid)
+		case "DELETE":
+			delete(webhooks, uuid)
+			w.WriteHeader(204)
+		default:
+			if _, ok := webhooks[uuid]; !ok {
+				w.WriteHeader(404)
+				return
+			}
+			respond(w, uuid)
+		}
+	})
+
+	return server
+}
+
+var webhookYAML = `
+webhooks:
+  - uuid: trigger-bobby-build-0001
+    description: Trigger bobby build
+    provider: bobby
+    events: [contract_content_changed]
+    request:
+      method: POST
+      url: https://ci.example.com/build
+      headers:
+        Content-Type: application/json
+      body:
+        pactUrl: ${pactbroker.pactUrl}
+        tags: [a, b]
+  - description: Notify chat
+    consumer:
+      name: billy
+    events:
+      - name: provider_verification_failed
+    request:
+      method: POST
+      url: https://chat.example.com/notify
+`
+
+func TestBroker_Webhooks(t *testing.T) {
+	s := setupMockWebhookBroker()
+	defer s.Close()
+	broker := &Broker{URL: s.URL}
+
+	webhooks, err := types.ParseWebhooks([]byte(webhookYAML))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var created []types.Webhook
+	for _, webhook := range webhooks {
+		c, err := broker.CreateWebhook(webhook)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		created = append(created, c)
+	}
+
+	if created[0].UUID != "trigger-bobby-build-0001" || created[1].UUID != "generated-2" {
+		t.Fatalf("Unexpected UUIDs %s and %s", created[0].UUID, created[1].UUID)
+	}
+	if created[0].Provider.Name != "bobby" || created[0].Events[0].Name != types.WebhookEventContractContentChanged {
+		t.Fatalf("Unexpected webhook %+v", created[0])
+	}
+	if body, ok := created[0].Request.Body.(map[string]interface{}); !ok || body["pactUrl"] != "${pactbroker.pactUrl}" {
+		t.Fatalf("Unexpected webhook body %v", created[0].Request.Body)
+	}
+
+	list, err := broker.ListWebhooks()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 webhooks but got %d", len(list))
+	}
+
+	created[1].Description = "Notify team chat"
+	updated, err := broker.UpdateWebhook(created[1])
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if updated.Description != "Notify team chat" {
+		t.Fatalf("Expected webhook to be updated but got %+v", updated)
+	}
+
+	res, err := broker.ExecuteWebhook("trigger-bobby-build-0001")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !res.Success || !strings.Contains(res.Logs, "202 Accepted") {
+		t.Fatalf("Unexpected execution result %+v", res)
+	}
+
+	if err = broker.DeleteWebhook("generated-2"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, err = broker.GetWebhook("generated-2"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestBroker_WebhooksFail(t *testing.T) {
+	broker := &Broker{URL: "http://localhost"}
+
+	if _, err := broker.CreateWebhook(types.Webhook{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	_, err := broker.UpdateWebhook(types.Webhook{
+		Events:  []types.WebhookEvent{{Name: types.WebhookEventContractPublished}},
+		Request: types.WebhookRequest{Method: "POST", URL: "http://example.com"},
+	})
+	if err == nil || err.Error() != "Webhook UUID is mandatory" {
+		t.Fatalf("Expected UUID error but got: %v", err)
+	}
+
+	_, err = types.ParseWebhooks([]byte("webhooks:\n  - events: [contract_published]\n"))
+	if err == nil || !strings.Contains(err.Error(), "webhook 1") {
+		t.Fatalf("Expected validation error but got: %v", err)
+	}
+}
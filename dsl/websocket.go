@@ -0,0 +1,468 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pact-foundation/pact-go/utils"
+)
+
+// WebSocketInteraction is a conversation over a WebSocket: the handshake
+// request opening the connection, and the ordered frames the client and
+// server then exchange, e.g.
+//
+//	ws := (&dsl.WebSocketInteraction{}).
+//		Given("ACME is trading").
+//		UponReceiving("a subscription to prices").
+//		WithHandshake(dsl.Request{Path: dsl.String("/prices")}).
+//		Sends(map[string]interface{}{"subscribe": "ACME"}).
+//		Receives(map[string]interface{}{"symbol": "ACME", "price": dsl.Like(1.5)})
+//
+// The content of a frame is sent in a text frame as JSON, or as text if it
+// is a string, and may contain Matchers. []byte and BinaryBody content is
+// sent in a binary frame.
+//
+// A WebSocketMockServer plays the server side of the conversation for a
+// Consumer, and Verify plays the client side against the Provider.
+type WebSocketInteraction struct {
+	// Description of the conversation.
+	Description string
+
+	// State of the Provider, set up before the conversation is verified.
+	State string
+
+	// Request is the handshake request. The method defaults to GET, and
+	// the Connection, Upgrade and Sec-WebSocket headers are added.
+	Request Request
+
+	// Frames are the messages sent after the handshake, in order.
+	Frames []WebSocketFrame
+}
+
+// WebSocketFrame is a message of a WebSocketInteraction.
+type WebSocketFrame struct {
+	// FromClient is true for a message sent by the client to the server,
+	// and false for one sent by the server to the client.
+	FromClient bool
+
+	// Content of the message, which may contain Matchers.
+	Content interface{}
+}
+
+// Given specifies a provider state. Optional.
+func (w *WebSocketInteraction) Given(state string) *WebSocketInteraction {
+	w.State = state
+	return w
+}
+
+// UponReceiving specifies the description of the conversation. Mandatory.
+func (w *WebSocketInteraction) UponReceiving(description string) *WebSocketInteraction {
+	w.Description = description
+	return w
+}
+
+// WithHandshake specifies the handshake request. Mandatory.
+func (w *WebSocketInteraction) WithHandshake(request Request) *WebSocketInteraction {
+	w.Request = request
+	return w
+}
+
+// Sends adds a message sent by the client to the server.
+func (w *WebSocketInteraction) Sends(content interface{}) *WebSocketInteraction {
+	w.Frames = append(w.Frames, WebSocketFrame{FromClient: true, Content: content})
+	return w
+}
+
+// Receives adds a message sent by the server to the client.
+func (w *WebSocketInteraction) Receives(content interface{}) *WebSocketInteraction {
+	w.Frames = append(w.Frames, WebSocketFrame{FromClient: false, Content: content})
+	return w
+}
+
+// Validate checks the Matchers of the handshake and frames, as
+// Interaction.Validate does. It returns a *MatcherError listing every
+// problem found.
+func (w *WebSocketInteraction) Validate() error {
+	problems := validateMatchers([]string{"request"}, w.Request)
+	problems = append(problems, validateHeaderNames([]string{"request", "headers"}, w.Request.Headers)...)
+	if path, ok := w.Request.Path.(*PathMatcher); ok {
+		problems = append(problems, path.validate([]string{"request", "path"})...)
+	}
+	if len(w.Frames) == 0 {
+		problems = append(problems, "$.frames: a conversation must have at least one frame")
+	}
+	for i, frame := range w.Frames {
+		path := []string{"frames", fmt.Sprintf("[%d]", i)}
+		if body, ok := webSocketBinary(frame.Content); ok {
+			problems = append(problems, body.validateBody(path)...)
+		} else {
+			problems = append(problems, validateMatchers(path, frame.Content)...)
+		}
+	}
+
+	return newMatcherError(w.Description, problems)
+}
+
+// webSocketScript is a WebSocketInteraction with its Matchers converted to
+// examples and matching rules.
+type webSocketScript struct {
+	description string
+	handshake   PactRequest
+	frames      []webSocketFrame
+	rules       matchingRules
+}
+
+// webSocketFrame is a frame of a webSocketScript.
+type webSocketFrame struct {
+	fromClient bool
+	binary     *BinaryBody
+	example    interface{}
+}
+
+// script converts the interaction to a webSocketScript.
+func (w *WebSocketInteraction) script() (*webSocketScript, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	request := w.Request
+	if request.Method == "" {
+		request.Method = "GET"
+	}
+	var pact PactFile
+	if err := pact.AddInteraction(Interaction{Description: w.Description, Request: request}); err != nil {
+		return nil, err
+	}
+
+	s := &webSocketScript{description: w.Description, handshake: pact.Interactions[0].Request}
+	rules := map[string]interface{}{}
+	for i, frame := range w.Frames {
+		f := webSocketFrame{fromClient: frame.FromClient}
+		if body, ok := webSocketBinary(frame.Content); ok {
+			f.binary = body
+		} else {
+			example, err := extractMatchers([]string{"frames", fmt.Sprintf("[%d]", i)}, frame.Content, rules)
+			if err != nil {
+				return nil, err
+			}
+			f.example = example
+		}
+		s.frames = append(s.frames, f)
+	}
+	s.rules = parseMatchingRules(rules)
+
+	return s, nil
+}
+
+// webSocketBinary returns the BinaryBody of []byte or BinaryBody content.
+func webSocketBinary(content interface{}) (*BinaryBody, bool) {
+	switch c := content.(type) {
+	case []byte:
+		return Binary(c), true
+	case *BinaryBody:
+		return c, true
+	}
+	return nil, false
+}
+
+// send writes the example of a frame.
+func (f webSocketFrame) send(conn *wsConn) error {
+	if f.binary != nil {
+		return conn.writeFrame(wsBinary, f.binary.Bytes())
+	}
+	if text, ok := f.example.(string); ok {
+		return conn.writeFrame(wsText, []byte(text))
+	}
+	data, err := json.Marshal(f.example)
+	if err != nil {
+		return err
+	}
+	return conn.writeFrame(wsText, data)
+}
+
+// receive reads the next message and compares it with the frame at index i,
+// recording any mismatches. It returns an error if no message was read.
+func (s *webSocketScript) receive(c *comparison, conn *wsConn, i int, timeout time.Duration) error {
+	path := []string{"frames", fmt.Sprintf("[%d]", i)}
+	f := s.frames[i]
+
+	conn.conn.SetReadDeadline(time.Now().Add(timeout))
+	opcode, message, err := conn.readMessage()
+	conn.conn.SetReadDeadline(time.Time{})
+	if err == io.EOF {
+		return fmt.Errorf("%s: the connection was closed before the frame was received", formatPath(path))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: unable to receive the frame: %v", formatPath(path), err)
+	}
+
+	if f.binary != nil {
+		switch {
+		case opcode != wsBinary:
+			c.mismatch(path, "expected a binary frame but received a text frame")
+		case f.binary.exact && !bytes.Equal(f.binary.Bytes(), message):
+			c.mismatch(path, "expected %d bytes of %s but received %d bytes of %s", len(f.binary.Bytes()), f.binary.ContentType(), len(message), detectContentType(message))
+		case !f.binary.exact && !matchesContentType(message, f.binary.ContentType(), f.binary.Bytes()):
+			c.mismatch(path, "expected content of type %s but received %s", f.binary.ContentType(), detectContentType(message))
+		}
+		return nil
+	}
+
+	if opcode != wsText {
+		c.mismatch(path, "expected a text frame but received a binary frame")
+		return nil
+	}
+	var actual interface{} = string(message)
+	if _, ok := f.example.(string); !ok {
+		decoder := json.NewDecoder(bytes.NewReader(message))
+		decoder.UseNumber()
+		if err := decoder.Decode(&actual); err != nil {
+			c.mismatch(path, "expected JSON but received %q", string(message))
+			return nil
+		}
+	}
+	c.compare(path, f.example, actual)
+	return nil
+}
+
+// play plays one side of the conversation on the connection, sending the
+// frames of that side and receiving those of the other. It stops at the
+// first frame that could not be sent or received, and returns an error
+// listing any mismatches.
+func (s *webSocketScript) play(conn *wsConn, client bool, timeout time.Duration) error {
+	c := &comparison{rules: s.rules}
+	var problems []string
+	for i, f := range s.frames {
+		var err error
+		if f.fromClient == client {
+			if err = f.send(conn); err != nil {
+				err = fmt.Errorf("$.frames[%d]: unable to send the frame: %v", i, err)
+			}
+		} else {
+			err = s.receive(c, conn, i, timeout)
+		}
+		if err != nil {
+			problems = append(problems, err.Error())
+			break
+		}
+	}
+
+	for _, m := range c.mismatches {
+		problems = append(problems, m.String())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the conversation '%s' did not match:\n\t%s", s.description, strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// Verify plays the client side of the conversation against the Provider
+// at baseURL, e.g. "ws://localhost:8080", sending the example handshake and
+// client frames, and checking the frames the Provider sends. The Provider
+// must be in the interaction's State. Each frame must be received within
+// the timeout.
+func (w *WebSocketInteraction) Verify(baseURL string, timeout time.Duration) error {
+	s, err := w.script()
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	for name, value := range s.handshake.Headers {
+		header.Set(name, value)
+	}
+	url := strings.TrimSuffix(baseURL, "/") + s.handshake.Path
+	if len(s.handshake.Query) > 0 {
+		url += "?" + s.handshake.Query.String()
+	}
+
+	log.Printf("[DEBUG] verifying WebSocket conversation '%s' with %s", w.Description, url)
+	conn, _, err := dialWebSocket(url, header, timeout)
+	if err != nil {
+		return fmt.Errorf("unable to open the WebSocket for '%s': %v", w.Description, err)
+	}
+	defer conn.close()
+
+	return s.play(conn, true, timeout)
+}
+
+// WebSocketMockServer plays the server side of WebSocketInteractions for a
+// Consumer under test. A connection whose handshake matches an interaction
+// is upgraded, and its frames exchanged: the server frames are sent as
+// their examples, and the client frames checked against their Matchers.
+// The connection is closed at the end of the conversation, or on its first
+// mismatch.
+//
+// Call Verify once the Consumer is done to check that every conversation
+// was played and matched.
+type WebSocketMockServer struct {
+	// Interactions are the conversations to play.
+	Interactions []*WebSocketInteraction
+
+	// Host to listen on, defaults to "localhost".
+	Host string
+
+	// Port to listen on. If 0, a free port is chosen.
+	Port int
+
+	// Timeout to wait for each frame from the client, defaults to 10
+	// seconds.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	scripts []*webSocketScript
+	played  map[*webSocketScript]bool
+	errors  []string
+	server  *http.Server
+}
+
+// Start starts serving the interactions in the background.
+func (s *WebSocketMockServer) Start() error {
+	if err := s.Load(); err != nil {
+		return err
+	}
+
+	if s.Host == "" {
+		s.Host = "localhost"
+	}
+
+	if s.Port == 0 {
+		port, err := utils.GetFreePort()
+		if err != nil {
+			return err
+		}
+		s.Port = port
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.Host, s.Port))
+	if err != nil {
+		return err
+	}
+	s.server = &http.Server{Handler: s}
+
+	log.Printf("[INFO] WebSocket mock server serving %d interactions on %s", len(s.scripts), s.URL())
+	go s.server.Serve(listener)
+
+	return nil
+}
+
+// Stop stops the server.
+func (s *WebSocketMockServer) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	log.Println("[DEBUG] stopping WebSocket mock server")
+	err := s.server.Close()
+	s.server = nil
+	return err
+}
+
+// URL is the base WebSocket URL of the running server.
+func (s *WebSocketMockServer) URL() string {
+	return fmt.Sprintf("ws://%s:%d", s.Host, s.Port)
+}
+
+// Load validates the interactions, and clears the results of any earlier
+// conversations. It is called by Start, and need only be called directly
+// when using the WebSocketMockServer as an http.Handler.
+func (s *WebSocketMockServer) Load() error {
+	if len(s.Interactions) == 0 {
+		return errors.New("no interactions found to serve")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts = nil
+	for _, interaction := range s.Interactions {
+		script, err := interaction.script()
+		if err != nil {
+			return err
+		}
+		s.scripts = append(s.scripts, script)
+	}
+	s.played = map[*webSocketScript]bool{}
+	s.errors = nil
+
+	return nil
+}
+
+// ServeHTTP plays the first interaction whose handshake matches the request.
+func (s *WebSocketMockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var script *webSocketScript
+	var closestMismatches []Mismatch
+	for _, candidate := range s.scripts {
+		mismatches := matchRequest(candidate.handshake, r, nil)
+		if len(mismatches) == 0 {
+			script = candidate
+			break
+		}
+		if closestMismatches == nil || len(mismatches) < len(closestMismatches) {
+			closestMismatches = mismatches
+		}
+	}
+
+	if script == nil {
+		message := fmt.Sprintf("No WebSocket interaction found for %s %s", r.Method, r.URL)
+		log.Printf("[INFO] %s", message)
+		s.record(nil, errors.New(message))
+		writeStubError(w, http.StatusNotFound, message, closestMismatches)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.record(script, err)
+		writeStubError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	defer conn.close()
+
+	log.Printf("[DEBUG] WebSocket mock server playing '%s'", script.description)
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	s.record(script, script.play(conn, false, timeout))
+}
+
+// record records a conversation as played, and its error if any.
+func (s *WebSocketMockServer) record(script *webSocketScript, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if script != nil {
+		s.played[script] = true
+	}
+	if err != nil {
+		s.errors = append(s.errors, err.Error())
+	}
+}
+
+// Verify checks that every interaction was played, and that the Consumer's
+// handshakes and frames matched.
+func (s *WebSocketMockServer) Verify() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	problems := append([]string{}, s.errors...)
+	for _, script := range s.scripts {
+		if !s.played[script] {
+			problems = append(problems, fmt.Sprintf("the conversation '%s' was not played", script.description))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("WebSocket verification failed:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package dsl
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebSocket opcodes, from RFC 6455.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// wsGUID is appended to the Sec-WebSocket-Key of a handshake to compute the
+// Sec-WebSocket-Accept.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketFrame is the largest frame payload read, to guard against
+// malformed lengths.
+const maxWebSocketFrame = 16 * 1024 * 1024
+
+// wsConn is a minimal WebSocket connection, enough to exchange the text and
+// binary messages of a WebSocketInteraction.
+type wsConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	client bool
+}
+
+// wsAccept computes the Sec-WebSocket-Accept for a Sec-WebSocket-Key.
+func wsAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// upgradeWebSocket completes the server side of a WebSocket handshake.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, errors.New("not a WebSocket handshake: expected the headers Connection: Upgrade and Upgrade: websocket")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("not a WebSocket handshake: the Sec-WebSocket-Key header is missing")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("the server does not support WebSocket connections")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAccept(key) + "\r\n"
+	if protocol := r.Header.Get("Sec-WebSocket-Protocol"); protocol != "" {
+		response += "Sec-WebSocket-Protocol: " + strings.TrimSpace(strings.Split(protocol, ",")[0]) + "\r\n"
+	}
+	if _, err = conn.Write([]byte(response + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// dialWebSocket opens a WebSocket connection, sending the handshake headers,
+// and returns the handshake response. The URL may be ws(s):// or http(s)://.
+func dialWebSocket(rawURL string, header http.Header, timeout time.Duration) (*wsConn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secure := u.Scheme == "wss" || u.Scheme == "https"
+	host := u.Host
+	if u.Port() == "" {
+		if secure {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if secure {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	u.Scheme = map[bool]string{true: "https", false: "http"}[secure]
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err = req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, res, fmt.Errorf("expected the handshake response 101 Switching Protocols but received %s", res.Status)
+	}
+	if res.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, res, errors.New("the handshake response has an invalid Sec-WebSocket-Accept header")
+	}
+
+	return &wsConn{conn: conn, r: r, client: true}, res, nil
+}
+
+// headerContains reports whether a header contains a token, ignoring case,
+// e.g. "upgrade" in "Connection: keep-alive, Upgrade".
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame writes a single, final frame. Frames from a client are masked.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode, 0}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	if c.client {
+		header[1] |= 0x80
+		mask := make([]byte, 4)
+		rand.Read(mask)
+		header = append(header, mask...)
+		masked := make([]byte, length)
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+
+	_, err := c.conn.Write(append(header, payload...))
+	return err
+}
+
+// readMessage reads a text or binary message, joining fragmented frames and
+// answering pings. It returns io.EOF once the other side closes.
+func (c *wsConn) readMessage() (byte, []byte, error) {
+	var opcode byte
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case wsPing:
+			if err = c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.writeFrame(wsClose, payload)
+			return 0, nil, io.EOF
+		case wsContinuation:
+			message = append(message, payload...)
+		default:
+			opcode, message = op, payload
+		}
+
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame, unmasking its payload.
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(c.r, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(c.r, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if length > maxWebSocketFrame {
+		return false, 0, nil, fmt.Errorf("frame of %d bytes is too large", length)
+	}
+
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(c.r, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		if masked {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// close sends a normal closure frame and closes the connection.
+func (c *wsConn) close() error {
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.writeFrame(wsClose, []byte{0x03, 0xE8})
+	return c.conn.Close()
+}
@@ -0,0 +1,176 @@
+package dsl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func priceConversation() *WebSocketInteraction {
+	return (&WebSocketInteraction{}).
+		Given("ACME is trading").
+		UponReceiving("a subscription to prices").
+		WithHandshake(Request{
+			Path:    PathTemplate("/prices/{symbol}", map[string]Matcher{"symbol": Term("ACME", "^[A-Z]+$")}),
+			Headers: MapMatcher{"Authorization": Term("Bearer 1234", "^Bearer .+$")},
+		}).
+		Sends(map[string]interface{}{"subscribe": Like("ACME")}).
+		Receives(map[string]interface{}{"symbol": Like("ACME"), "price": Like(1.5)}).
+		Receives("done").
+		Sends([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'})
+}
+
+func TestWebSocket_mockServer(t *testing.T) {
+	server := &WebSocketMockServer{Interactions: []*WebSocketInteraction{priceConversation()}, Timeout: time.Second}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer server.Stop()
+
+	header := http.Header{"Authorization": {"Bearer abc"}}
+	conn, _, err := dialWebSocket(server.URL()+"/prices/XYZ", header, time.Second)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer conn.close()
+
+	if err = conn.writeFrame(wsText, []byte(`{"subscribe": "XYZ"}`)); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	opcode, message, err := conn.readMessage()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var price map[string]interface{}
+	if err = json.Unmarshal(message, &price); err != nil || opcode != wsText || price["symbol"] != "ACME" || price["price"] != 1.5 {
+		t.Fatalf("Unexpected frame %d: %s", opcode, message)
+	}
+	if _, message, err = conn.readMessage(); err != nil || string(message) != "done" {
+		t.Fatalf("Unexpected frame: %s, %v", message, err)
+	}
+	if err = conn.writeFrame(wsBinary, []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, _, err = conn.readMessage(); err == nil {
+		t.Fatalf("Expected the server to close the connection")
+	}
+
+	if err = server.Verify(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func TestWebSocket_mockServerMismatches(t *testing.T) {
+	server := &WebSocketMockServer{Interactions: []*WebSocketInteraction{priceConversation()}, Timeout: time.Second}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer server.Stop()
+
+	if _, res, err := dialWebSocket(server.URL()+"/prices/xyz", http.Header{"Authorization": {"Bearer abc"}}, time.Second); err == nil || res.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected the handshake to be rejected")
+	}
+
+	conn, _, err := dialWebSocket(server.URL()+"/prices/XYZ", http.Header{"Authorization": {"Bearer abc"}}, time.Second)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer conn.close()
+	conn.writeFrame(wsText, []byte(`{"subscribe": 1}`))
+	conn.readMessage()
+	conn.readMessage()
+	conn.writeFrame(wsText, []byte("not binary"))
+	conn.readMessage()
+
+	err = server.Verify()
+	if err == nil {
+		t.Fatalf("Expected the verification to fail")
+	}
+	for _, expected := range []string{
+		"No WebSocket interaction found for GET /prices/xyz",
+		"$.frames[0].subscribe: expected a string",
+		"$.frames[3]: expected a binary frame but received a text frame",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected '%s' in: %v", expected, err)
+		}
+	}
+}
+
+func TestWebSocket_mockServerNotPlayed(t *testing.T) {
+	server := &WebSocketMockServer{Interactions: []*WebSocketInteraction{priceConversation()}}
+	if err := server.Load(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	err := server.Verify()
+	if err == nil || !strings.Contains(err.Error(), "the conversation 'a subscription to prices' was not played") {
+		t.Fatalf("Expected the conversation not to have been played, got %v", err)
+	}
+}
+
+func TestWebSocket_Verify(t *testing.T) {
+	cases := []struct {
+		price    interface{}
+		last     []byte
+		expected string
+	}{
+		{2.25, []byte("done"), ""},
+		{"2.25", []byte("done"), "$.frames[1].price: expected a number"},
+		{2.25, nil, "$.frames[2]: the connection was closed before the frame was received"},
+	}
+
+	for _, c := range cases {
+		provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer 1234" || r.URL.Path != "/prices/ACME" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			conn, err := upgradeWebSocket(w, r)
+			if err != nil {
+				return
+			}
+			defer conn.close()
+
+			_, message, err := conn.readMessage()
+			if err != nil || string(message) != `{"subscribe":"ACME"}` {
+				return
+			}
+			price, _ := json.Marshal(map[string]interface{}{"symbol": "ACME", "price": c.price})
+			conn.writeFrame(wsText, price)
+			if c.last == nil {
+				return
+			}
+			conn.writeFrame(wsText, c.last)
+			conn.readMessage()
+		}))
+
+		err := priceConversation().Verify(strings.Replace(provider.URL, "http", "ws", 1), time.Second)
+		provider.Close()
+
+		if c.expected == "" && err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if c.expected != "" && (err == nil || !strings.Contains(err.Error(), c.expected)) {
+			t.Fatalf("Expected '%s' but got %v", c.expected, err)
+		}
+	}
+}
+
+func TestWebSocket_Validate(t *testing.T) {
+	w := (&WebSocketInteraction{}).
+		UponReceiving("an invalid conversation").
+		WithHandshake(Request{Path: String("/")}).
+		Receives(map[string]interface{}{"id": Term("abc", "^[0-9]+$")})
+
+	err := w.Validate()
+	if err == nil {
+		t.Fatalf("Expected the conversation to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "$.frames[0].id") {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+}
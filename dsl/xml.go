@@ -0,0 +1,339 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// XMLElement is an element of an XML body, with Matchers for its attributes,
+// text and child elements. Use XML to create one, e.g.
+//
+//	body := dsl.XML("ns:user").
+//		Namespace("ns", "http://example.com/user").
+//		Attribute("id", dsl.Like("1")).
+//		Child(dsl.XML("ns:name").Text(dsl.Term("Billy", `^\w+$`))).
+//		EachLike(dsl.XML("ns:role").Text(dsl.String("admin")), 1)
+//
+// When sent to the mock service, an XMLElement is its example document.
+// Pact.Verify matches requests with its Matchers in front of the mock
+// service, and Pact.WritePact adds them to the Pact file it writes.
+// They become matching rules, as they do when added to a PactFile, with
+// paths such as "$.body.user.name[0].#text" and "$.body.user.@id", that the
+// StubServer understands.
+//
+// The rules are only enforced by Pact.Verify and the StubServer. The
+// provider verifier does not apply them to XML, and compares a response
+// body with the example document, so a provider must return the example
+// exactly.
+type XMLElement struct {
+	name       string
+	namespaces []xmlAttribute
+	attributes []xmlAttribute
+	text       StringMatcher
+	children   []xmlChild
+}
+
+// xmlAttribute is an attribute, or namespace declaration, of an XMLElement.
+type xmlAttribute struct {
+	name  string
+	value StringMatcher
+}
+
+// xmlChild is a child element, repeated at least min times if eachLike.
+type xmlChild struct {
+	element  *XMLElement
+	eachLike bool
+	min      int
+}
+
+// XML creates an element of an XML body. The name may include a namespace
+// prefix, e.g. "soap:Envelope".
+func XML(name string) *XMLElement {
+	return &XMLElement{name: name}
+}
+
+// Namespace declares a namespace on the element. An empty prefix declares
+// the default namespace.
+func (e *XMLElement) Namespace(prefix string, uri string) *XMLElement {
+	e.namespaces = append(e.namespaces, xmlAttribute{prefix, String(uri)})
+	return e
+}
+
+// Attribute adds an attribute, which is either a String or a Matcher such as
+// Like or Term.
+func (e *XMLElement) Attribute(name string, value StringMatcher) *XMLElement {
+	e.attributes = append(e.attributes, xmlAttribute{name, value})
+	return e
+}
+
+// Text sets the text of the element, which is either a String or a Matcher
+// such as Like or Term.
+func (e *XMLElement) Text(value StringMatcher) *XMLElement {
+	e.text = value
+	return e
+}
+
+// Child adds child elements, each of which must appear exactly as given.
+func (e *XMLElement) Child(children ...*XMLElement) *XMLElement {
+	for _, c := range children {
+		e.children = append(e.children, xmlChild{element: c})
+	}
+	return e
+}
+
+// EachLike adds a child element that can be repeated, at least min times,
+// with each repetition matched by type like the example. min must be 1 or
+// greater.
+func (e *XMLElement) EachLike(child *XMLElement, min int) *XMLElement {
+	e.children = append(e.children, xmlChild{element: child, eachLike: true, min: min})
+	return e
+}
+
+// String returns the example XML document.
+func (e *XMLElement) String() string {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	e.write(&b)
+	return b.String()
+}
+
+// ContentType returns the media type of the document, for its Content-Type
+// header.
+func (e *XMLElement) ContentType() string {
+	return "application/xml"
+}
+
+// MarshalJSON writes the example XML document as a JSON string, as sent to
+// the mock service.
+func (e *XMLElement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+// MatchingRules returns the matching rules of the element as the body rules
+// of a v3 Pact file, e.g. {"body": {"$.user.@id": {"matchers": [...]}}}.
+func (e *XMLElement) MatchingRules() map[string]interface{} {
+	rules := map[string]interface{}{}
+	e.bodyRules(nil, rules)
+
+	body := make(map[string]interface{}, len(rules))
+	for path, rule := range rules {
+		body[path] = map[string]interface{}{"matchers": []interface{}{rule}}
+	}
+	return map[string]interface{}{"body": body}
+}
+
+// bodyRules adds the matching rules of the element, as the root element of
+// the body at path.
+func (e *XMLElement) bodyRules(path []string, rules map[string]interface{}) {
+	e.rules(child(path, localName(e.name)), rules)
+}
+
+// validateBody checks the Matchers of the element, as the root element of
+// the body at path.
+func (e *XMLElement) validateBody(path []string) []string {
+	return e.validate(child(path, localName(e.name)))
+}
+
+// write writes the element and its children as XML.
+func (e *XMLElement) write(b *bytes.Buffer) {
+	b.WriteString("<" + e.name)
+	for _, ns := range e.namespaces {
+		name := "xmlns"
+		if ns.name != "" {
+			name += ":" + ns.name
+		}
+		writeXMLAttribute(b, name, ns.value)
+	}
+	for _, attribute := range e.attributes {
+		writeXMLAttribute(b, attribute.name, attribute.value)
+	}
+
+	if e.text == nil && len(e.children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">")
+
+	if e.text != nil {
+		example, _ := matcherExample(e.text)
+		xml.EscapeText(b, []byte(example))
+	}
+	for _, c := range e.children {
+		n := 1
+		if c.eachLike && c.min > 1 {
+			n = c.min
+		}
+		for i := 0; i < n; i++ {
+			c.element.write(b)
+		}
+	}
+	b.WriteString("</" + e.name + ">")
+}
+
+func writeXMLAttribute(b *bytes.Buffer, name string, value StringMatcher) {
+	example, _ := matcherExample(value)
+	b.WriteString(" " + name + `="`)
+	xml.EscapeText(b, []byte(example))
+	b.WriteString(`"`)
+}
+
+// rules adds the matching rules of the element at path, keyed by their
+// path, e.g. "$.user.@id".
+func (e *XMLElement) rules(path []string, rules map[string]interface{}) {
+	for _, attribute := range e.attributes {
+		if _, rule := matcherExample(attribute.value); rule != nil {
+			rules[formatPath(child(path, "@"+localName(attribute.name)))] = rule
+		}
+	}
+	if e.text != nil {
+		if _, rule := matcherExample(e.text); rule != nil {
+			rules[formatPath(child(path, "#text"))] = rule
+		}
+	}
+
+	index := map[string]int{}
+	for _, c := range e.children {
+		name := localName(c.element.name)
+		if c.eachLike {
+			min := 1
+			if c.min > 1 {
+				min = c.min
+			}
+			rules[formatPath(child(path, name))] = map[string]interface{}{"match": "type", "min": min}
+			c.element.rules(append(child(path, name), "[*]"), rules)
+			index[name] += min
+			continue
+		}
+		c.element.rules(append(child(path, name), fmt.Sprintf("[%d]", index[name])), rules)
+		index[name]++
+	}
+}
+
+// validate checks the Matchers of the element, like validateMatchers.
+func (e *XMLElement) validate(path []string) []string {
+	var problems []string
+	for _, attribute := range e.attributes {
+		problems = append(problems, validateMatchers(child(path, "@"+localName(attribute.name)), attribute.value)...)
+	}
+	if e.text != nil {
+		problems = append(problems, validateMatchers(child(path, "#text"), e.text)...)
+	}
+
+	index := map[string]int{}
+	for _, c := range e.children {
+		name := localName(c.element.name)
+		if c.eachLike {
+			if c.min < 1 {
+				problems = append(problems, fmt.Sprintf("%s: EachLike must have a minimum of at least 1, got %d", formatPath(child(path, name)), c.min))
+			}
+			problems = append(problems, c.element.validate(append(child(path, name), "[*]"))...)
+			continue
+		}
+		problems = append(problems, c.element.validate(append(child(path, name), fmt.Sprintf("[%d]", index[name])))...)
+		index[name]++
+	}
+	return problems
+}
+
+// matcherExample returns the example value of a String or Matcher, and the
+// matching rule of the Matcher.
+func matcherExample(value StringMatcher) (string, map[string]interface{}) {
+	generic, err := decodeGeneric(value)
+	if err != nil {
+		return "", nil
+	}
+
+	rules := map[string]interface{}{}
+	example := extractGeneric([]string{}, generic, rules)
+	if examples, ok := example.([]interface{}); ok && len(examples) > 0 {
+		example = examples[0]
+	}
+	rule, _ := rules["$"].(map[string]interface{})
+	return fmt.Sprintf("%v", example), rule
+}
+
+// localName removes the namespace prefix from a name.
+func localName(name string) string {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// isXMLContentType reports whether the content type is an XML media type.
+func isXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// parseXML decodes an XML document into the form matching rules apply to:
+// the root element is keyed by its local name, and each element is an object
+// of its attributes ("@name"), its text ("#text"), its namespace ("@xmlns")
+// and arrays of its child elements, keyed by local name.
+func parseXML(data string) (interface{}, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+
+	var root map[string]interface{}
+	var stack []map[string]interface{}
+	var text []*bytes.Buffer
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := map[string]interface{}{}
+			if t.Name.Space != "" {
+				element["@xmlns"] = t.Name.Space
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				element["@"+attr.Name.Local] = attr.Value
+			}
+
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				root = map[string]interface{}{t.Name.Local: element}
+			} else {
+				parent := stack[len(stack)-1]
+				siblings, _ := parent[t.Name.Local].([]interface{})
+				parent[t.Name.Local] = append(siblings, element)
+			}
+			stack = append(stack, element)
+			text = append(text, &bytes.Buffer{})
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1].Write(t)
+			}
+		case xml.EndElement:
+			if s := strings.TrimSpace(text[len(text)-1].String()); s != "" {
+				stack[len(stack)-1]["#text"] = s
+			}
+			stack = stack[:len(stack)-1]
+			text = text[:len(text)-1]
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
@@ -0,0 +1,151 @@
+package dsl
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func xmlUser() *XMLElement {
+	return XML("ns:user").
+		Namespace("ns", "http://example.com/user").
+		Attribute("id", Like("1")).
+		Attribute("type", String("admin")).
+		Child(XML("ns:name").Text(Term("Billy", `^\w+$`))).
+		EachLike(XML("ns:role").Text(Like("reader")), 2)
+}
+
+func TestXML_String(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<ns:user xmlns:ns="http://example.com/user" id="1" type="admin">` +
+		`<ns:name>Billy</ns:name><ns:role>reader</ns:role><ns:role>reader</ns:role></ns:user>`
+
+	if xmlUser().String() != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, xmlUser().String())
+	}
+
+	data, err := json.Marshal(Request{Body: XML("empty").Attribute("a", String("<&>"))})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var request struct{ Body string }
+	if err = json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if request.Body != xml.Header+`<empty a="&lt;&amp;&gt;"/>` {
+		t.Fatalf("Expected the body to be the escaped example document but got %s", request.Body)
+	}
+}
+
+func TestXML_MatchingRules(t *testing.T) {
+	expected := map[string]interface{}{
+		"body": map[string]interface{}{
+			"$.user.@id":           map[string]interface{}{"matchers": []interface{}{map[string]interface{}{"match": "type"}}},
+			"$.user.name[0].#text": map[string]interface{}{"matchers": []interface{}{map[string]interface{}{"match": "regex", "regex": `^\w+$`}}},
+			"$.user.role":          map[string]interface{}{"matchers": []interface{}{map[string]interface{}{"match": "type", "min": 2}}},
+			"$.user.role[*].#text": map[string]interface{}{"matchers": []interface{}{map[string]interface{}{"match": "type"}}},
+		},
+	}
+
+	if rules := xmlUser().MatchingRules(); !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Expected %v but got %v", expected, rules)
+	}
+}
+
+func TestXML_parseXML(t *testing.T) {
+	doc, err := parseXML(`<users xmlns="http://example.com/user"><user id="1"> Billy </user><user id="2"/></users>`)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"users": map[string]interface{}{
+			"@xmlns": "http://example.com/user",
+			"user": []interface{}{
+				map[string]interface{}{"@xmlns": "http://example.com/user", "@id": "1", "#text": "Billy"},
+				map[string]interface{}{"@xmlns": "http://example.com/user", "@id": "2"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Fatalf("Expected %v but got %v", expected, doc)
+	}
+
+	for _, invalid := range []string{"", "<a>", "<a/><b/>"} {
+		if _, err = parseXML(invalid); err == nil {
+			t.Fatalf("Expected an error parsing '%s'", invalid)
+		}
+	}
+}
+
+func TestXML_matchRequest(t *testing.T) {
+	var pact PactFile
+	err := pact.AddInteraction(Interaction{
+		Description: "a request to create a user",
+		Request: Request{
+			Method:  "POST",
+			Path:    String("/users"),
+			Headers: MapMatcher{"Content-Type": String("application/xml")},
+			Body:    xmlUser(),
+		},
+		Response: Response{Status: 201},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := pact.Interactions[0].Request
+	if expected.Body != xmlUser().String() {
+		t.Fatalf("Expected the body to be the example document but got %v", expected.Body)
+	}
+	if _, ok := expected.MatchingRules["$.body.user.role[*].#text"]; !ok {
+		t.Fatalf("Expected matching rules for the XML body but got %v", expected.MatchingRules)
+	}
+
+	cases := []struct {
+		body  string
+		paths string
+	}{
+		{`<u:user xmlns:u="http://example.com/user" id="42" type="admin"><u:name>Bob</u:name><u:role>a</u:role><u:role>b</u:role><u:role>c</u:role></u:user>`, ""},
+		{`<user xmlns="http://example.com/user" id="42" type="admin"><name>Bob</name><role>a</role><role>b</role></user>`, ""},
+		{`<user xmlns="http://example.com/other" id="42" type="user"><name>Bob Smith</name><role>a</role></user>`,
+			"$.body.user.@type,$.body.user.@xmlns,$.body.user.name[0].#text,$.body.user.name[0].@xmlns,$.body.user.role"},
+		{`not xml`, "$.body"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/xml")
+
+		var paths []string
+		for _, m := range matchRequest(expected, req, []byte(c.body)) {
+			paths = append(paths, m.Path)
+		}
+		if strings.Join(paths, ",") != c.paths {
+			t.Fatalf("Expected mismatches at '%s' for %s but got %v", c.paths, c.body, paths)
+		}
+	}
+}
+
+func TestXML_Validate(t *testing.T) {
+	i := (&Interaction{}).
+		UponReceiving("a request for a user").
+		WithRequest(Request{Method: "GET", Path: String("/users/1")}).
+		WillRespondWith(Response{
+			Status: 200,
+			Body: XML("user").
+				Attribute("id", Term("abc", `\d+`)).
+				EachLike(XML("role"), 0),
+		})
+
+	err := i.Validate()
+	if err == nil {
+		t.Fatalf("Expected the interaction to be invalid")
+	}
+	problems := err.(*MatcherError).Problems
+	if len(problems) != 2 || !strings.HasPrefix(problems[0], "$.response.body.user.@id: ") || !strings.HasPrefix(problems[1], "$.response.body.user.role: EachLike") {
+		t.Fatalf("Expected the attribute and EachLike to be invalid but got %v", problems)
+	}
+}
@@ -40,7 +40,7 @@ var loginRequest = ex.LoginRequest{
 	Password: password,
 }
 var commonHeaders = dsl.MapMatcher{
-	"Content-Type": term("application/json; charset=utf-8", `application\/json`),
+	"Content-Type": dsl.MediaType("application/json; charset=utf-8"),
 }
 
 // Use this to control the setup and teardown of Pact
@@ -159,7 +159,7 @@ func TestPactConsumerLoginHandler_UserExists(t *testing.T) {
 			Body:   body,
 			Headers: dsl.MapMatcher{
 				"X-Api-Correlation-Id": dsl.Like("100"),
-				"Content-Type":         term("application/json; charset=utf-8", `application\/json`),
+				"Content-Type":         dsl.MediaType("application/json; charset=utf-8"),
 			},
 		})
 
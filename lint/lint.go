@@ -0,0 +1,364 @@
+// Package lint checks Pact files for common mistakes that make contracts
+// brittle, such as literal timestamps and IDs that are matched exactly.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Severities of an Issue.
+const (
+	Error   = "error"
+	Warning = "warning"
+	Off     = "off"
+)
+
+// Rule is a check made of each Pact file.
+type Rule struct {
+	// Name of the rule, e.g. "literal-uuid".
+	Name string `json:"name"`
+
+	// Description of what the rule checks.
+	Description string `json:"description"`
+
+	// Severity of the rule's issues, unless configured otherwise.
+	Severity string `json:"severity"`
+}
+
+// Rules are the available rules.
+var Rules = []Rule{
+	{"literal-uuid", "UUIDs that are matched exactly, rather than with dsl.UUID()", Warning},
+	{"literal-date", "dates and timestamps that are matched exactly, rather than with dsl.Date() or dsl.Timestamp()", Warning},
+	{"exact-id", "response IDs that are matched exactly, rather than by type", Warning},
+	{"missing-provider-state", "requests other than GET, HEAD and OPTIONS without a provider state", Warning},
+	{"duplicate-description", "interactions with the same description and provider state", Error},
+	{"term-mismatch", "Term examples that don't match their own regex", Error},
+	{"unchecked-term", "Term regexes that can't be checked, as Go can't compile them, e.g. lookaheads", Warning},
+	{"body-size", "bodies larger than the maximum body size", Warning},
+}
+
+// DefaultMaxBodySize is the size in bytes of a JSON body above which the
+// body-size rule reports it.
+const DefaultMaxBodySize = 4096
+
+// Config configures the rules.
+type Config struct {
+	// Rules sets the severity of rules by name: "error", "warning", or "off"
+	// to disable the rule.
+	Rules map[string]string `json:"rules" yaml:"rules"`
+
+	// MaxBodySize is the size in bytes of a JSON body above which the
+	// body-size rule reports it. Defaults to DefaultMaxBodySize.
+	MaxBodySize int `json:"maxBodySize" yaml:"maxBodySize"`
+}
+
+// LoadConfig reads a Config from a YAML or JSON file.
+func LoadConfig(file string) (Config, error) {
+	var config Config
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return config, err
+	}
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("unable to parse lint config %s: %v", file, err)
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the configured rules and severities exist.
+func (c Config) Validate() error {
+	for name, severity := range c.Rules {
+		if _, ok := findRule(name); !ok {
+			return fmt.Errorf("unknown lint rule '%s'", name)
+		}
+		switch severity {
+		case Error, Warning, Off:
+		default:
+			return fmt.Errorf("invalid severity '%s' for lint rule '%s', must be one of 'error', 'warning' or 'off'", severity, name)
+		}
+	}
+	if c.MaxBodySize < 0 {
+		return fmt.Errorf("invalid maximum body size %d", c.MaxBodySize)
+	}
+	return nil
+}
+
+// severity is the configured severity of a rule.
+func (c Config) severity(name string) string {
+	if severity, ok := c.Rules[name]; ok {
+		return severity
+	}
+	rule, _ := findRule(name)
+	return rule.Severity
+}
+
+func findRule(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Issue is a problem found in a Pact file.
+type Issue struct {
+	// Rule that found the issue.
+	Rule string `json:"rule"`
+
+	// Severity of the issue: "error" or "warning".
+	Severity string `json:"severity"`
+
+	// Consumer and Provider of the Pact.
+	Consumer string `json:"consumer"`
+	Provider string `json:"provider"`
+
+	// Interaction is the description of the interaction with the issue.
+	Interaction string `json:"interaction"`
+
+	// Path to the value with the issue, e.g. "$.response.body.id".
+	Path string `json:"path,omitempty"`
+
+	// Message describing the issue and how to fix it.
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	location := fmt.Sprintf("%s-%s '%s'", i.Consumer, i.Provider, i.Interaction)
+	if i.Path != "" {
+		location += " " + i.Path
+	}
+	return fmt.Sprintf("%s: %s (%s)", location, i.Message, i.Rule)
+}
+
+// Report is the list of issues found in the Pact files.
+type Report struct {
+	Issues []Issue `json:"issues"`
+
+	// Errors and Warnings are the number of issues of each severity.
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+// LintFiles loads the Pact files at the given paths, directories, glob
+// patterns or URLs and checks them.
+func LintFiles(urls []string, config Config) (Report, error) {
+	if err := config.Validate(); err != nil {
+		return Report{}, err
+	}
+	pacts, err := dsl.LoadPactFiles(urls)
+	if err != nil {
+		return Report{}, err
+	}
+	return Lint(pacts, config), nil
+}
+
+// Lint checks the Pact files against the configured rules.
+func Lint(pacts []*dsl.PactFile, config Config) Report {
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+
+	var report Report
+	for _, pact := range pacts {
+		l := &linter{config: config, pact: pact}
+		l.lint()
+		report.Issues = append(report.Issues, l.issues...)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Severity == Error {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report
+}
+
+// linter collects the issues of a Pact file.
+type linter struct {
+	config      Config
+	pact        *dsl.PactFile
+	interaction string
+	issues      []Issue
+}
+
+// report records an issue with the current interaction, unless its rule is
+// disabled.
+func (l *linter) report(rule string, path string, format string, args ...interface{}) {
+	severity := l.config.severity(rule)
+	if severity == Off {
+		return
+	}
+	l.issues = append(l.issues, Issue{
+		Rule:        rule,
+		Severity:    severity,
+		Consumer:    l.pact.Consumer.Name,
+		Provider:    l.pact.Provider.Name,
+		Interaction: l.interaction,
+		Path:        path,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lint() {
+	seen := make(map[string]bool)
+	for _, i := range l.pact.Interactions {
+		l.interaction = i.Description
+
+		key := i.Description + "\x00" + strings.Join(i.States(), "\x00")
+		if seen[key] {
+			l.report("duplicate-description", "", "another interaction has the same description and provider state, so only one of them can be verified")
+		}
+		seen[key] = true
+
+		switch strings.ToUpper(i.Request.Method) {
+		case "GET", "HEAD", "OPTIONS":
+		default:
+			if len(i.States()) == 0 {
+				l.report("missing-provider-state", "", "%s request has no provider state, so the provider can't set up the data it changes", strings.ToUpper(i.Request.Method))
+			}
+		}
+
+		l.lintPart("request", i.Request.Path, i.Request.Headers, i.Request.Body, i.Request.MatchingRules)
+		l.lintPart("response", "", i.Response.Headers, i.Response.Body, i.Response.MatchingRules)
+	}
+}
+
+// lintPart checks the request or response of an interaction.
+func (l *linter) lintPart(part string, path string, headers map[string]string, body interface{}, rules map[string]interface{}) {
+	if path != "" {
+		l.checkTerm(part, []string{"path"}, path, rules)
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		l.checkTerm(part, []string{"headers", name}, headers[name], rules)
+	}
+
+	if body == nil {
+		return
+	}
+	if data, err := json.Marshal(body); err == nil && len(data) > l.config.MaxBodySize {
+		l.report("body-size", formatPath(part, []string{"body"}), "body is %d bytes, more than the maximum of %d: only include the fields the consumer uses", len(data), l.config.MaxBodySize)
+	}
+	l.lintValue(part, []string{"body"}, body, rules)
+}
+
+// lintValue checks a value of a body, and its children.
+func (l *linter) lintValue(part string, path []string, value interface{}, rules map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			l.lintValue(part, append(append([]string{}, path...), k), v[k], rules)
+		}
+		return
+	case []interface{}:
+		for i, item := range v {
+			l.lintValue(part, append(append([]string{}, path...), fmt.Sprintf("[%d]", i)), item, rules)
+		}
+		return
+	}
+
+	if !l.checkTerm(part, path, value, rules) {
+		return
+	}
+
+	s, isString := value.(string)
+	switch {
+	case isString && uuidRegex.MatchString(s):
+		l.report("literal-uuid", formatPath(part, path), "UUID '%s' is matched exactly: use dsl.UUID() or a Term", s)
+	case isString && timestampRegex.MatchString(s):
+		l.report("literal-date", formatPath(part, path), "timestamp '%s' is matched exactly: use dsl.Timestamp() or a Term", s)
+	case isString && dateRegex.MatchString(s):
+		l.report("literal-date", formatPath(part, path), "date '%s' is matched exactly: use dsl.Date() or a Term", s)
+	case part == "response" && value != nil && isID(path[len(path)-1]):
+		l.report("exact-id", formatPath(part, path), "ID %s is matched exactly, so the provider must return this exact value: use dsl.Like() or set it up in a provider state", describe(value))
+	}
+}
+
+// checkTerm checks a value matches the regex of its matching rules, if any,
+// and reports whether the value is matched exactly.
+func (l *linter) checkTerm(part string, path []string, value interface{}, rules map[string]interface{}) bool {
+	matchers, _ := dsl.FindMatchingRules(rules, formatPath("", path))
+
+	exact := true
+	for _, rule := range matchers {
+		switch rule.Match {
+		case "", "equality":
+			continue
+		case "regex":
+			s, ok := value.(string)
+			if !ok {
+				s = fmt.Sprintf("%v", value)
+			}
+			if err := dsl.CheckTerm(s, rule.Regex); err != nil {
+				if _, unchecked := err.(*dsl.UncheckedRegexError); unchecked {
+					l.report("unchecked-term", formatPath(part, path), "%v", err)
+				} else {
+					l.report("term-mismatch", formatPath(part, path), "%v", err)
+				}
+			}
+		}
+		exact = false
+	}
+	return exact
+}
+
+var (
+	uuidRegex      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	timestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}`)
+	dateRegex      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// isID reports whether a field name looks like an ID, e.g. "id", "userId" or
+// "user_id".
+func isID(name string) bool {
+	return name == "id" || name == "ID" || strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "ID") || strings.HasSuffix(name, "_id")
+}
+
+// formatPath formats a path within the request or response, e.g.
+// "$.response.body.items[0]".
+func formatPath(part string, path []string) string {
+	s := "$"
+	if part != "" {
+		s += "." + part
+	}
+	for _, segment := range path {
+		switch {
+		case strings.HasPrefix(segment, "["):
+			s += segment
+		case strings.ContainsAny(segment, ". []'"):
+			s += "['" + segment + "']"
+		default:
+			s += "." + segment
+		}
+	}
+	return s
+}
+
+// describe describes a value in an issue.
+func describe(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return "'" + s + "'"
+	}
+	return fmt.Sprintf("%v", v)
+}
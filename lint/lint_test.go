@@ -0,0 +1,178 @@
+package lint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/dsl"
+)
+
+func parsePact(t *testing.T, pact string) *dsl.PactFile {
+	var p dsl.PactFile
+	if err := json.Unmarshal([]byte(pact), &p); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return &p
+}
+
+var pact = `{
+  "consumer": {"name": "Consumer"},
+  "provider": {"name": "Provider"},
+  "interactions": [
+    {
+      "description": "a request for a user",
+      "request": {"method": "GET", "path": "/users/fc763eba-0905-41c5-a27f-3934ab26786c"},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "body": {
+          "id": 1,
+          "uuid": "fc763eba-0905-41c5-a27f-3934ab26786c",
+          "accountId": "abc",
+          "born": "2000-01-01",
+          "created": "2018-01-01T12:00:00Z",
+          "updated": "2018-01-01T12:00:00Z",
+          "code": "abc",
+          "name": "Billy"
+        },
+        "matchingRules": {
+          "$.body.accountId": {"match": "type"},
+          "$.body.updated": {"match": "regex", "regex": "^\\d{4}-\\d{2}-\\d{2}T"},
+          "$.body.code": {"match": "regex", "regex": "^\\d+$"},
+          "$.headers.Content-Type": {"match": "regex", "regex": "application/json"}
+        }
+      }
+    },
+    {
+      "description": "a request to delete a user",
+      "request": {"method": "DELETE", "path": "/users/1"},
+      "response": {"status": 204}
+    },
+    {
+      "description": "a request for a user",
+      "request": {"method": "GET", "path": "/users/2"},
+      "response": {"status": 404}
+    }
+  ]
+}`
+
+func TestLint(t *testing.T) {
+	report := Lint([]*dsl.PactFile{parsePact(t, pact)}, Config{})
+
+	expected := []Issue{
+		{Rule: "literal-date", Severity: Warning, Interaction: "a request for a user", Path: "$.response.body.born"},
+		{Rule: "term-mismatch", Severity: Error, Interaction: "a request for a user", Path: "$.response.body.code"},
+		{Rule: "literal-date", Severity: Warning, Interaction: "a request for a user", Path: "$.response.body.created"},
+		{Rule: "exact-id", Severity: Warning, Interaction: "a request for a user", Path: "$.response.body.id"},
+		{Rule: "literal-uuid", Severity: Warning, Interaction: "a request for a user", Path: "$.response.body.uuid"},
+		{Rule: "missing-provider-state", Severity: Warning, Interaction: "a request to delete a user"},
+		{Rule: "duplicate-description", Severity: Error, Interaction: "a request for a user"},
+	}
+
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("Expected %d issues but got %d: %v", len(expected), len(report.Issues), report.Issues)
+	}
+	for i, issue := range report.Issues {
+		e := expected[i]
+		if issue.Rule != e.Rule || issue.Severity != e.Severity || issue.Interaction != e.Interaction || issue.Path != e.Path {
+			t.Fatalf("Expected issue %d to be %+v but got %+v", i, e, issue)
+		}
+		if issue.Consumer != "Consumer" || issue.Provider != "Provider" {
+			t.Fatalf("Expected the issue to name the pact but got %+v", issue)
+		}
+	}
+	if report.Errors != 2 || report.Warnings != 5 {
+		t.Fatalf("Expected 2 errors and 5 warnings but got %d and %d", report.Errors, report.Warnings)
+	}
+	if !strings.Contains(report.Issues[4].Message, "dsl.UUID()") {
+		t.Fatalf("Expected the issue to suggest a fix but got '%s'", report.Issues[4].Message)
+	}
+}
+
+func TestLint_Config(t *testing.T) {
+	config := Config{
+		Rules: map[string]string{
+			"literal-date":          Off,
+			"exact-id":              Off,
+			"literal-uuid":          Off,
+			"duplicate-description": Off,
+			"term-mismatch":         Warning,
+			"body-size":             Error,
+		},
+		MaxBodySize: 100,
+	}
+	report := Lint([]*dsl.PactFile{parsePact(t, pact)}, config)
+
+	if len(report.Issues) != 3 || report.Errors != 1 || report.Warnings != 2 {
+		t.Fatalf("Expected 1 error and 2 warnings but got %v", report.Issues)
+	}
+	if report.Issues[0].Rule != "body-size" || report.Issues[0].Path != "$.response.body" {
+		t.Fatalf("Expected the body to be too large but got %+v", report.Issues[0])
+	}
+}
+
+func TestLint_UncheckedTerm(t *testing.T) {
+	pact := `{
+  "interactions": [
+    {
+      "description": "a request for a user",
+      "providerState": "a user exists",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {
+        "status": 200,
+        "body": {"version": "10"},
+        "matchingRules": {"$.body.version": {"match": "regex", "regex": "^\\d+(?!\\.)$"}}
+      }
+    }
+  ]
+}`
+	report := Lint([]*dsl.PactFile{parsePact(t, pact)}, Config{})
+
+	if len(report.Issues) != 1 || report.Issues[0].Rule != "unchecked-term" || report.Issues[0].Severity != Warning || report.Issues[0].Path != "$.response.body.version" {
+		t.Fatalf("Expected the regex to be unchecked but got %v", report.Issues)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := (Config{Rules: map[string]string{"literal-uuid": Error}}).Validate(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := (Config{Rules: map[string]string{"unknown": Error}}).Validate(); err == nil {
+		t.Fatalf("Expected an error for an unknown rule")
+	}
+	if err := (Config{Rules: map[string]string{"literal-uuid": "fatal"}}).Validate(); err == nil {
+		t.Fatalf("Expected an error for an invalid severity")
+	}
+}
+
+func TestLintFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pact-lint")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "pact.json"), []byte(pact), 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	configFile := filepath.Join(dir, "lint.yml")
+	if err = ioutil.WriteFile(configFile, []byte("rules:\n  literal-date: error\nmaxBodySize: 10000\n"), 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	report, err := LintFiles([]string{dir}, config)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if report.Errors != 4 || report.Warnings != 3 {
+		t.Fatalf("Expected 4 errors and 3 warnings but got %v", report.Issues)
+	}
+}
@@ -0,0 +1,615 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// CompareFiles loads an OpenAPI document and Pact files, and compares each
+// Pact with the document.
+func CompareFiles(openapi string, pactURLs []string) (types.ProviderVerifierResponse, error) {
+	spec, err := Load(openapi)
+	if err != nil {
+		return types.ProviderVerifierResponse{}, err
+	}
+
+	pacts, err := dsl.LoadPactFiles(pactURLs)
+	if err != nil {
+		return types.ProviderVerifierResponse{}, err
+	}
+
+	var response types.ProviderVerifierResponse
+	for _, pact := range pacts {
+		res := Compare(spec, pact)
+		response.Examples = append(response.Examples, res.Examples...)
+		response.Summary.ExampleCount += res.Summary.ExampleCount
+		response.Summary.FailureCount += res.Summary.FailureCount
+	}
+	response.SummaryLine = summaryLine(response)
+
+	return response, nil
+}
+
+// Compare checks that each interaction of a Pact is allowed by the OpenAPI
+// document of its Provider: that its method and path are an operation, its
+// query, headers and body are valid parameters and content of the request,
+// and its response status, headers and body are a response of the operation.
+// This allows a Pact to be checked without running the Provider. The results
+// are in the same form as those of a provider verification, with an example
+// for each interaction.
+func Compare(spec *Spec, pact *dsl.PactFile) types.ProviderVerifierResponse {
+	var response types.ProviderVerifierResponse
+
+	// The examples are anonymous structs, so the slice is made by reflection
+	examples := reflect.MakeSlice(reflect.TypeOf(response.Examples), len(pact.Interactions), len(pact.Interactions))
+	reflect.ValueOf(&response.Examples).Elem().Set(examples)
+
+	for i, interaction := range pact.Interactions {
+		mismatches := compareInteraction(spec, interaction)
+
+		description := interaction.Description
+		if states := interaction.States(); len(states) > 0 {
+			description = fmt.Sprintf("Given %s %s", strings.Join(states, " and "), description)
+		}
+
+		example := &response.Examples[i]
+		example.Description = interaction.Description
+		example.FullDescription = fmt.Sprintf("Comparing a pact between %s and %s with the OpenAPI document %s %s with %s %s is allowed by the document",
+			pact.Consumer.Name, pact.Provider.Name, spec.Info.Title, description, interaction.Request.Method, interaction.Request.Path)
+		example.Status = "passed"
+
+		if len(mismatches) > 0 {
+			example.Status = "failed"
+			messages := make([]string, len(mismatches))
+			for i, m := range mismatches {
+				messages[i] = m.String()
+			}
+			example.Exception.Class = "OpenAPIMismatch"
+			example.Exception.Message = strings.Join(messages, "\n")
+			response.Summary.FailureCount++
+		}
+		response.Summary.ExampleCount++
+	}
+
+	response.SummaryLine = summaryLine(response)
+	return response
+}
+
+// summaryLine summarises the results, as the provider verifier does.
+func summaryLine(response types.ProviderVerifierResponse) string {
+	return fmt.Sprintf("%d examples, %d failures", response.Summary.ExampleCount, response.Summary.FailureCount)
+}
+
+// compareInteraction returns the differences between an interaction and the
+// OpenAPI document.
+func compareInteraction(spec *Spec, interaction dsl.PactInteraction) []dsl.Mismatch {
+	v := &validator{spec: spec}
+	request := interaction.Request
+
+	op, params, ok := v.findOperation(request.Method, request.Path)
+	if !ok {
+		return v.mismatches
+	}
+
+	parameters := spec.Parameters(op)
+	v.compareParameters(parameters, params, request)
+	v.compareRequestBody(spec.RequestBody(op), request)
+	v.compareResponse(op, interaction.Response)
+
+	return v.mismatches
+}
+
+// validator validates the parts of an interaction against schemas.
+type validator struct {
+	spec       *Spec
+	mismatches []dsl.Mismatch
+
+	// request is true when validating a request body, in which required
+	// properties must be present. Response bodies may omit properties, but
+	// may not expect undocumented ones.
+	request bool
+}
+
+// mismatch records a difference at the path.
+func (v *validator) mismatch(path []string, format string, args ...interface{}) {
+	v.mismatches = append(v.mismatches, dsl.Mismatch{
+		Path:    comparePath(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// comparePath formats a path, e.g. "$.response.body.items[0].id".
+func comparePath(path []string) string {
+	var b bytes.Buffer
+	b.WriteString("$")
+	for _, segment := range path {
+		if strings.HasPrefix(segment, "[") {
+			b.WriteString(segment)
+		} else {
+			b.WriteString("." + segment)
+		}
+	}
+	return b.String()
+}
+
+// findOperation finds the operation for a method and path, and the values
+// of its path parameters. Paths without parameters take precedence.
+func (v *validator) findOperation(method string, path string) (OperationRef, map[string]string, bool) {
+	var templates []string
+	for template := range v.spec.Paths {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		pi, pj := strings.Count(templates[i], "{"), strings.Count(templates[j], "{")
+		if pi != pj {
+			return pi < pj
+		}
+		return templates[i] < templates[j]
+	})
+
+	for _, template := range templates {
+		params, ok := matchPath(template, path)
+		if !ok {
+			continue
+		}
+
+		item := v.spec.Paths[template]
+		op := item.Operation(method)
+		if op == nil {
+			v.mismatch([]string{"request", "method"}, "%s is not allowed for the path %s", strings.ToUpper(method), template)
+			return OperationRef{}, nil, false
+		}
+		return OperationRef{Method: strings.ToUpper(method), Path: template, PathItem: item, Operation: op}, params, true
+	}
+
+	v.mismatch([]string{"request", "path"}, "%s does not match a path of the OpenAPI document", path)
+	return OperationRef{}, nil, false
+}
+
+// matchPath matches a path against a path template such as "/users/{id}",
+// returning the values of its parameters.
+func matchPath(template string, path string) (map[string]string, bool) {
+	var b bytes.Buffer
+	b.WriteString("^")
+	var names []string
+	last := 0
+	for _, m := range pathParameterRegex.FindAllStringSubmatchIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:m[0]]))
+		b.WriteString("([^/]+)")
+		names = append(names, template[m[2]:m[3]])
+		last = m[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("/?$")
+
+	matches := regexp.MustCompile(b.String()).FindStringSubmatch(path)
+	if matches == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(names))
+	for i, name := range names {
+		params[name] = matches[i+1]
+	}
+	return params, true
+}
+
+// compareParameters validates the path, query and header parameters of a
+// request.
+func (v *validator) compareParameters(parameters []*Parameter, pathParams map[string]string, request dsl.PactRequest) {
+	declared := map[string]bool{}
+	for _, p := range parameters {
+		switch p.In {
+		case "path":
+			if value, ok := pathParams[p.Name]; ok {
+				v.validateParameter([]string{"request", "path", p.Name}, p.Schema, []string{value})
+			}
+		case "query":
+			declared[p.Name] = true
+			values, ok := request.Query[p.Name]
+			if !ok {
+				if p.Required {
+					v.mismatch([]string{"request", "query", p.Name}, "the required query parameter %s is missing", p.Name)
+				}
+				continue
+			}
+			v.validateParameter([]string{"request", "query", p.Name}, p.Schema, values)
+		case "header":
+			value, ok := findHeader(request.Headers, p.Name)
+			if !ok {
+				if p.Required {
+					v.mismatch([]string{"request", "headers", p.Name}, "the required header %s is missing", p.Name)
+				}
+				continue
+			}
+			v.validateParameter([]string{"request", "headers", p.Name}, p.Schema, []string{value})
+		}
+	}
+
+	for _, name := range sortedQueryNames(request.Query) {
+		if !declared[name] {
+			v.mismatch([]string{"request", "query", name}, "the query parameter %s is not defined by the operation", name)
+		}
+	}
+}
+
+// validateParameter validates the string values of a parameter, converted to
+// the type of its schema.
+func (v *validator) validateParameter(path []string, schema *Schema, values []string) {
+	schema = v.spec.Schema(schema)
+	if schema == nil {
+		return
+	}
+
+	if schema.Type == "array" {
+		var items []interface{}
+		for _, value := range values {
+			for _, item := range strings.Split(value, ",") {
+				items = append(items, parameterValue(v.spec.Schema(schema.Items), item))
+			}
+		}
+		v.validate(path, schema, items)
+		return
+	}
+
+	v.validate(path, schema, parameterValue(schema, values[0]))
+}
+
+// parameterValue converts a string parameter to the type of its schema, or
+// leaves it as a string if it can not be.
+func parameterValue(schema *Schema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// compareRequestBody validates the content type and body of a request.
+func (v *validator) compareRequestBody(body *RequestBody, request dsl.PactRequest) {
+	path := []string{"request", "body"}
+	if request.Body == nil {
+		if body != nil && body.Required {
+			v.mismatch(path, "the operation requires a request body")
+		}
+		return
+	}
+
+	if body == nil {
+		v.mismatch(path, "the operation does not define a request body")
+		return
+	}
+
+	contentType, _ := findHeader(request.Headers, "Content-Type")
+	media, ok := findContent(body.Content, contentType, request.Body)
+	if !ok {
+		v.mismatch([]string{"request", "headers", "Content-Type"}, "the content type %s is not allowed by the operation", describeContentType(contentType))
+		return
+	}
+
+	v.request = true
+	v.validate(path, media.Schema, request.Body)
+}
+
+// compareResponse validates the status, content type and body of a
+// response.
+func (v *validator) compareResponse(op OperationRef, response dsl.PactResponse) {
+	status := response.Status
+	if status == 0 {
+		status = 200
+	}
+
+	definition := v.spec.Response(op, strconv.Itoa(status))
+	if definition == nil {
+		v.mismatch([]string{"response", "status"}, "the status %d is not a response of the operation", status)
+		return
+	}
+
+	if response.Body == nil {
+		return
+	}
+
+	path := []string{"response", "body"}
+	if len(definition.Content) == 0 {
+		v.mismatch(path, "the response with status %d does not define a body", status)
+		return
+	}
+
+	contentType, _ := findHeader(response.Headers, "Content-Type")
+	media, ok := findContent(definition.Content, contentType, response.Body)
+	if !ok {
+		v.mismatch([]string{"response", "headers", "Content-Type"}, "the content type %s is not a response of the operation", describeContentType(contentType))
+		return
+	}
+
+	v.request = false
+	v.validate(path, media.Schema, response.Body)
+}
+
+// describeContentType describes a content type in a mismatch.
+func describeContentType(contentType string) string {
+	if contentType == "" {
+		return "(none)"
+	}
+	return contentType
+}
+
+// findHeader finds a header, ignoring the case of its name.
+func findHeader(headers map[string]string, name string) (string, bool) {
+	for k, value := range headers {
+		if strings.EqualFold(k, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// findContent finds the media type for a content type, including wildcards
+// such as "application/*". Bodies without a content type are assumed to be
+// JSON, unless they are strings.
+func findContent(content map[string]*MediaType, contentType string, body interface{}) (*MediaType, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if contentType == "" || err != nil {
+		if _, ok := body.(string); ok {
+			mediaType = "text/plain"
+		} else {
+			mediaType = "application/json"
+		}
+	}
+
+	for _, candidate := range []string{mediaType, mediaType[:strings.Index(mediaType+"/", "/")] + "/*", "*/*"} {
+		for t, media := range content {
+			if strings.EqualFold(t, candidate) {
+				if media == nil {
+					media = &MediaType{}
+				}
+				return media, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// validate validates a value against a schema.
+func (v *validator) validate(path []string, schema *Schema, value interface{}) {
+	schema = v.spec.flatten(v.spec.Schema(schema))
+	if schema == nil {
+		return
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			v.mismatch(path, "null is not allowed")
+		}
+		return
+	}
+
+	if alternatives := append(append([]*Schema{}, schema.OneOf...), schema.AnyOf...); len(alternatives) > 0 {
+		if !v.validAgainstAny(path, alternatives, value) {
+			v.mismatch(path, "%s does not match any of the allowed schemas", describe(value))
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		v.mismatch(path, "%s is not one of %v", describe(value), schema.Enum)
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.mismatch(path, "expected an object but got %s", describe(value))
+			return
+		}
+		v.validateObject(path, schema, object)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			v.mismatch(path, "expected an array but got %s", describe(value))
+			return
+		}
+		if v.request && schema.MinItems != nil && len(array) < *schema.MinItems {
+			v.mismatch(path, "expected at least %d items but got %d", *schema.MinItems, len(array))
+		}
+		if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+			v.mismatch(path, "expected at most %d items but got %d", *schema.MaxItems, len(array))
+		}
+		for i, item := range array {
+			v.validate(append(append([]string{}, path...), fmt.Sprintf("[%d]", i)), schema.Items, item)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.mismatch(path, "expected a string but got %s", describe(value))
+			return
+		}
+		v.validateString(path, schema, s)
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			v.mismatch(path, "expected %s but got %s", map[string]string{"integer": "an integer", "number": "a number"}[schema.Type], describe(value))
+			return
+		}
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			v.mismatch(path, "expected an integer but got %v", n)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			v.mismatch(path, "%v is less than the minimum of %v", n, *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			v.mismatch(path, "%v is greater than the maximum of %v", n, *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.mismatch(path, "expected a boolean but got %s", describe(value))
+		}
+	default:
+		if object, ok := value.(map[string]interface{}); ok && len(schema.Properties) > 0 {
+			v.validateObject(path, schema, object)
+		}
+	}
+}
+
+// validAgainstAny reports whether the value is valid against any of the
+// schemas.
+func (v *validator) validAgainstAny(path []string, schemas []*Schema, value interface{}) bool {
+	for _, s := range schemas {
+		alternative := &validator{spec: v.spec, request: v.request}
+		alternative.validate(path, s, value)
+		if len(alternative.mismatches) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateObject validates the properties of an object. Request bodies must
+// have the required properties, and response bodies may only expect
+// documented properties, unless additional properties are allowed.
+func (v *validator) validateObject(path []string, schema *Schema, object map[string]interface{}) {
+	properties := schema.Properties
+
+	if v.request {
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				v.mismatch(path, "the required property %s is missing", name)
+			}
+		}
+	}
+
+	additional := v.additionalProperties(schema)
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := append(append([]string{}, path...), name)
+		property, ok := properties[name]
+		switch {
+		case ok:
+			v.validate(childPath, property, object[name])
+		case additional != nil:
+			v.validate(childPath, additional, object[name])
+		case !v.request || schema.AdditionalProperties == false:
+			v.mismatch(childPath, "the property %s is not defined by the schema", name)
+		}
+	}
+}
+
+// additionalProperties returns the schema of additional properties, or nil
+// if they are not allowed. "true" allows properties of any schema.
+func (v *validator) additionalProperties(schema *Schema) *Schema {
+	switch additional := jsonValue(schema.AdditionalProperties).(type) {
+	case bool:
+		if additional {
+			return &Schema{}
+		}
+	case map[string]interface{}:
+		s := &Schema{}
+		if ref, ok := additional["$ref"].(string); ok {
+			s.Ref = ref
+		}
+		if t, ok := additional["type"].(string); ok {
+			s.Type = t
+		}
+		return s
+	}
+	return nil
+}
+
+// Formats that are validated, by their time layout or regular expression.
+var (
+	formatLayouts = map[string]string{
+		"date":      "2006-01-02",
+		"date-time": time.RFC3339,
+	}
+	formatRegexes = map[string]*regexp.Regexp{
+		"uuid":  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+		"email": regexp.MustCompile(`^[^@\s]+@[^@\s]+$`),
+		"ipv4":  regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`),
+	}
+)
+
+// validateString validates the length, pattern and format of a string.
+func (v *validator) validateString(path []string, schema *Schema, s string) {
+	if schema.MinLength != nil && len([]rune(s)) < *schema.MinLength {
+		v.mismatch(path, "%q is shorter than the minimum length of %d", s, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && len([]rune(s)) > *schema.MaxLength {
+		v.mismatch(path, "%q is longer than the maximum length of %d", s, *schema.MaxLength)
+	}
+
+	if schema.Pattern != "" {
+		if pattern, err := regexp.Compile(schema.Pattern); err == nil && !pattern.MatchString(s) {
+			v.mismatch(path, "%q does not match the pattern %s", s, schema.Pattern)
+		}
+	}
+
+	if layout, ok := formatLayouts[schema.Format]; ok {
+		if _, err := time.Parse(layout, s); err != nil {
+			v.mismatch(path, "%q is not a valid %s", s, schema.Format)
+		}
+	}
+	if regex, ok := formatRegexes[schema.Format]; ok && !regex.MatchString(s) {
+		v.mismatch(path, "%q is not a valid %s", s, schema.Format)
+	}
+}
+
+// inEnum reports whether the value is one of the enum values.
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprintf("%v", jsonValue(e)) == fmt.Sprintf("%v", value) {
+			return true
+		}
+	}
+	return false
+}
+
+// describe describes a value and its type in a mismatch.
+func describe(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return fmt.Sprintf("the string %q", value)
+	case float64:
+		return fmt.Sprintf("the number %v", value)
+	case bool:
+		return fmt.Sprintf("the boolean %v", value)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// sortedQueryNames returns the names of the query parameters in order.
+func sortedQueryNames(query dsl.PactQuery) []string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
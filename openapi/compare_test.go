@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/dsl"
+)
+
+func parsePact(t *testing.T, pact string) *dsl.PactFile {
+	var p dsl.PactFile
+	if err := json.Unmarshal([]byte(pact), &p); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return &p
+}
+
+func TestCompare(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	pact := parsePact(t, `{
+	  "consumer": {"name": "PetClient"},
+	  "provider": {"name": "PetStore"},
+	  "interactions": [
+	    {
+	      "description": "a request for pets",
+	      "request": {"method": "GET", "path": "/pets", "query": "limit=10", "headers": {"x-request-id": "abc"}},
+	      "response": {
+	        "status": 200,
+	        "headers": {"Content-Type": "application/json; charset=utf-8"},
+	        "body": [{"id": "fc763eba-0905-41c5-a27f-3934ab26786c", "name": "Fido", "status": "sold", "tags": ["a"]}]
+	      }
+	    },
+	    {
+	      "description": "a request to create a pet",
+	      "providerState": "no pets exist",
+	      "request": {"method": "POST", "path": "/pets", "headers": {"Content-Type": "application/json"}, "body": {"name": "Fido"}},
+	      "response": {"status": 201}
+	    },
+	    {
+	      "description": "a request for a pet",
+	      "request": {"method": "GET", "path": "/pets/1"},
+	      "response": {"status": 200, "headers": {"Content-Type": "application/hal+json"}, "body": {"id": "1", "name": 2, "colour": "black", "status": "lost"}}
+	    },
+	    {
+	      "description": "an invalid request for pets",
+	      "request": {"method": "GET", "path": "/pets", "query": "limit=ten&sort=name"},
+	      "response": {"status": 500}
+	    },
+	    {
+	      "description": "an invalid request to create a pet",
+	      "request": {"method": "POST", "path": "/pets", "headers": {"Content-Type": "text/plain"}, "body": "Fido"},
+	      "response": {"status": 201}
+	    },
+	    {
+	      "description": "a request to delete a pet",
+	      "request": {"method": "DELETE", "path": "/pets/1"},
+	      "response": {"status": 204}
+	    },
+	    {
+	      "description": "a request for owners",
+	      "request": {"method": "GET", "path": "/owners"},
+	      "response": {"status": 200}
+	    }
+	  ]
+	}`)
+
+	res := Compare(spec, pact)
+	if res.Summary.ExampleCount != 7 || res.Summary.FailureCount != 5 || res.SummaryLine != "7 examples, 5 failures" {
+		t.Fatalf("Unexpected summary: %+v", res.Summary)
+	}
+
+	expected := []struct {
+		status     string
+		mismatches []string
+	}{
+		{"passed", nil},
+		{"passed", nil},
+		{"failed", []string{
+			`$.request.path.petId: "1" is not a valid uuid`,
+			`$.response.body.colour: the property colour is not defined by the schema`,
+			`$.response.body.id: "1" is not a valid uuid`,
+			`$.response.body.name: expected a string but got the number 2`,
+			`$.response.body.status: the string "lost" is not one of [available sold]`,
+		}},
+		{"failed", []string{
+			`$.request.query.limit: expected an integer but got the string "ten"`,
+			`$.request.headers.X-Request-ID: the required header X-Request-ID is missing`,
+			`$.request.query.sort: the query parameter sort is not defined by the operation`,
+			`$.response.status: the status 500 is not a response of the operation`,
+		}},
+		{"failed", []string{`$.request.headers.Content-Type: the content type text/plain is not allowed by the operation`}},
+		{"failed", []string{`$.request.method: DELETE is not allowed for the path /pets/{petId}`}},
+		{"failed", []string{`$.request.path: /owners does not match a path of the OpenAPI document`}},
+	}
+
+	for i, e := range expected {
+		example := res.Examples[i]
+		if example.Status != e.status {
+			t.Fatalf("Expected '%s' to have %s, but got %s: %s", example.Description, e.status, example.Status, example.Exception.Message)
+		}
+		if message := strings.Join(e.mismatches, "\n"); example.Exception.Message != message {
+			t.Fatalf("Expected mismatches for '%s':\n%s\nbut got:\n%s", example.Description, message, example.Exception.Message)
+		}
+	}
+
+	if !strings.Contains(res.Examples[1].FullDescription, "Given no pets exist a request to create a pet") {
+		t.Fatalf("Unexpected full description: %s", res.Examples[1].FullDescription)
+	}
+}
+
+func TestCompare_RequestBody(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	pact := parsePact(t, `{
+	  "consumer": {"name": "PetClient"},
+	  "provider": {"name": "PetStore"},
+	  "interactions": [
+	    {
+	      "description": "a request to create a pet without a name",
+	      "request": {"method": "POST", "path": "/pets", "body": {"nickname": "Fido"}},
+	      "response": {"status": 201}
+	    },
+	    {
+	      "description": "a request to create a pet without a body",
+	      "request": {"method": "POST", "path": "/pets"},
+	      "response": {"status": 201}
+	    }
+	  ]
+	}`)
+
+	res := Compare(spec, pact)
+	if res.Examples[0].Exception.Message != "$.request.body: the required property name is missing" {
+		t.Fatalf("Unexpected mismatches: %s", res.Examples[0].Exception.Message)
+	}
+	if res.Examples[1].Exception.Message != "$.request.body: the operation requires a request body" {
+		t.Fatalf("Unexpected mismatches: %s", res.Examples[1].Exception.Message)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"petstore.yaml": petstore,
+		"pacts/a.json":  `{"consumer": {"name": "a"}, "provider": {"name": "PetStore"}, "interactions": [{"description": "a", "request": {"method": "POST", "path": "/pets", "body": {"name": "Fido"}}, "response": {"status": 201}}]}`,
+		"pacts/b.json":  `{"consumer": {"name": "b"}, "provider": {"name": "PetStore"}, "interactions": [{"description": "b", "request": {"method": "PUT", "path": "/pets"}, "response": {"status": 200}}]}`,
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	res, err := CompareFiles(filepath.Join(dir, "petstore.yaml"), []string{filepath.Join(dir, "pacts")})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if res.Summary.ExampleCount != 2 || res.Summary.FailureCount != 1 {
+		t.Fatalf("Unexpected summary: %+v", res.Summary)
+	}
+
+	if _, err = CompareFiles(filepath.Join(dir, "missing.yaml"), []string{filepath.Join(dir, "pacts")}); err == nil {
+		t.Fatalf("Expected an error for a missing OpenAPI document")
+	}
+}
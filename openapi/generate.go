@@ -0,0 +1,434 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateOptions configures the Pact tests generated from an OpenAPI
+// document.
+type GenerateOptions struct {
+	// Package of the generated tests.
+	Package string
+
+	// Consumer name.
+	Consumer string
+
+	// Provider name, defaults to the title of the document.
+	Provider string
+
+	// Operations to generate tests for, by operation ID or method and path
+	// (e.g. "GET /users/{id}"). Tests are generated for all operations if
+	// empty.
+	Operations []string
+}
+
+// Generate writes a Go test file containing a skeleton consumer Pact test for
+// each of the selected operations. Body matchers are derived from the
+// schemas of the operations, and the tests are intended as a starting point
+// to be edited, e.g. to add provider states and call the API client.
+func Generate(w io.Writer, spec *Spec, options GenerateOptions) error {
+	if options.Package == "" {
+		return fmt.Errorf("Package is mandatory")
+	}
+	if options.Consumer == "" {
+		return fmt.Errorf("Consumer is mandatory")
+	}
+	if options.Provider == "" {
+		options.Provider = spec.Info.Title
+	}
+
+	operations := spec.Operations()
+	if len(options.Operations) > 0 {
+		operations = nil
+		for _, name := range options.Operations {
+			op, err := spec.FindOperation(name)
+			if err != nil {
+				return err
+			}
+			operations = append(operations, op)
+		}
+	}
+	if len(operations) == 0 {
+		return fmt.Errorf("no operations found to generate tests for")
+	}
+
+	g := &generator{spec: spec, names: make(map[string]bool)}
+	fmt.Fprintf(&g.buf, "// Pact tests generated by pact-go from the OpenAPI document %q (%s).\n\n", spec.Info.Title, spec.Info.Version)
+	fmt.Fprintf(&g.buf, "package %s\n\n", options.Package)
+	fmt.Fprintf(&g.buf, "import (\n\"os\"\n\"testing\"\n\n\"github.com/pact-foundation/pact-go/dsl\"\n)\n\n")
+	fmt.Fprintf(&g.buf, "var pact = &dsl.Pact{\nConsumer: %q,\nProvider: %q,\n}\n\n", options.Consumer, options.Provider)
+	fmt.Fprintf(&g.buf, "func TestMain(m *testing.M) {\ncode := m.Run()\npact.Teardown()\nos.Exit(code)\n}\n")
+
+	for _, op := range operations {
+		g.operation(op)
+	}
+
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("unable to format generated tests: %v", err)
+	}
+
+	_, err = w.Write(source)
+	return err
+}
+
+// generator writes the Go source of the tests.
+type generator struct {
+	spec  *Spec
+	buf   bytes.Buffer
+	names map[string]bool
+}
+
+// operation writes the test for an operation.
+func (g *generator) operation(op OperationRef) {
+	description := op.Operation.Summary
+	if description == "" {
+		description = fmt.Sprintf("a %s request to %s", op.Method, op.Path)
+	} else {
+		description = "a request to " + strings.ToLower(description[:1]) + strings.TrimSuffix(description[1:], ".")
+	}
+
+	b := &g.buf
+	fmt.Fprintf(b, "\nfunc %s(t *testing.T) {\n", g.testName(op))
+	fmt.Fprintf(b, "pact.\nAddInteraction().\n// Given(\"TODO: provider state\").\n")
+	fmt.Fprintf(b, "UponReceiving(%q).\n", description)
+	fmt.Fprintf(b, "WithRequest(dsl.Request{\n%s}).\n", g.request(op))
+	fmt.Fprintf(b, "WillRespondWith(dsl.Response{\n%s})\n\n", g.response(op))
+	fmt.Fprintf(b, "err := pact.Verify(func() error {\n")
+	fmt.Fprintf(b, "// TODO: call the API client, using the mock server at\n")
+	fmt.Fprintf(b, "// fmt.Sprintf(\"http://localhost:%%d\", pact.Server.Port)\n")
+	fmt.Fprintf(b, "return nil\n})\n")
+	fmt.Fprintf(b, "if err != nil {\nt.Fatalf(\"Error on Verify: %%v\", err)\n}\n}\n")
+}
+
+// testName is a unique test function name for the operation.
+func (g *generator) testName(op OperationRef) string {
+	name := op.Operation.OperationID
+	if name == "" {
+		name = strings.ToLower(op.Method) + " " + op.Path
+	}
+
+	var b bytes.Buffer
+	b.WriteString("TestPact")
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	test := b.String()
+	for n := 2; g.names[test]; n++ {
+		test = fmt.Sprintf("%s%d", b.String(), n)
+	}
+	g.names[test] = true
+	return test
+}
+
+var pathParameterRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+// request writes the fields of the dsl.Request for an operation.
+func (g *generator) request(op OperationRef) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Method: %q,\n", op.Method)
+
+	parameters := g.spec.Parameters(op)
+	examples := make(map[string]string)
+	var query, headers []string
+	for _, p := range parameters {
+		switch p.In {
+		case "path":
+			examples[p.Name] = fmt.Sprintf("%v", g.parameterExample(p))
+		case "query":
+			if p.Required {
+				query = append(query, fmt.Sprintf("%q: %s,\n", p.Name, g.stringMatcher(p.Schema, g.parameterExample(p))))
+			}
+		case "header":
+			if p.Required {
+				headers = append(headers, fmt.Sprintf("%q: %s,\n", p.Name, g.stringMatcher(p.Schema, g.parameterExample(p))))
+			}
+		}
+	}
+
+	// Paths with parameters are matched by regular expression
+	path := op.Path
+	if pathParameterRegex.MatchString(path) {
+		example := pathParameterRegex.ReplaceAllStringFunc(path, func(m string) string {
+			if e, ok := examples[m[1:len(m)-1]]; ok {
+				return e
+			}
+			return "1"
+		})
+		fmt.Fprintf(&b, "Path: dsl.Term(%q, %q),\n", example, pathPattern(path))
+	} else {
+		fmt.Fprintf(&b, "Path: dsl.String(%q),\n", path)
+	}
+
+	if len(query) > 0 {
+		fmt.Fprintf(&b, "Query: dsl.MapMatcher{\n%s},\n", strings.Join(query, ""))
+	}
+
+	if body := g.spec.RequestBody(op); body != nil {
+		contentType, media := JSONContent(body.Content)
+		if media != nil {
+			headers = append(headers, fmt.Sprintf("%q: dsl.String(%q),\n", "Content-Type", contentType))
+			if media.Schema != nil {
+				fmt.Fprintf(&b, "Body: %s,\n", g.matcher(media.Schema, 0))
+			}
+		}
+	}
+
+	if len(headers) > 0 {
+		sort.Strings(headers)
+		fmt.Fprintf(&b, "Headers: dsl.MapMatcher{\n%s},\n", strings.Join(headers, ""))
+	}
+
+	return b.String()
+}
+
+// response writes the fields of the dsl.Response for the successful response
+// of an operation, i.e. its lowest 2xx status.
+func (g *generator) response(op OperationRef) string {
+	status := ""
+	for code := range op.Operation.Responses {
+		if strings.HasPrefix(code, "2") && (status == "" || code < status) {
+			status = code
+		}
+	}
+	if status == "" {
+		status = "default"
+	}
+
+	var b bytes.Buffer
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		code = 200
+	}
+	fmt.Fprintf(&b, "Status: %d,\n", code)
+
+	response := g.spec.Response(op, status)
+	if response == nil {
+		return b.String()
+	}
+
+	contentType, media := JSONContent(response.Content)
+	if media != nil {
+		fmt.Fprintf(&b, "Headers: dsl.MapMatcher{%q: dsl.String(%q)},\n", "Content-Type", contentType)
+		if media.Schema != nil {
+			fmt.Fprintf(&b, "Body: %s,\n", g.matcher(media.Schema, 0))
+		}
+	}
+
+	return b.String()
+}
+
+// pathPattern is a regular expression matching a path template, e.g.
+// "^/users/[^/]+$" for "/users/{id}".
+func pathPattern(path string) string {
+	var b bytes.Buffer
+	b.WriteString("^")
+	last := 0
+	for _, m := range pathParameterRegex.FindAllStringIndex(path, -1) {
+		b.WriteString(regexp.QuoteMeta(path[last:m[0]]))
+		b.WriteString("[^/]+")
+		last = m[1]
+	}
+	b.WriteString(regexp.QuoteMeta(path[last:]))
+	b.WriteString("$")
+	return b.String()
+}
+
+// parameterExample is an example value for a parameter.
+func (g *generator) parameterExample(p *Parameter) interface{} {
+	if p.Example != nil {
+		return p.Example
+	}
+	return g.example(g.spec.Schema(p.Schema))
+}
+
+// stringMatcher is a StringMatcher for a query or header parameter.
+func (g *generator) stringMatcher(schema *Schema, example interface{}) string {
+	schema = g.spec.Schema(schema)
+	value := fmt.Sprintf("%v", example)
+	if schema != nil && schema.Pattern != "" {
+		return fmt.Sprintf("dsl.Term(%q, %q)", value, schema.Pattern)
+	}
+	return fmt.Sprintf("dsl.String(%q)", value)
+}
+
+// matcher is a Go expression matching values of the schema: types are
+// matched with Like, arrays with EachLike, patterns and enums with Term, and
+// common formats with their matchers.
+func (g *generator) matcher(schema *Schema, depth int) string {
+	schema = g.merge(g.spec.Schema(schema))
+	if schema == nil || depth > 10 {
+		return "nil"
+	}
+
+	switch {
+	case schema.Type == "object" || len(schema.Properties) > 0:
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var b bytes.Buffer
+		b.WriteString("map[string]interface{}{\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "%q: %s,\n", name, g.matcher(schema.Properties[name], depth+1))
+		}
+		b.WriteString("}")
+		return b.String()
+	case schema.Type == "array":
+		min := 1
+		if schema.MinItems != nil && *schema.MinItems > 1 {
+			min = *schema.MinItems
+		}
+		return fmt.Sprintf("dsl.EachLike(%s, %d)", g.matcher(schema.Items, depth+1), min)
+	case len(schema.Enum) > 0:
+		values := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			values[i] = regexp.QuoteMeta(fmt.Sprintf("%v", v))
+		}
+		return fmt.Sprintf("dsl.Term(%q, %q)", fmt.Sprintf("%v", g.example(schema)), "^("+strings.Join(values, "|")+")$")
+	case schema.Pattern != "":
+		return fmt.Sprintf("dsl.Term(%q, %q)", fmt.Sprintf("%v", g.example(schema)), schema.Pattern)
+	}
+
+	if schema.Type == "string" && schema.Example == nil {
+		switch schema.Format {
+		case "uuid":
+			return "dsl.UUID()"
+		case "date":
+			return "dsl.Date()"
+		case "date-time":
+			return "dsl.Timestamp()"
+		case "ipv4":
+			return "dsl.IPv4Address()"
+		case "ipv6":
+			return "dsl.IPv6Address()"
+		}
+	}
+
+	return fmt.Sprintf("dsl.Like(%s)", literal(g.example(schema)))
+}
+
+// merge combines the schemas of an allOf, and picks the first of a oneOf or
+// anyOf.
+func (g *generator) merge(schema *Schema) *Schema {
+	if schema == nil {
+		return nil
+	}
+
+	if len(schema.OneOf) > 0 {
+		return g.merge(g.spec.Schema(schema.OneOf[0]))
+	}
+	if len(schema.AnyOf) > 0 {
+		return g.merge(g.spec.Schema(schema.AnyOf[0]))
+	}
+	if len(schema.AllOf) == 0 {
+		return schema
+	}
+
+	merged := *schema
+	merged.AllOf = nil
+	merged.Properties = make(map[string]*Schema)
+	for name, property := range schema.Properties {
+		merged.Properties[name] = property
+	}
+	for _, s := range schema.AllOf {
+		s = g.merge(g.spec.Schema(s))
+		if s == nil {
+			continue
+		}
+		if merged.Type == "" {
+			merged.Type = s.Type
+		}
+		for name, property := range s.Properties {
+			merged.Properties[name] = property
+		}
+		merged.Required = append(merged.Required, s.Required...)
+	}
+	return &merged
+}
+
+// example is an example value for a primitive schema.
+func (g *generator) example(schema *Schema) interface{} {
+	if schema == nil {
+		return "example"
+	}
+	switch {
+	case schema.Example != nil && schema.Type == "integer":
+		if n, ok := schema.Example.(float64); ok {
+			return int(n)
+		}
+		return schema.Example
+	case schema.Example != nil:
+		return jsonValue(schema.Example)
+	case schema.Default != nil:
+		return jsonValue(schema.Default)
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	switch schema.Type {
+	case "integer":
+		if schema.Minimum != nil {
+			return int(*schema.Minimum)
+		}
+		return 1
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 1.5
+	case "boolean":
+		return true
+	}
+
+	switch schema.Format {
+	case "uuid":
+		return "fc763eba-0905-41c5-a27f-3934ab26786c"
+	case "date":
+		return "2000-02-01"
+	case "date-time":
+		return "2000-02-01T12:30:00Z"
+	case "email":
+		return "billy@example.com"
+	case "uri":
+		return "http://example.com"
+	}
+	return "example"
+}
+
+// literal is the Go literal for an example value.
+func literal(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return strconv.Quote(value)
+	case float64:
+		if value == float64(int64(value)) {
+			return strconv.FormatFloat(value, 'f', 1, 64)
+		}
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case int, int64, bool:
+		return fmt.Sprintf("%v", value)
+	default:
+		return fmt.Sprintf("%#v", value)
+	}
+}
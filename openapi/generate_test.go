@@ -0,0 +1,70 @@
+package openapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var out bytes.Buffer
+	err = Generate(&out, spec, GenerateOptions{
+		Package:    "client",
+		Consumer:   "PetClient",
+		Operations: []string{"listPets", "GET /pets/{petId}", "createPet"},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	source := out.String()
+	for _, expected := range []string{
+		"package client",
+		`Provider: "Pet Store"`,
+		"func TestPactListPets(t *testing.T) {",
+		`UponReceiving("a request to list all pets")`,
+		`"limit": dsl.String("10")`,
+		`"X-Request-ID": dsl.Term("abc123", "^[0-9a-f]+$")`,
+		`"id":     dsl.UUID()`,
+		`"born":   dsl.Date()`,
+		`"status": dsl.Term("available", "^(available|sold)$")`,
+		`"tags":   dsl.EachLike(dsl.Like("example"), 2)`,
+		`"weight": dsl.Like(1.5)`,
+		"func TestPactGetPetsPetId(t *testing.T) {",
+		`Path:   dsl.Term("/pets/fc763eba-0905-41c5-a27f-3934ab26786c", "^/pets/[^/]+$")`,
+		`"Content-Type": dsl.String("application/hal+json")`,
+		"func TestPactCreatePet(t *testing.T) {",
+		"Status: 201,",
+		`"name": dsl.Like("Fido")`,
+	} {
+		if !strings.Contains(source, expected) {
+			t.Fatalf("Expected the generated tests to contain '%s', but got:\n%s", expected, source)
+		}
+	}
+}
+
+func TestGenerate_Fail(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err = Generate(&out, spec, GenerateOptions{Package: "client"}); err == nil {
+		t.Fatalf("Expected an error without a Consumer")
+	}
+	if err = Generate(&out, spec, GenerateOptions{Package: "client", Consumer: "PetClient", Operations: []string{"deletePet"}}); err == nil {
+		t.Fatalf("Expected an error for a missing operation")
+	}
+}
+
+func TestGenerate_pathPattern(t *testing.T) {
+	if pattern := pathPattern("/pets/{petId}/photos.{format}"); pattern != `^/pets/[^/]+/photos\.[^/]+$` {
+		t.Fatalf("Unexpected pattern: %s", pattern)
+	}
+}
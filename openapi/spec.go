@@ -0,0 +1,405 @@
+// Package openapi works with OpenAPI 3 documents: generating consumer Pact
+// tests from them, and checking Pact files against them.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Methods are the HTTP methods of the operations of a PathItem, in order.
+var Methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+// Spec is an OpenAPI 3 document. Only the parts relevant to contract testing
+// are read.
+type Spec struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components Components           `json:"components" yaml:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// Components holds the reusable objects referenced by "$ref".
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas" yaml:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters" yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies" yaml:"requestBodies"`
+	Responses     map[string]*Response    `json:"responses" yaml:"responses"`
+}
+
+// PathItem holds the operations on a path.
+type PathItem struct {
+	Get        *Operation   `json:"get" yaml:"get"`
+	Put        *Operation   `json:"put" yaml:"put"`
+	Post       *Operation   `json:"post" yaml:"post"`
+	Delete     *Operation   `json:"delete" yaml:"delete"`
+	Options    *Operation   `json:"options" yaml:"options"`
+	Head       *Operation   `json:"head" yaml:"head"`
+	Patch      *Operation   `json:"patch" yaml:"patch"`
+	Trace      *Operation   `json:"trace" yaml:"trace"`
+	Parameters []*Parameter `json:"parameters" yaml:"parameters"`
+}
+
+// Operation returns the operation for an HTTP method, or nil.
+func (p *PathItem) Operation(method string) *Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return p.Get
+	case "PUT":
+		return p.Put
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	case "OPTIONS":
+		return p.Options
+	case "HEAD":
+		return p.Head
+	case "PATCH":
+		return p.Patch
+	case "TRACE":
+		return p.Trace
+	}
+	return nil
+}
+
+// Operation is a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId" yaml:"operationId"`
+	Summary     string               `json:"summary" yaml:"summary"`
+	Parameters  []*Parameter         `json:"parameters" yaml:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody" yaml:"requestBody"`
+	Responses   map[string]*Response `json:"responses" yaml:"responses"`
+}
+
+// Parameter is a path, query, header or cookie parameter.
+type Parameter struct {
+	Ref      string      `json:"$ref" yaml:"$ref"`
+	Name     string      `json:"name" yaml:"name"`
+	In       string      `json:"in" yaml:"in"`
+	Required bool        `json:"required" yaml:"required"`
+	Schema   *Schema     `json:"schema" yaml:"schema"`
+	Example  interface{} `json:"example" yaml:"example"`
+}
+
+// RequestBody is the body of an operation's request.
+type RequestBody struct {
+	Ref      string                `json:"$ref" yaml:"$ref"`
+	Required bool                  `json:"required" yaml:"required"`
+	Content  map[string]*MediaType `json:"content" yaml:"content"`
+}
+
+// Response is a response of an operation, keyed by status code.
+type Response struct {
+	Ref         string                `json:"$ref" yaml:"$ref"`
+	Description string                `json:"description" yaml:"description"`
+	Headers     map[string]*Header    `json:"headers" yaml:"headers"`
+	Content     map[string]*MediaType `json:"content" yaml:"content"`
+}
+
+// Header is a response header.
+type Header struct {
+	Required bool    `json:"required" yaml:"required"`
+	Schema   *Schema `json:"schema" yaml:"schema"`
+}
+
+// MediaType is the schema of a body with a content type.
+type MediaType struct {
+	Schema  *Schema     `json:"schema" yaml:"schema"`
+	Example interface{} `json:"example" yaml:"example"`
+}
+
+// Schema is a JSON schema, as used by OpenAPI 3.
+type Schema struct {
+	Ref                  string             `json:"$ref" yaml:"$ref"`
+	Type                 string             `json:"type" yaml:"type"`
+	Format               string             `json:"format" yaml:"format"`
+	Pattern              string             `json:"pattern" yaml:"pattern"`
+	Enum                 []interface{}      `json:"enum" yaml:"enum"`
+	Example              interface{}        `json:"example" yaml:"example"`
+	Default              interface{}        `json:"default" yaml:"default"`
+	Nullable             bool               `json:"nullable" yaml:"nullable"`
+	Properties           map[string]*Schema `json:"properties" yaml:"properties"`
+	Required             []string           `json:"required" yaml:"required"`
+	AdditionalProperties interface{}        `json:"additionalProperties" yaml:"additionalProperties"`
+	Items                *Schema            `json:"items" yaml:"items"`
+	MinItems             *int               `json:"minItems" yaml:"minItems"`
+	MaxItems             *int               `json:"maxItems" yaml:"maxItems"`
+	MinLength            *int               `json:"minLength" yaml:"minLength"`
+	MaxLength            *int               `json:"maxLength" yaml:"maxLength"`
+	Minimum              *float64           `json:"minimum" yaml:"minimum"`
+	Maximum              *float64           `json:"maximum" yaml:"maximum"`
+	AllOf                []*Schema          `json:"allOf" yaml:"allOf"`
+	OneOf                []*Schema          `json:"oneOf" yaml:"oneOf"`
+	AnyOf                []*Schema          `json:"anyOf" yaml:"anyOf"`
+}
+
+// OperationRef is an operation, with the method and path it is found at.
+type OperationRef struct {
+	Method    string
+	Path      string
+	PathItem  *PathItem
+	Operation *Operation
+}
+
+// Name is the operation ID, or the method and path if there is none.
+func (o OperationRef) Name() string {
+	if o.Operation.OperationID != "" {
+		return o.Operation.OperationID
+	}
+	return fmt.Sprintf("%s %s", o.Method, o.Path)
+}
+
+// Load reads an OpenAPI document, in JSON or YAML, from a local file or
+// http(s) URL.
+func Load(file string) (*Spec, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(file, "http") {
+		var res *http.Response
+		res, err = http.Get(file)
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err == nil && (res.StatusCode < 200 || res.StatusCode >= 300) {
+			err = fmt.Errorf("unable to fetch OpenAPI document %s: %s", file, res.Status)
+		}
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse OpenAPI document %s: %v", file, err)
+	}
+	return spec, nil
+}
+
+// Parse parses an OpenAPI document in JSON or YAML.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, &spec)
+	} else {
+		err = yaml.Unmarshal(data, &spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, errors.New("only OpenAPI 3 documents are supported")
+	}
+
+	return &spec, nil
+}
+
+// Operations returns the operations of the document, sorted by path and
+// method.
+func (s *Spec) Operations() []OperationRef {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var operations []OperationRef
+	for _, path := range paths {
+		item := s.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, method := range Methods {
+			if op := item.Operation(method); op != nil {
+				operations = append(operations, OperationRef{Method: method, Path: path, PathItem: item, Operation: op})
+			}
+		}
+	}
+	return operations
+}
+
+// FindOperation finds an operation by operation ID, or by method and path,
+// e.g. "GET /users/{id}".
+func (s *Spec) FindOperation(name string) (OperationRef, error) {
+	for _, op := range s.Operations() {
+		if op.Operation.OperationID == name || strings.EqualFold(fmt.Sprintf("%s %s", op.Method, op.Path), name) {
+			return op, nil
+		}
+	}
+	return OperationRef{}, fmt.Errorf("operation '%s' not found", name)
+}
+
+// Parameters returns the resolved parameters of an operation, including
+// those of its path. Operation parameters override path parameters.
+func (s *Spec) Parameters(op OperationRef) []*Parameter {
+	var parameters []*Parameter
+	index := make(map[string]int)
+
+	add := func(p *Parameter) {
+		p = s.resolveParameter(p)
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			parameters[i] = p
+			return
+		}
+		index[key] = len(parameters)
+		parameters = append(parameters, p)
+	}
+
+	for _, p := range op.PathItem.Parameters {
+		add(p)
+	}
+	for _, p := range op.Operation.Parameters {
+		add(p)
+	}
+	return parameters
+}
+
+// RequestBody returns the resolved request body of an operation, or nil.
+func (s *Spec) RequestBody(op OperationRef) *RequestBody {
+	body := op.Operation.RequestBody
+	if body != nil && body.Ref != "" {
+		return s.Components.RequestBodies[refName(body.Ref)]
+	}
+	return body
+}
+
+// Response returns the resolved response of an operation for a status, or
+// the "default" response.
+func (s *Spec) Response(op OperationRef, status string) *Response {
+	response, ok := op.Operation.Responses[status]
+	if !ok && status != "" {
+		// Ranges such as "2XX"
+		response, ok = op.Operation.Responses[strings.ToUpper(status[:1])+"XX"]
+	}
+	if !ok {
+		response = op.Operation.Responses["default"]
+	}
+	if response != nil && response.Ref != "" {
+		return s.Components.Responses[refName(response.Ref)]
+	}
+	return response
+}
+
+// Schema resolves a schema reference, returning the schema itself if it is
+// not a reference.
+func (s *Spec) Schema(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 10; i++ {
+		schema = s.Components.Schemas[refName(schema.Ref)]
+	}
+	return schema
+}
+
+// flatten combines the properties and required properties of the schemas
+// of an allOf into a single schema.
+func (s *Spec) flatten(schema *Schema) *Schema {
+	if schema == nil || len(schema.AllOf) == 0 {
+		return schema
+	}
+
+	flattened := *schema
+	flattened.AllOf = nil
+	flattened.Properties = make(map[string]*Schema)
+	flattened.Required = append([]string{}, schema.Required...)
+	for name, property := range schema.Properties {
+		flattened.Properties[name] = property
+	}
+
+	for _, part := range schema.AllOf {
+		part = s.flatten(s.Schema(part))
+		if part == nil {
+			continue
+		}
+		if flattened.Type == "" {
+			flattened.Type = part.Type
+		}
+		if flattened.AdditionalProperties == nil {
+			flattened.AdditionalProperties = part.AdditionalProperties
+		}
+		for name, property := range part.Properties {
+			flattened.Properties[name] = property
+		}
+		flattened.Required = append(flattened.Required, part.Required...)
+	}
+
+	return &flattened
+}
+
+// resolveParameter resolves a parameter reference.
+func (s *Spec) resolveParameter(p *Parameter) *Parameter {
+	if p.Ref != "" {
+		if resolved, ok := s.Components.Parameters[refName(p.Ref)]; ok {
+			return resolved
+		}
+	}
+	return p
+}
+
+// refName is the name of a local reference, e.g. "User" for
+// "#/components/schemas/User".
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// JSONContent returns the schema of the JSON content, and its media type.
+func JSONContent(content map[string]*MediaType) (string, *MediaType) {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		if t == "application/json" || strings.HasSuffix(t, "+json") {
+			return t, content[t]
+		}
+	}
+	if len(types) > 0 {
+		return types[0], content[types[0]]
+	}
+	return "", nil
+}
+
+// jsonValue converts a value parsed from YAML into one that can be marshalled
+// as JSON.
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprintf("%v", k)] = jsonValue(val)
+		}
+		return m
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = jsonValue(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = jsonValue(val)
+		}
+		return t
+	}
+	return v
+}
@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var petstore = `
+openapi: 3.0.0
+info:
+  title: Pet Store
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List all pets
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+            example: 10
+        - name: X-Request-ID
+          in: header
+          required: true
+          schema:
+            type: string
+            pattern: "^[0-9a-f]+$"
+            example: abc123
+      responses:
+        "200":
+          description: A list of pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+    post:
+      operationId: createPet
+      requestBody:
+        $ref: "#/components/requestBodies/NewPet"
+      responses:
+        201:
+          description: Created
+  /pets/{petId}:
+    parameters:
+      - $ref: "#/components/parameters/PetId"
+    get:
+      responses:
+        "200":
+          $ref: "#/components/responses/Pet"
+        "404":
+          description: Not found
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: string
+        format: uuid
+  requestBodies:
+    NewPet:
+      required: true
+      content:
+        application/json:
+          schema:
+            type: object
+            required: [name]
+            properties:
+              name:
+                type: string
+                example: Fido
+  responses:
+    Pet:
+      description: A pet
+      content:
+        application/hal+json:
+          schema:
+            $ref: "#/components/schemas/Pet"
+  schemas:
+    Pet:
+      allOf:
+        - $ref: "#/components/schemas/NewPet"
+        - type: object
+          required: [id]
+          properties:
+            id:
+              type: string
+              format: uuid
+            born:
+              type: string
+              format: date
+            status:
+              type: string
+              enum: [available, sold]
+            weight:
+              type: number
+            tags:
+              type: array
+              minItems: 2
+              items:
+                type: string
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          example: Fido
+`
+
+func TestSpec_Load(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "petstore.yaml")
+	if err = ioutil.WriteFile(file, []byte(petstore), 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	spec, err := Load(file)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if spec.Info.Title != "Pet Store" || len(spec.Operations()) != 3 {
+		t.Fatalf("Unexpected spec: %+v", spec)
+	}
+
+	op, err := spec.FindOperation("get /pets/{petId}")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if op.Name() != "GET /pets/{petId}" {
+		t.Fatalf("Unexpected operation name: %s", op.Name())
+	}
+
+	parameters := spec.Parameters(op)
+	if len(parameters) != 1 || parameters[0].Name != "petId" {
+		t.Fatalf("Expected the path parameter reference to be resolved, but got %+v", parameters)
+	}
+
+	response := spec.Response(op, "200")
+	if response == nil || response.Description != "A pet" {
+		t.Fatalf("Expected the response reference to be resolved, but got %+v", response)
+	}
+
+	if _, err = spec.FindOperation("deletePet"); err == nil {
+		t.Fatalf("Expected an error for a missing operation")
+	}
+}
+
+func TestSpec_ParseJSON(t *testing.T) {
+	spec, err := Parse([]byte(`{"openapi": "3.0.1", "info": {"title": "API"}, "paths": {"/": {"get": {"operationId": "root"}}}}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, err = spec.FindOperation("root"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if _, err = Parse([]byte(`swagger: "2.0"`)); err == nil {
+		t.Fatalf("Expected an error for a Swagger 2 document")
+	}
+}
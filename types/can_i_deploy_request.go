@@ -0,0 +1,68 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// CanIDeployRequest contains the details required to ask a Pact Broker
+// whether a version of a pacticipant is safe to deploy.
+type CanIDeployRequest struct {
+	// Pacticipant is the name of the application being deployed. Required.
+	Pacticipant string
+
+	// Version of the Pacticipant being deployed. One of Version, Latest or
+	// LatestTag is required.
+	Version string
+
+	// Latest uses the latest version of the Pacticipant.
+	Latest bool
+
+	// LatestTag uses the latest version of the Pacticipant with the given tag.
+	LatestTag string
+
+	// To is the tag of the versions the Pacticipant will be deployed alongside
+	// e.g. "prod". Optional.
+	To string
+
+	// ToEnvironment is the name of the environment being deployed to. Optional.
+	ToEnvironment string
+
+	// RetryWhileUnknown is the number of times to retry while there are
+	// verification results still to be published. Optional.
+	RetryWhileUnknown int
+
+	// RetryInterval is the time to wait between retries. Defaults to 10 seconds.
+	RetryInterval time.Duration
+}
+
+// Validate checks that the minimum fields are provided.
+func (r *CanIDeployRequest) Validate() error {
+	if r.Pacticipant == "" {
+		return fmt.Errorf("Pacticipant is mandatory")
+	}
+
+	selectors := 0
+	for _, set := range []bool{r.Version != "", r.Latest, r.LatestTag != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return fmt.Errorf("Exactly one of Version, Latest or LatestTag must be provided")
+	}
+
+	if r.To != "" && r.ToEnvironment != "" {
+		return fmt.Errorf("Only one of To and ToEnvironment may be provided")
+	}
+
+	if r.RetryWhileUnknown < 0 {
+		return fmt.Errorf("RetryWhileUnknown must not be negative")
+	}
+
+	if r.RetryInterval == 0 {
+		r.RetryInterval = 10 * time.Second
+	}
+
+	return nil
+}
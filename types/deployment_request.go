@@ -0,0 +1,38 @@
+package types
+
+import "fmt"
+
+// DeploymentRequest contains the details required to record the deployment,
+// release or undeployment of a pacticipant version to an Environment.
+type DeploymentRequest struct {
+	// Pacticipant is the name of the application. Required.
+	Pacticipant string
+
+	// Version of the Pacticipant. Required for deployments and releases.
+	Version string
+
+	// Environment is the name of the Environment. Required.
+	Environment string
+
+	// ApplicationInstance identifies the instance of the application, when
+	// several are deployed to the same Environment (e.g. per customer). Optional.
+	ApplicationInstance string
+}
+
+// Validate checks that the minimum fields are provided. The Version is only
+// checked if versionRequired is set.
+func (r *DeploymentRequest) Validate(versionRequired bool) error {
+	if r.Pacticipant == "" {
+		return fmt.Errorf("Pacticipant is mandatory")
+	}
+
+	if versionRequired && r.Version == "" {
+		return fmt.Errorf("Version is mandatory")
+	}
+
+	if r.Environment == "" {
+		return fmt.Errorf("Environment is mandatory")
+	}
+
+	return nil
+}
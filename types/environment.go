@@ -0,0 +1,17 @@
+package types
+
+// Environment is a place that pacticipant versions are deployed or released
+// to, e.g. "test" or "production".
+type Environment struct {
+	// UUID is assigned by the Pact Broker when the Environment is created.
+	UUID string `json:"uuid,omitempty"`
+
+	// Name of the Environment, used to refer to it from other commands. Required.
+	Name string `json:"name"`
+
+	// DisplayName is a human readable name for the Environment. Optional.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Production is true if the Environment is a production environment.
+	Production bool `json:"production"`
+}
@@ -0,0 +1,46 @@
+package types
+
+// MatrixResponse is the response from the matrix API of a Pact Broker,
+// describing the compatibility of a set of pacticipant versions.
+type MatrixResponse struct {
+	Summary MatrixSummary `json:"summary"`
+	Matrix  []MatrixRow   `json:"matrix"`
+}
+
+// MatrixSummary is the overall result of a matrix query.
+type MatrixSummary struct {
+	// Deployable is true when every required verification has passed.
+	Deployable bool   `json:"deployable"`
+	Reason     string `json:"reason"`
+	Success    int    `json:"success"`
+	Failed     int    `json:"failed"`
+	Unknown    int    `json:"unknown"`
+}
+
+// MatrixRow is the verification status of a single consumer/provider
+// version pair.
+type MatrixRow struct {
+	Consumer           MatrixPacticipant         `json:"consumer"`
+	Provider           MatrixPacticipant         `json:"provider"`
+	VerificationResult *MatrixVerificationResult `json:"verificationResult"`
+}
+
+// MatrixPacticipant is a pacticipant version in a MatrixRow.
+type MatrixPacticipant struct {
+	Name    string `json:"name"`
+	Version struct {
+		Number string `json:"number"`
+	} `json:"version"`
+}
+
+// MatrixVerificationResult is the verification result in a MatrixRow. It is
+// nil when the pact has not yet been verified.
+type MatrixVerificationResult struct {
+	Success    bool   `json:"success"`
+	VerifiedAt string `json:"verifiedAt"`
+	Links      struct {
+		Self struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"_links"`
+}
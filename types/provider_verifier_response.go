@@ -13,6 +13,7 @@ type ProviderVerifierResponse struct {
 		LineNumber      int         `json:"line_number"`
 		RunTime         float64     `json:"run_time"`
 		PendingMessage  interface{} `json:"pending_message"`
+		Pending         bool        `json:"pending"`
 		Exception       struct {
 			Class     string   `json:"class"`
 			Message   string   `json:"message"`
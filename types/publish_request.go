@@ -3,8 +3,21 @@ package types
 // PublishRequest contains the details required to Publish Pacts to a broker.
 type PublishRequest struct {
 	// Array of local Pact files or directories containing them. Required.
+	// Directories are expanded to the *.json files they contain, and glob
+	// patterns such as "pacts/*-billy.json" are supported. Remote http(s)
+	// Pact files are also accepted.
 	PactURLs []string
 
+	// Consumers restricts publishing to the Pacts of the given consumers. Optional.
+	Consumers []string
+
+	// Providers restricts publishing to the Pacts for the given providers. Optional.
+	Providers []string
+
+	// DryRun logs the Pacts that would be published without contacting the
+	// broker. Optional.
+	DryRun bool
+
 	// URL to fetch the provider states for the given provider API. Optional.
 	PactBroker string
 
@@ -20,4 +33,15 @@ type PublishRequest struct {
 	// Tags help you organise your Pacts for different testing purposes.
 	// e.g. "production", "latest" and "development" are some common examples.
 	Tags []string
+
+	// Branch is the repository branch of the consumer version. Optional.
+	Branch string
+
+	// BuildURL links to the CI build that created the Pacts. Optional.
+	BuildURL string
+
+	// AutoDetectVersionProperties fills in the ConsumerVersion, Branch and
+	// BuildURL, if not provided, from common CI environment variables or the
+	// local git checkout. The commit SHA is used as the ConsumerVersion.
+	AutoDetectVersionProperties bool
 }
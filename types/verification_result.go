@@ -0,0 +1,74 @@
+package types
+
+// VerificationResult is the outcome of verifying a single Pact, in the format
+// expected by the Pact Broker when publishing verification results.
+type VerificationResult struct {
+	// Success is true when every interaction in the Pact passed verification.
+	Success bool `json:"success"`
+
+	// ProviderApplicationVersion is the version of the Provider that was verified.
+	ProviderApplicationVersion string `json:"providerApplicationVersion"`
+
+	// ProviderVersionBranch is the repository branch of the Provider version.
+	ProviderVersionBranch string `json:"providerVersionBranch,omitempty"`
+
+	// BuildURL links to the CI build that performed the verification.
+	BuildURL string `json:"buildUrl,omitempty"`
+
+	// TestResults contains the result of each interaction.
+	TestResults *VerificationTestResults `json:"testResults,omitempty"`
+}
+
+// VerificationTestResults summarises the results of each interaction in a Pact.
+type VerificationTestResults struct {
+	Summary VerificationTestSummary `json:"summary"`
+	Tests   []VerificationTest      `json:"tests"`
+}
+
+// VerificationTestSummary contains the counts of the verified interactions.
+type VerificationTestSummary struct {
+	TestCount    int `json:"testCount"`
+	FailureCount int `json:"failureCount"`
+	PendingCount int `json:"pendingCount"`
+}
+
+// VerificationTest is the result of verifying a single interaction.
+type VerificationTest struct {
+	Description     string `json:"testDescription"`
+	FullDescription string `json:"testFullDescription"`
+	Status          string `json:"status"`
+	Message         string `json:"message,omitempty"`
+}
+
+// NewVerificationResult creates a VerificationResult from the response of a
+// provider verification. The Provider version details must be set by the caller.
+func NewVerificationResult(response ProviderVerifierResponse) VerificationResult {
+	results := &VerificationTestResults{
+		Tests: []VerificationTest{},
+	}
+
+	success := response.Summary.FailureCount == 0 && response.Summary.ErrorsOutsideOfExamplesCount == 0
+
+	for _, example := range response.Examples {
+		results.Tests = append(results.Tests, VerificationTest{
+			Description:     example.Description,
+			FullDescription: example.FullDescription,
+			Status:          example.Status,
+			Message:         example.Exception.Message,
+		})
+
+		results.Summary.TestCount++
+		switch {
+		case example.Pending:
+			results.Summary.PendingCount++
+		case example.Status != "passed":
+			results.Summary.FailureCount++
+			success = false
+		}
+	}
+
+	return VerificationResult{
+		Success:     success,
+		TestResults: results,
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"log"
+	"time"
 )
 
 // VerifyRequest contains the verification params.
@@ -19,6 +20,25 @@ type VerifyRequest struct {
 	// Tags to find in Broker for matrix-based testing
 	Tags []string
 
+	// ProviderTags are the tags of the Provider version being verified. When
+	// EnablePending is set, the Pact Broker uses them to work out which pacts
+	// are pending for this Provider.
+	ProviderTags []string
+
+	// EnablePending allows pending pacts to be verified without failing the
+	// build. The pending status of each pact is retrieved from the Pact Broker.
+	EnablePending bool
+
+	// IncludeWIPPactsSince includes any "work in progress" pacts published
+	// to the Pact Broker after the given time. WIP pacts are always pending.
+	IncludeWIPPactsSince *time.Time
+
+	// PendingPactURLs are Local/HTTP paths to pending Pact files. They are
+	// verified in addition to PactURLs, but their failures are reported as
+	// warnings. Populated automatically from the Pact Broker when
+	// EnablePending or IncludeWIPPactsSince is set.
+	PendingPactURLs []string
+
 	// URL to retrieve valid Provider States.
 	// Deprecation notice: no longer valid/required
 	ProviderStatesURL string
@@ -32,12 +52,26 @@ type VerifyRequest struct {
 	// Password when authenticating to a Pact Broker.
 	BrokerPassword string
 
-	// PublishVerificationResults to the Pact Broker.
+	// PublishVerificationResults to the Pact Broker. Results are published
+	// for each Pact retrieved from a Pact Broker.
 	PublishVerificationResults bool
 
 	// ProviderVersion is the semantical version of the Provider API.
 	ProviderVersion string
 
+	// ProviderBranch is the repository branch of the Provider version. Sent
+	// with the verification results when PublishVerificationResults is set.
+	ProviderBranch string
+
+	// BuildURL links to the CI build performing the verification. Sent with
+	// the verification results when PublishVerificationResults is set.
+	BuildURL string
+
+	// StreamTimeout is how long to wait for the events of a streaming
+	// response, of Server-Sent Events or NDJSON, which are verified by
+	// reading the stream rather than by the verifier. Defaults to 10 seconds.
+	StreamTimeout time.Duration
+
 	// Verbose increases verbosity of output
 	// Deprecated
 	Verbose bool
@@ -98,10 +132,8 @@ func (v *VerifyRequest) Validate() error {
 
 	if v.ProviderVersion != "" {
 		v.Args = append(v.Args, "--provider_app_version", v.ProviderVersion)
-	}
-
-	if v.PublishVerificationResults {
-		v.Args = append(v.Args, "--publish_verification_results", "true")
+	} else if v.PublishVerificationResults {
+		return fmt.Errorf("Provider version is mandatory when publishing verification results")
 	}
 
 	if v.Verbose {
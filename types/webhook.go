@@ -0,0 +1,191 @@
+package types
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Webhook events supported by the Pact Broker.
+const (
+	WebhookEventContractContentChanged        = "contract_content_changed"
+	WebhookEventContractPublished             = "contract_published"
+	WebhookEventProviderVerificationPublished = "provider_verification_published"
+	WebhookEventProviderVerificationSucceeded = "provider_verification_succeeded"
+	WebhookEventProviderVerificationFailed    = "provider_verification_failed"
+	WebhookEventContractRequiringVerification = "contract_requiring_verification_published"
+)
+
+// Webhook is a request the Pact Broker makes when one of its Events occurs,
+// e.g. triggering a provider build when the content of a contract changes.
+type Webhook struct {
+	// UUID identifies the Webhook. It is assigned by the Pact Broker if not
+	// provided when the Webhook is created.
+	UUID string `json:"uuid,omitempty" yaml:"uuid"`
+
+	// Description of the Webhook. Optional.
+	Description string `json:"description,omitempty" yaml:"description"`
+
+	// Consumer restricts the Webhook to the contracts of a consumer. Optional.
+	Consumer *WebhookPacticipant `json:"consumer,omitempty" yaml:"consumer"`
+
+	// Provider restricts the Webhook to the contracts of a provider. Optional.
+	Provider *WebhookPacticipant `json:"provider,omitempty" yaml:"provider"`
+
+	// Events that trigger the Webhook. Required.
+	Events []WebhookEvent `json:"events" yaml:"events"`
+
+	// Request is the HTTP request made by the Pact Broker. Required.
+	Request WebhookRequest `json:"request" yaml:"request"`
+
+	// Enabled may be set to false to disable the Webhook. Defaults to true.
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled"`
+}
+
+// WebhookPacticipant is the consumer or provider of a Webhook.
+type WebhookPacticipant struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// UnmarshalYAML allows the pacticipant to be given as just its name.
+func (p *WebhookPacticipant) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&p.Name); err == nil {
+		return nil
+	}
+
+	type plain WebhookPacticipant
+	return unmarshal((*plain)(p))
+}
+
+// WebhookEvent is an event that triggers a Webhook.
+type WebhookEvent struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// UnmarshalYAML allows the event to be given as just its name.
+func (e *WebhookEvent) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Name); err == nil {
+		return nil
+	}
+
+	type plain WebhookEvent
+	return unmarshal((*plain)(e))
+}
+
+// WebhookRequest is the HTTP request made by the Pact Broker when a Webhook
+// is triggered. The URL, headers and body may contain Pact Broker template
+// parameters such as ${pactbroker.consumerVersionNumber}.
+type WebhookRequest struct {
+	Method   string            `json:"method" yaml:"method"`
+	URL      string            `json:"url" yaml:"url"`
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers"`
+	Body     interface{}       `json:"body,omitempty" yaml:"body"`
+	Username string            `json:"username,omitempty" yaml:"username"`
+	Password string            `json:"password,omitempty" yaml:"password"`
+}
+
+// WebhookExecution is the outcome of test executing a Webhook.
+type WebhookExecution struct {
+	// Success is true if the Webhook request returned a successful status.
+	Success bool `json:"success"`
+
+	// Logs of the request and response made by the Pact Broker.
+	Logs string `json:"logs"`
+
+	// Response received by the Pact Broker, if any.
+	Response *struct {
+		Status  int                    `json:"status"`
+		Headers map[string]interface{} `json:"headers"`
+		Body    interface{}            `json:"body"`
+	} `json:"response"`
+}
+
+// Validate checks that the minimum fields are provided.
+func (w *Webhook) Validate() error {
+	if len(w.Events) == 0 {
+		return fmt.Errorf("Webhook events are mandatory")
+	}
+
+	for _, event := range w.Events {
+		if event.Name == "" {
+			return fmt.Errorf("Webhook event name is mandatory")
+		}
+	}
+
+	if w.Request.Method == "" {
+		return fmt.Errorf("Webhook request method is mandatory")
+	}
+
+	if w.Request.URL == "" {
+		return fmt.Errorf("Webhook request URL is mandatory")
+	}
+
+	return nil
+}
+
+// webhookFile is the format of a YAML file of Webhook definitions.
+type webhookFile struct {
+	Webhooks []Webhook `yaml:"webhooks"`
+}
+
+// LoadWebhooks reads the Webhook definitions from a YAML file, e.g.
+//
+//	webhooks:
+//	  - uuid: trigger-bobby-build-0001
+//	    description: Trigger bobby build
+//	    provider: bobby
+//	    events: [contract_content_changed]
+//	    request:
+//	      method: POST
+//	      url: https://ci.example.com/jobs/bobby/build
+//	      headers:
+//	        Content-Type: application/json
+//	      body:
+//	        pactUrl: ${pactbroker.pactUrl}
+func LoadWebhooks(file string) ([]Webhook, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseWebhooks(data)
+}
+
+// ParseWebhooks parses YAML Webhook definitions, see LoadWebhooks.
+func ParseWebhooks(data []byte) ([]Webhook, error) {
+	var f webhookFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	for i := range f.Webhooks {
+		// YAML maps are keyed by interface{}, which can not be sent as JSON
+		f.Webhooks[i].Request.Body = jsonValue(f.Webhooks[i].Request.Body)
+
+		if err := f.Webhooks[i].Validate(); err != nil {
+			return nil, fmt.Errorf("webhook %d: %v", i+1, err)
+		}
+	}
+
+	return f.Webhooks, nil
+}
+
+// jsonValue converts a value parsed from YAML into one that can be marshalled
+// as JSON.
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprintf("%v", k)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range t {
+			t[i] = jsonValue(val)
+		}
+		return t
+	}
+	return v
+}
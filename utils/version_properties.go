@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// VersionProperties describes the version of an application being built.
+type VersionProperties struct {
+	// Commit is the SHA of the commit being built.
+	Commit string
+
+	// Branch is the repository branch being built.
+	Branch string
+
+	// BuildURL links to the CI build.
+	BuildURL string
+}
+
+// Environment variables set by common CI systems, in order of preference.
+var (
+	commitEnvironmentVariables = []string{
+		"GIT_COMMIT",           // Jenkins
+		"GITHUB_SHA",           // GitHub Actions
+		"CI_COMMIT_SHA",        // GitLab
+		"BUILDKITE_COMMIT",     // Buildkite
+		"CIRCLE_SHA1",          // CircleCI
+		"TRAVIS_COMMIT",        // Travis CI
+		"BITBUCKET_COMMIT",     // Bitbucket Pipelines
+		"APPVEYOR_REPO_COMMIT", // AppVeyor
+		"WERCKER_GIT_COMMIT",   // Wercker
+	}
+	branchEnvironmentVariables = []string{
+		"GIT_BRANCH",           // Jenkins
+		"GITHUB_HEAD_REF",      // GitHub Actions, pull requests only
+		"GITHUB_REF_NAME",      // GitHub Actions
+		"CI_COMMIT_REF_NAME",   // GitLab
+		"BUILDKITE_BRANCH",     // Buildkite
+		"CIRCLE_BRANCH",        // CircleCI
+		"TRAVIS_BRANCH",        // Travis CI
+		"BITBUCKET_BRANCH",     // Bitbucket Pipelines
+		"APPVEYOR_REPO_BRANCH", // AppVeyor
+		"WERCKER_GIT_BRANCH",   // Wercker
+	}
+	buildURLEnvironmentVariables = []string{
+		"BUILD_URL",            // Jenkins
+		"CI_JOB_URL",           // GitLab
+		"BUILDKITE_BUILD_URL",  // Buildkite
+		"CIRCLE_BUILD_URL",     // CircleCI
+		"TRAVIS_BUILD_WEB_URL", // Travis CI
+		"WERCKER_RUN_URL",      // Wercker
+	}
+)
+
+// gitCommand runs git with the given arguments, returning its trimmed output.
+var gitCommand = func(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// DetectVersionProperties detects the commit, branch and build URL of the
+// current build from the environment variables of common CI systems, falling
+// back to the local git checkout for the commit and branch. Properties that
+// cannot be detected are left empty.
+func DetectVersionProperties() VersionProperties {
+	props := VersionProperties{
+		Commit:   firstEnvironmentVariable(commitEnvironmentVariables),
+		Branch:   firstEnvironmentVariable(branchEnvironmentVariables),
+		BuildURL: firstEnvironmentVariable(buildURLEnvironmentVariables),
+	}
+
+	// Jenkins reports the remote tracking branch, e.g. origin/master
+	props.Branch = strings.TrimPrefix(props.Branch, "origin/")
+
+	if props.BuildURL == "" && os.Getenv("GITHUB_RUN_ID") != "" {
+		props.BuildURL = fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+	}
+
+	if props.Commit == "" {
+		if commit, err := gitCommand("rev-parse", "HEAD"); err == nil {
+			props.Commit = commit
+		}
+	}
+
+	if props.Branch == "" {
+		// A detached HEAD has no branch
+		if branch, err := gitCommand("rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+			props.Branch = branch
+		}
+	}
+
+	return props
+}
+
+// firstEnvironmentVariable returns the value of the first of the given
+// environment variables that is set.
+func firstEnvironmentVariable(names []string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// clearCIEnvironment unsets any CI environment variables for the duration of
+// a test, returning a function to restore them.
+func clearCIEnvironment() func() {
+	saved := make(map[string]string)
+	names := append(append(append([]string{"GITHUB_RUN_ID", "GITHUB_SERVER_URL", "GITHUB_REPOSITORY"}, commitEnvironmentVariables...), branchEnvironmentVariables...), buildURLEnvironmentVariables...)
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			saved[name] = value
+			os.Unsetenv(name)
+		}
+	}
+
+	return func() {
+		for _, name := range names {
+			os.Unsetenv(name)
+		}
+		for name, value := range saved {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+func stubGit(commit string, branch string, err error) func() {
+	old := gitCommand
+	gitCommand = func(args ...string) (string, error) {
+		if len(args) == 2 {
+			return commit, err
+		}
+		return branch, err
+	}
+	return func() { gitCommand = old }
+}
+
+func Test_DetectVersionPropertiesFromCI(t *testing.T) {
+	defer clearCIEnvironment()()
+	defer stubGit("abc", "feat/local", nil)()
+
+	os.Setenv("GIT_COMMIT", "1234")
+	os.Setenv("GIT_BRANCH", "origin/master")
+	os.Setenv("BUILD_URL", "http://jenkins/job/1")
+
+	props := DetectVersionProperties()
+
+	if props.Commit != "1234" || props.Branch != "master" || props.BuildURL != "http://jenkins/job/1" {
+		t.Fatalf("Expected properties from Jenkins but got %+v", props)
+	}
+}
+
+func Test_DetectVersionPropertiesFromGitHub(t *testing.T) {
+	defer clearCIEnvironment()()
+	defer stubGit("abc", "feat/local", nil)()
+
+	os.Setenv("GITHUB_SHA", "1234")
+	os.Setenv("GITHUB_REF_NAME", "master")
+	os.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	os.Setenv("GITHUB_REPOSITORY", "pact-foundation/pact-go")
+	os.Setenv("GITHUB_RUN_ID", "42")
+
+	props := DetectVersionProperties()
+
+	if props.BuildURL != "https://github.com/pact-foundation/pact-go/actions/runs/42" {
+		t.Fatalf("Expected a GitHub Actions build URL but got '%s'", props.BuildURL)
+	}
+}
+
+func Test_DetectVersionPropertiesFromGit(t *testing.T) {
+	defer clearCIEnvironment()()
+	defer stubGit("abc", "feat/local", nil)()
+
+	props := DetectVersionProperties()
+
+	if props.Commit != "abc" || props.Branch != "feat/local" || props.BuildURL != "" {
+		t.Fatalf("Expected properties from git but got %+v", props)
+	}
+}
+
+func Test_DetectVersionPropertiesDetachedHead(t *testing.T) {
+	defer clearCIEnvironment()()
+	defer stubGit("abc", "HEAD", nil)()
+
+	props := DetectVersionProperties()
+
+	if props.Branch != "" {
+		t.Fatalf("Expected no branch but got '%s'", props.Branch)
+	}
+}
+
+func Test_DetectVersionPropertiesNoGit(t *testing.T) {
+	defer clearCIEnvironment()()
+	defer stubGit("", "", errors.New("not a git repository"))()
+
+	props := DetectVersionProperties()
+
+	if props != (VersionProperties{}) {
+		t.Fatalf("Expected no properties but got %+v", props)
+	}
+}